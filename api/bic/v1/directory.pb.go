@@ -0,0 +1,836 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: bic/v1/directory.proto
+
+package bicv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Bank struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode     string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	BankName      string                 `protobuf:"bytes,2,opt,name=bank_name,json=bankName,proto3" json:"bank_name,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	CountryIso2   string                 `protobuf:"bytes,4,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	CountryName   string                 `protobuf:"bytes,5,opt,name=country_name,json=countryName,proto3" json:"country_name,omitempty"`
+	IsHeadquarter bool                   `protobuf:"varint,6,opt,name=is_headquarter,json=isHeadquarter,proto3" json:"is_headquarter,omitempty"`
+	Branches      []*BankBranch          `protobuf:"bytes,7,rep,name=branches,proto3" json:"branches,omitempty"`
+	// version identifies the state of the record, see DeleteRequest.
+	Version       int64 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bank) Reset() {
+	*x = Bank{}
+	mi := &file_bic_v1_directory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bank) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bank) ProtoMessage() {}
+
+func (x *Bank) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bank.ProtoReflect.Descriptor instead.
+func (*Bank) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bank) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *Bank) GetBankName() string {
+	if x != nil {
+		return x.BankName
+	}
+	return ""
+}
+
+func (x *Bank) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Bank) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *Bank) GetCountryName() string {
+	if x != nil {
+		return x.CountryName
+	}
+	return ""
+}
+
+func (x *Bank) GetIsHeadquarter() bool {
+	if x != nil {
+		return x.IsHeadquarter
+	}
+	return false
+}
+
+func (x *Bank) GetBranches() []*BankBranch {
+	if x != nil {
+		return x.Branches
+	}
+	return nil
+}
+
+func (x *Bank) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type BankBranch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode     string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	BankName      string                 `protobuf:"bytes,2,opt,name=bank_name,json=bankName,proto3" json:"bank_name,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	CountryIso2   string                 `protobuf:"bytes,4,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	IsHeadquarter bool                   `protobuf:"varint,5,opt,name=is_headquarter,json=isHeadquarter,proto3" json:"is_headquarter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BankBranch) Reset() {
+	*x = BankBranch{}
+	mi := &file_bic_v1_directory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BankBranch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BankBranch) ProtoMessage() {}
+
+func (x *BankBranch) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BankBranch.ProtoReflect.Descriptor instead.
+func (*BankBranch) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BankBranch) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *BankBranch) GetBankName() string {
+	if x != nil {
+		return x.BankName
+	}
+	return ""
+}
+
+func (x *BankBranch) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *BankBranch) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *BankBranch) GetIsHeadquarter() bool {
+	if x != nil {
+		return x.IsHeadquarter
+	}
+	return false
+}
+
+type CountryBanks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CountryIso2   string                 `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	CountryName   string                 `protobuf:"bytes,2,opt,name=country_name,json=countryName,proto3" json:"country_name,omitempty"`
+	SwiftCodes    []*BankBranch          `protobuf:"bytes,3,rep,name=swift_codes,json=swiftCodes,proto3" json:"swift_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountryBanks) Reset() {
+	*x = CountryBanks{}
+	mi := &file_bic_v1_directory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountryBanks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountryBanks) ProtoMessage() {}
+
+func (x *CountryBanks) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountryBanks.ProtoReflect.Descriptor instead.
+func (*CountryBanks) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CountryBanks) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *CountryBanks) GetCountryName() string {
+	if x != nil {
+		return x.CountryName
+	}
+	return ""
+}
+
+func (x *CountryBanks) GetSwiftCodes() []*BankBranch {
+	if x != nil {
+		return x.SwiftCodes
+	}
+	return nil
+}
+
+type GetSwiftCodeRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	// as_of asks for the data that was valid at the given instant.
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSwiftCodeRequest) Reset() {
+	*x = GetSwiftCodeRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSwiftCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSwiftCodeRequest) ProtoMessage() {}
+
+func (x *GetSwiftCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSwiftCodeRequest.ProtoReflect.Descriptor instead.
+func (*GetSwiftCodeRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSwiftCodeRequest) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *GetSwiftCodeRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type ListByCountryRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	CountryIso2 string                 `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	// as_of asks for the data that was valid at the given instant.
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListByCountryRequest) Reset() {
+	*x = ListByCountryRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListByCountryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListByCountryRequest) ProtoMessage() {}
+
+func (x *ListByCountryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListByCountryRequest.ProtoReflect.Descriptor instead.
+func (*ListByCountryRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListByCountryRequest) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+func (x *ListByCountryRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type BatchLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCodes    []string               `protobuf:"bytes,1,rep,name=swift_codes,json=swiftCodes,proto3" json:"swift_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupRequest) Reset() {
+	*x = BatchLookupRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupRequest) ProtoMessage() {}
+
+func (x *BatchLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupRequest.ProtoReflect.Descriptor instead.
+func (*BatchLookupRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchLookupRequest) GetSwiftCodes() []string {
+	if x != nil {
+		return x.SwiftCodes
+	}
+	return nil
+}
+
+type BatchLookupResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// results are in the order of the requested SWIFT codes.
+	Results       []*LookupResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupResponse) Reset() {
+	*x = BatchLookupResponse{}
+	mi := &file_bic_v1_directory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupResponse) ProtoMessage() {}
+
+func (x *BatchLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupResponse.ProtoReflect.Descriptor instead.
+func (*BatchLookupResponse) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchLookupResponse) GetResults() []*LookupResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type LookupResult struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	// bank is set if the SWIFT code was found, error otherwise.
+	Bank          *Bank  `protobuf:"bytes,2,opt,name=bank,proto3" json:"bank,omitempty"`
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupResult) Reset() {
+	*x = LookupResult{}
+	mi := &file_bic_v1_directory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupResult) ProtoMessage() {}
+
+func (x *LookupResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupResult.ProtoReflect.Descriptor instead.
+func (*LookupResult) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LookupResult) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *LookupResult) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+func (x *LookupResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type CreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bank          *Bank                  `protobuf:"bytes,1,opt,name=bank,proto3" json:"bank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateRequest) GetBank() *Bank {
+	if x != nil {
+		return x.Bank
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SwiftCode string                 `protobuf:"bytes,1,opt,name=swift_code,json=swiftCode,proto3" json:"swift_code,omitempty"`
+	// version is required and makes the deletion conditional: it fails with FAILED_PRECONDITION if it
+	// is not set, and with ABORTED if the record has been modified since it was read with this version.
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteRequest) GetSwiftCode() string {
+	if x != nil {
+		return x.SwiftCode
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_bic_v1_directory_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{10}
+}
+
+type ExportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// country_iso2, if set, limits the export to one country.
+	CountryIso2   string `protobuf:"bytes,1,opt,name=country_iso2,json=countryIso2,proto3" json:"country_iso2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_bic_v1_directory_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bic_v1_directory_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_bic_v1_directory_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExportRequest) GetCountryIso2() string {
+	if x != nil {
+		return x.CountryIso2
+	}
+	return ""
+}
+
+var File_bic_v1_directory_proto protoreflect.FileDescriptor
+
+const file_bic_v1_directory_proto_rawDesc = "" +
+	"\n" +
+	"\x16bic/v1/directory.proto\x12\x06bic.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x93\x02\n" +
+	"\x04Bank\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12\x1b\n" +
+	"\tbank_name\x18\x02 \x01(\tR\bbankName\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12!\n" +
+	"\fcountry_iso2\x18\x04 \x01(\tR\vcountryIso2\x12!\n" +
+	"\fcountry_name\x18\x05 \x01(\tR\vcountryName\x12%\n" +
+	"\x0eis_headquarter\x18\x06 \x01(\bR\risHeadquarter\x12.\n" +
+	"\bbranches\x18\a \x03(\v2\x12.bic.v1.BankBranchR\bbranches\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\"\xac\x01\n" +
+	"\n" +
+	"BankBranch\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12\x1b\n" +
+	"\tbank_name\x18\x02 \x01(\tR\bbankName\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12!\n" +
+	"\fcountry_iso2\x18\x04 \x01(\tR\vcountryIso2\x12%\n" +
+	"\x0eis_headquarter\x18\x05 \x01(\bR\risHeadquarter\"\x89\x01\n" +
+	"\fCountryBanks\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso2\x12!\n" +
+	"\fcountry_name\x18\x02 \x01(\tR\vcountryName\x123\n" +
+	"\vswift_codes\x18\x03 \x03(\v2\x12.bic.v1.BankBranchR\n" +
+	"swiftCodes\"e\n" +
+	"\x13GetSwiftCodeRequest\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12/\n" +
+	"\x05as_of\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"j\n" +
+	"\x14ListByCountryRequest\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso2\x12/\n" +
+	"\x05as_of\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"5\n" +
+	"\x12BatchLookupRequest\x12\x1f\n" +
+	"\vswift_codes\x18\x01 \x03(\tR\n" +
+	"swiftCodes\"E\n" +
+	"\x13BatchLookupResponse\x12.\n" +
+	"\aresults\x18\x01 \x03(\v2\x14.bic.v1.LookupResultR\aresults\"e\n" +
+	"\fLookupResult\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12 \n" +
+	"\x04bank\x18\x02 \x01(\v2\f.bic.v1.BankR\x04bank\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"1\n" +
+	"\rCreateRequest\x12 \n" +
+	"\x04bank\x18\x01 \x01(\v2\f.bic.v1.BankR\x04bank\"H\n" +
+	"\rDeleteRequest\x12\x1d\n" +
+	"\n" +
+	"swift_code\x18\x01 \x01(\tR\tswiftCode\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\x10\n" +
+	"\x0eDeleteResponse\"2\n" +
+	"\rExportRequest\x12!\n" +
+	"\fcountry_iso2\x18\x01 \x01(\tR\vcountryIso22\xef\x02\n" +
+	"\fBicDirectory\x129\n" +
+	"\fGetSwiftCode\x12\x1b.bic.v1.GetSwiftCodeRequest\x1a\f.bic.v1.Bank\x12C\n" +
+	"\rListByCountry\x12\x1c.bic.v1.ListByCountryRequest\x1a\x14.bic.v1.CountryBanks\x12F\n" +
+	"\vBatchLookup\x12\x1a.bic.v1.BatchLookupRequest\x1a\x1b.bic.v1.BatchLookupResponse\x12-\n" +
+	"\x06Create\x12\x15.bic.v1.CreateRequest\x1a\f.bic.v1.Bank\x127\n" +
+	"\x06Delete\x12\x15.bic.v1.DeleteRequest\x1a\x16.bic.v1.DeleteResponse\x12/\n" +
+	"\x06Export\x12\x15.bic.v1.ExportRequest\x1a\f.bic.v1.Bank0\x01B9Z7github.com/pkacprzak5/bic-data-service/api/bic/v1;bicv1b\x06proto3"
+
+var (
+	file_bic_v1_directory_proto_rawDescOnce sync.Once
+	file_bic_v1_directory_proto_rawDescData []byte
+)
+
+func file_bic_v1_directory_proto_rawDescGZIP() []byte {
+	file_bic_v1_directory_proto_rawDescOnce.Do(func() {
+		file_bic_v1_directory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bic_v1_directory_proto_rawDesc), len(file_bic_v1_directory_proto_rawDesc)))
+	})
+	return file_bic_v1_directory_proto_rawDescData
+}
+
+var file_bic_v1_directory_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_bic_v1_directory_proto_goTypes = []any{
+	(*Bank)(nil),                  // 0: bic.v1.Bank
+	(*BankBranch)(nil),            // 1: bic.v1.BankBranch
+	(*CountryBanks)(nil),          // 2: bic.v1.CountryBanks
+	(*GetSwiftCodeRequest)(nil),   // 3: bic.v1.GetSwiftCodeRequest
+	(*ListByCountryRequest)(nil),  // 4: bic.v1.ListByCountryRequest
+	(*BatchLookupRequest)(nil),    // 5: bic.v1.BatchLookupRequest
+	(*BatchLookupResponse)(nil),   // 6: bic.v1.BatchLookupResponse
+	(*LookupResult)(nil),          // 7: bic.v1.LookupResult
+	(*CreateRequest)(nil),         // 8: bic.v1.CreateRequest
+	(*DeleteRequest)(nil),         // 9: bic.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 10: bic.v1.DeleteResponse
+	(*ExportRequest)(nil),         // 11: bic.v1.ExportRequest
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_bic_v1_directory_proto_depIdxs = []int32{
+	1,  // 0: bic.v1.Bank.branches:type_name -> bic.v1.BankBranch
+	1,  // 1: bic.v1.CountryBanks.swift_codes:type_name -> bic.v1.BankBranch
+	12, // 2: bic.v1.GetSwiftCodeRequest.as_of:type_name -> google.protobuf.Timestamp
+	12, // 3: bic.v1.ListByCountryRequest.as_of:type_name -> google.protobuf.Timestamp
+	7,  // 4: bic.v1.BatchLookupResponse.results:type_name -> bic.v1.LookupResult
+	0,  // 5: bic.v1.LookupResult.bank:type_name -> bic.v1.Bank
+	0,  // 6: bic.v1.CreateRequest.bank:type_name -> bic.v1.Bank
+	3,  // 7: bic.v1.BicDirectory.GetSwiftCode:input_type -> bic.v1.GetSwiftCodeRequest
+	4,  // 8: bic.v1.BicDirectory.ListByCountry:input_type -> bic.v1.ListByCountryRequest
+	5,  // 9: bic.v1.BicDirectory.BatchLookup:input_type -> bic.v1.BatchLookupRequest
+	8,  // 10: bic.v1.BicDirectory.Create:input_type -> bic.v1.CreateRequest
+	9,  // 11: bic.v1.BicDirectory.Delete:input_type -> bic.v1.DeleteRequest
+	11, // 12: bic.v1.BicDirectory.Export:input_type -> bic.v1.ExportRequest
+	0,  // 13: bic.v1.BicDirectory.GetSwiftCode:output_type -> bic.v1.Bank
+	2,  // 14: bic.v1.BicDirectory.ListByCountry:output_type -> bic.v1.CountryBanks
+	6,  // 15: bic.v1.BicDirectory.BatchLookup:output_type -> bic.v1.BatchLookupResponse
+	0,  // 16: bic.v1.BicDirectory.Create:output_type -> bic.v1.Bank
+	10, // 17: bic.v1.BicDirectory.Delete:output_type -> bic.v1.DeleteResponse
+	0,  // 18: bic.v1.BicDirectory.Export:output_type -> bic.v1.Bank
+	13, // [13:19] is the sub-list for method output_type
+	7,  // [7:13] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_bic_v1_directory_proto_init() }
+func file_bic_v1_directory_proto_init() {
+	if File_bic_v1_directory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bic_v1_directory_proto_rawDesc), len(file_bic_v1_directory_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bic_v1_directory_proto_goTypes,
+		DependencyIndexes: file_bic_v1_directory_proto_depIdxs,
+		MessageInfos:      file_bic_v1_directory_proto_msgTypes,
+	}.Build()
+	File_bic_v1_directory_proto = out.File
+	file_bic_v1_directory_proto_goTypes = nil
+	file_bic_v1_directory_proto_depIdxs = nil
+}
@@ -0,0 +1,335 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: bic/v1/directory.proto
+
+package bicv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BicDirectory_GetSwiftCode_FullMethodName  = "/bic.v1.BicDirectory/GetSwiftCode"
+	BicDirectory_ListByCountry_FullMethodName = "/bic.v1.BicDirectory/ListByCountry"
+	BicDirectory_BatchLookup_FullMethodName   = "/bic.v1.BicDirectory/BatchLookup"
+	BicDirectory_Create_FullMethodName        = "/bic.v1.BicDirectory/Create"
+	BicDirectory_Delete_FullMethodName        = "/bic.v1.BicDirectory/Delete"
+	BicDirectory_Export_FullMethodName        = "/bic.v1.BicDirectory/Export"
+)
+
+// BicDirectoryClient is the client API for BicDirectory service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BicDirectory gives typed access to the SWIFT codes served by the REST API. It applies the same
+// validation, and reports errors with the standard gRPC status codes:
+// INVALID_ARGUMENT for malformed requests, NOT_FOUND, ALREADY_EXISTS, ABORTED when a record
+// has been modified by someone else, and UNAVAILABLE when the database cannot be reached.
+type BicDirectoryClient interface {
+	// GetSwiftCode returns a bank; a headquarter includes its branches.
+	GetSwiftCode(ctx context.Context, in *GetSwiftCodeRequest, opts ...grpc.CallOption) (*Bank, error)
+	// ListByCountry returns all SWIFT codes of a country.
+	ListByCountry(ctx context.Context, in *ListByCountryRequest, opts ...grpc.CallOption) (*CountryBanks, error)
+	// BatchLookup looks up to 1000 SWIFT codes at once. A code which is invalid or not found does not
+	// fail the whole call, its result carries the error instead.
+	BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error)
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Bank, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Export streams every stored record ordered by SWIFT code, without branch aggregation.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Bank], error)
+}
+
+type bicDirectoryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBicDirectoryClient(cc grpc.ClientConnInterface) BicDirectoryClient {
+	return &bicDirectoryClient{cc}
+}
+
+func (c *bicDirectoryClient) GetSwiftCode(ctx context.Context, in *GetSwiftCodeRequest, opts ...grpc.CallOption) (*Bank, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bank)
+	err := c.cc.Invoke(ctx, BicDirectory_GetSwiftCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bicDirectoryClient) ListByCountry(ctx context.Context, in *ListByCountryRequest, opts ...grpc.CallOption) (*CountryBanks, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountryBanks)
+	err := c.cc.Invoke(ctx, BicDirectory_ListByCountry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bicDirectoryClient) BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchLookupResponse)
+	err := c.cc.Invoke(ctx, BicDirectory_BatchLookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bicDirectoryClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Bank, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bank)
+	err := c.cc.Invoke(ctx, BicDirectory_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bicDirectoryClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, BicDirectory_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bicDirectoryClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Bank], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BicDirectory_ServiceDesc.Streams[0], BicDirectory_Export_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRequest, Bank]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BicDirectory_ExportClient = grpc.ServerStreamingClient[Bank]
+
+// BicDirectoryServer is the server API for BicDirectory service.
+// All implementations must embed UnimplementedBicDirectoryServer
+// for forward compatibility.
+//
+// BicDirectory gives typed access to the SWIFT codes served by the REST API. It applies the same
+// validation, and reports errors with the standard gRPC status codes:
+// INVALID_ARGUMENT for malformed requests, NOT_FOUND, ALREADY_EXISTS, ABORTED when a record
+// has been modified by someone else, and UNAVAILABLE when the database cannot be reached.
+type BicDirectoryServer interface {
+	// GetSwiftCode returns a bank; a headquarter includes its branches.
+	GetSwiftCode(context.Context, *GetSwiftCodeRequest) (*Bank, error)
+	// ListByCountry returns all SWIFT codes of a country.
+	ListByCountry(context.Context, *ListByCountryRequest) (*CountryBanks, error)
+	// BatchLookup looks up to 1000 SWIFT codes at once. A code which is invalid or not found does not
+	// fail the whole call, its result carries the error instead.
+	BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error)
+	Create(context.Context, *CreateRequest) (*Bank, error)
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Export streams every stored record ordered by SWIFT code, without branch aggregation.
+	Export(*ExportRequest, grpc.ServerStreamingServer[Bank]) error
+	mustEmbedUnimplementedBicDirectoryServer()
+}
+
+// UnimplementedBicDirectoryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBicDirectoryServer struct{}
+
+func (UnimplementedBicDirectoryServer) GetSwiftCode(context.Context, *GetSwiftCodeRequest) (*Bank, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSwiftCode not implemented")
+}
+func (UnimplementedBicDirectoryServer) ListByCountry(context.Context, *ListByCountryRequest) (*CountryBanks, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListByCountry not implemented")
+}
+func (UnimplementedBicDirectoryServer) BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedBicDirectoryServer) Create(context.Context, *CreateRequest) (*Bank, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedBicDirectoryServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedBicDirectoryServer) Export(*ExportRequest, grpc.ServerStreamingServer[Bank]) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedBicDirectoryServer) mustEmbedUnimplementedBicDirectoryServer() {}
+func (UnimplementedBicDirectoryServer) testEmbeddedByValue()                      {}
+
+// UnsafeBicDirectoryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BicDirectoryServer will
+// result in compilation errors.
+type UnsafeBicDirectoryServer interface {
+	mustEmbedUnimplementedBicDirectoryServer()
+}
+
+func RegisterBicDirectoryServer(s grpc.ServiceRegistrar, srv BicDirectoryServer) {
+	// If the following call pancis, it indicates UnimplementedBicDirectoryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BicDirectory_ServiceDesc, srv)
+}
+
+func _BicDirectory_GetSwiftCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSwiftCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BicDirectoryServer).GetSwiftCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BicDirectory_GetSwiftCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BicDirectoryServer).GetSwiftCode(ctx, req.(*GetSwiftCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BicDirectory_ListByCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListByCountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BicDirectoryServer).ListByCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BicDirectory_ListByCountry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BicDirectoryServer).ListByCountry(ctx, req.(*ListByCountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BicDirectory_BatchLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BicDirectoryServer).BatchLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BicDirectory_BatchLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BicDirectoryServer).BatchLookup(ctx, req.(*BatchLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BicDirectory_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BicDirectoryServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BicDirectory_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BicDirectoryServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BicDirectory_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BicDirectoryServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BicDirectory_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BicDirectoryServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BicDirectory_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BicDirectoryServer).Export(m, &grpc.GenericServerStream[ExportRequest, Bank]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BicDirectory_ExportServer = grpc.ServerStreamingServer[Bank]
+
+// BicDirectory_ServiceDesc is the grpc.ServiceDesc for BicDirectory service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BicDirectory_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bic.v1.BicDirectory",
+	HandlerType: (*BicDirectoryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSwiftCode",
+			Handler:    _BicDirectory_GetSwiftCode_Handler,
+		},
+		{
+			MethodName: "ListByCountry",
+			Handler:    _BicDirectory_ListByCountry_Handler,
+		},
+		{
+			MethodName: "BatchLookup",
+			Handler:    _BicDirectory_BatchLookup_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _BicDirectory_Create_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _BicDirectory_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Export",
+			Handler:       _BicDirectory_Export_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bic/v1/directory.proto",
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/pkg/client"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func runGet(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var conn connection
+	flags := newFlagSet("get", stderr)
+	conn.register(flags)
+	output := outputFlag(flags, outputTable)
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("get: exactly one SWIFT code is required")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	c, err := conn.client()
+	if err != nil {
+		return err
+	}
+	bank, err := c.GetSwiftCode(ctx, positional[0])
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	return write(stdout, *output, bankDetails(*bank))
+}
+
+func runList(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var conn connection
+	flags := newFlagSet("list", stderr)
+	conn.register(flags)
+	output := outputFlag(flags, outputTable)
+	iso2Code := flags.String("country", "", "ISO2 code of the country (required)")
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("list: unexpected argument %q", positional[0])
+	}
+	if *iso2Code == "" {
+		return errors.New("list: --country is required")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	c, err := conn.client()
+	if err != nil {
+		return err
+	}
+	countryBanks, err := c.GetCountry(ctx, strings.ToUpper(*iso2Code))
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+	return write(stdout, *output, countryDetails(*countryBanks))
+}
+
+func runAdd(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var conn connection
+	flags := newFlagSet("add", stderr)
+	conn.register(flags)
+	input := registerBankInput(flags)
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("add: unexpected argument %q", positional[0])
+	}
+
+	banks, err := input.banks(flags)
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	// nothing is sent unless every bank is valid, so that a file is not half imported
+	if !validate(banks, input.countryNames, stderr) {
+		return errInvalid
+	}
+
+	c, err := conn.client()
+	if err != nil {
+		return err
+	}
+	// each batch is added in a single transaction, all of its banks or none
+	for i := 0; i < len(banks); i += client.MaxBatchSize {
+		batch := banks[i:min(i+client.MaxBatchSize, len(banks))]
+		err := c.AddSwiftCodes(ctx, batch)
+		if err != nil && errors.Is(err, client.ErrNotSupported) {
+			fmt.Fprintln(stderr, "the server cannot add batches, the banks are added one at a time")
+			return addEach(ctx, c, banks[i:], stdout)
+		} else if err != nil {
+			return fmt.Errorf("add: %w", err)
+		}
+
+		for _, bank := range batch {
+			fmt.Fprintf(stdout, "added %s\n", *bank.SwiftCode)
+		}
+	}
+	return nil
+}
+
+// addEach adds the banks one by one, for a server which cannot add batches.
+func addEach(ctx context.Context, c *client.Client, banks []client.Bank, stdout io.Writer) error {
+	for _, bank := range banks {
+		if err := c.AddSwiftCode(ctx, bank); err != nil {
+			return fmt.Errorf("add: %s: %w", *bank.SwiftCode, err)
+		}
+		fmt.Fprintf(stdout, "added %s\n", *bank.SwiftCode)
+	}
+	return nil
+}
+
+func runDelete(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var conn connection
+	flags := newFlagSet("delete", stderr)
+	conn.register(flags)
+	etag := flags.String("etag", "", "delete only if the bank still has this ETag")
+	force := flags.Bool("force", false, "delete the bank whatever its version, instead of --etag")
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("delete: exactly one SWIFT code is required")
+	}
+	if (*etag == "") == !*force {
+		return errors.New("delete: either --etag or --force is required")
+	}
+	if *force {
+		*etag = client.AnyETag
+	}
+
+	c, err := conn.client()
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteSwiftCode(ctx, positional[0], *etag); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	fmt.Fprintf(stdout, "deleted %s\n", positional[0])
+	return nil
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("validate", stderr)
+	input := registerBankInput(flags)
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("validate: unexpected argument %q", positional[0])
+	}
+
+	banks, err := input.banks(flags)
+	if err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	if !validate(banks, input.countryNames, stderr) {
+		return errInvalid
+	}
+	fmt.Fprintf(stdout, "%d valid\n", len(banks))
+	return nil
+}
+
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var conn connection
+	flags := newFlagSet("export", stderr)
+	conn.register(flags)
+	output := outputFlag(flags, outputJSON)
+	iso2Code := flags.String("country", "", "export only the banks of this country")
+
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("export: unexpected argument %q", positional[0])
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	c, err := conn.client()
+	if err != nil {
+		return err
+	}
+
+	// without --country, only the countries which have SWIFT codes are requested
+	countries := []string{strings.ToUpper(*iso2Code)}
+	if *iso2Code == "" {
+		stored, err := c.ListCountries(ctx)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		countries = countries[:0]
+		for _, stats := range stored {
+			countries = append(countries, stats.CountryISO2)
+		}
+		slices.Sort(countries)
+	}
+
+	var exported banks
+	for _, code := range countries {
+		countryBanks, err := c.GetCountry(ctx, code)
+		if err != nil && errors.Is(err, client.ErrISO2CodeNotFound) && *iso2Code == "" {
+			// the last SWIFT code of the country was deleted after the countries were listed
+			continue
+		} else if err != nil {
+			return fmt.Errorf("export: %s: %w", code, err)
+		}
+
+		slices.SortFunc(countryBanks.SwiftCodes, func(a, b client.BankBranch) int {
+			return strings.Compare(a.SwiftCode, b.SwiftCode)
+		})
+		for _, branch := range countryBanks.SwiftCodes {
+			exported = append(exported, client.Bank{
+				Address:       &branch.Address,
+				BankName:      &branch.BankName,
+				CountryISO2:   &branch.CountryISO2,
+				CountryName:   &countryBanks.CountryName,
+				IsHeadquarter: &branch.IsHeadquarter,
+				SwiftCode:     &branch.SwiftCode,
+			})
+		}
+	}
+	return write(stdout, *output, exported)
+}
+
+// validate reports the banks which do not pass the service's validation and tells whether all passed.
+// The canonical country names set by the validation are sent to the service.
+func validate(banks []client.Bank, countryNames bankdata.CountryNames, stderr io.Writer) bool {
+	valid := true
+	for i, bank := range banks {
+		err := bankdata.Validate(bankdata.Record{
+			Address:       bank.Address,
+			BankName:      bank.BankName,
+			CountryISO2:   bank.CountryISO2,
+			CountryName:   bank.CountryName,
+			IsHeadquarter: bank.IsHeadquarter,
+			SwiftCode:     bank.SwiftCode,
+		}, countryNames)
+		if err != nil {
+			name := fmt.Sprintf("bank %d", i+1)
+			if bank.SwiftCode != nil && *bank.SwiftCode != "" {
+				name = *bank.SwiftCode
+			}
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			valid = false
+		}
+	}
+	return valid
+}
+
+// bankInput holds the flags describing the banks given to add and validate.
+type bankInput struct {
+	file          string
+	swiftCode     string
+	bankName      string
+	address       string
+	countryISO2   string
+	countryName   string
+	isHeadquarter bool
+	countryNames  bankdata.CountryNames
+}
+
+func registerBankInput(flags *flag.FlagSet) *bankInput {
+	var input bankInput
+	flags.StringVar(&input.file, "file", "",
+		`JSON file with a bank or an array of banks, or a CSV file in the snapshot format ("-" for standard input)`)
+	flags.StringVar(&input.swiftCode, "swift-code", "", "SWIFT code of the bank")
+	flags.StringVar(&input.bankName, "bank-name", "", "name of the bank")
+	flags.StringVar(&input.address, "address", "", "address of the bank")
+	flags.StringVar(&input.countryISO2, "country", "", "ISO2 code of the country")
+	flags.StringVar(&input.countryName, "country-name", "", "name of the country")
+	flags.BoolVar(&input.isHeadquarter, "headquarter", false,
+		`whether the bank is a headquarter (by default, whether the SWIFT code ends with "XXX")`)
+	flags.Func("country-names", `accepted country names, as configured on the server: aliases (default) or strict`,
+		func(value string) error {
+			switch names := bankdata.CountryNames(value); names {
+			case bankdata.CountryNamesAliases, bankdata.CountryNamesStrict:
+				input.countryNames = names
+				return nil
+			default:
+				return errors.New("use aliases or strict")
+			}
+		})
+	input.countryNames = bankdata.CountryNamesAliases
+	return &input
+}
+
+// banks returns the banks read from the file, or the one described by the other flags.
+func (in *bankInput) banks(flags *flag.FlagSet) ([]client.Bank, error) {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if in.file != "" {
+		for _, name := range []string{"swift-code", "bank-name", "address", "country", "country-name", "headquarter"} {
+			if set[name] {
+				return nil, fmt.Errorf("--%s cannot be combined with --file", name)
+			}
+		}
+		return readBanks(in.file)
+	}
+
+	// only the flags given are set, so that the missing ones are reported as required
+	var bank client.Bank
+	optional := func(name string, value *string) *string {
+		if !set[name] {
+			return nil
+		}
+		return value
+	}
+	bank.SwiftCode = optional("swift-code", &in.swiftCode)
+	bank.BankName = optional("bank-name", &in.bankName)
+	bank.Address = optional("address", &in.address)
+	bank.CountryISO2 = optional("country", &in.countryISO2)
+	bank.CountryName = optional("country-name", &in.countryName)
+	if !set["headquarter"] {
+		in.isHeadquarter = strings.HasSuffix(in.swiftCode, "XXX")
+	}
+	bank.IsHeadquarter = &in.isHeadquarter
+	return []client.Bank{bank}, nil
+}
+
+func readBanks(path string) ([]client.Bank, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		records, err := bankdata.ReadSnapshot(r)
+		if err != nil {
+			return nil, err
+		}
+		banks := make([]client.Bank, len(records))
+		for i, b := range records {
+			banks[i] = client.Bank{
+				Address:       b.Address,
+				BankName:      b.BankName,
+				CountryISO2:   b.CountryISO2,
+				CountryName:   b.CountryName,
+				IsHeadquarter: b.IsHeadquarter,
+				SwiftCode:     b.SwiftCode,
+			}
+		}
+		return banks, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var banks []client.Bank
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var bank client.Bank
+		if err := json.Unmarshal(data, &bank); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		banks = append(banks, bank)
+	} else if err := json.Unmarshal(data, &banks); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(banks) == 0 {
+		return nil, fmt.Errorf("%s contains no banks", path)
+	}
+	return banks, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/pkg/client"
+	"io"
+	"os"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// connection holds the flags shared by the commands which talk to the server.
+type connection struct {
+	server string
+	apiKey string
+}
+
+func (c *connection) register(flags *flag.FlagSet) {
+	flags.StringVar(&c.server, "server", getEnv("BICCTL_SERVER", "http://localhost:8080"),
+		"URL of the BIC Data Service, or BICCTL_SERVER")
+	flags.StringVar(&c.apiKey, "api-key", os.Getenv("BICCTL_API_KEY"),
+		"API key sent as a bearer token, or BICCTL_API_KEY")
+}
+
+func (c *connection) client() (*client.Client, error) {
+	var opts []client.Option
+	if c.apiKey != "" {
+		opts = append(opts, client.WithHeader("Authorization", "Bearer "+c.apiKey))
+	}
+	return client.New(c.server, opts...)
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+func outputFlag(flags *flag.FlagSet, defaultFormat string) *string {
+	return flags.String("output", defaultFormat, "output format: table, json or csv")
+}
+
+func checkOutput(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// parse parses flags given before and after the positional arguments, which are returned.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
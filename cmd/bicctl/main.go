@@ -0,0 +1,86 @@
+// Command bicctl is the command-line client of the BIC Data Service.
+//
+//	bicctl get BREXPLPWXXX
+//	bicctl list --country PL --output csv
+//	bicctl add --file banks.json
+//	bicctl delete BREXPLPWWAL --force
+//	bicctl validate --file banks.csv
+//	bicctl export --country PL > pl.csv
+//
+// The server URL and API key are read from the --server and --api-key flags, or from the
+// BICCTL_SERVER and BICCTL_API_KEY environment variables.
+//
+// add and validate check the banks with the rules of the service before anything is sent, accepting
+// the country names of --country-names, and add sends them in batches which are added all or nothing.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: bicctl <command> [flags] [arguments]
+
+Commands:
+  get <swift-code>       show a bank, with the branches of a headquarter
+  list --country <iso2>  list the SWIFT codes of a country
+  add                    add banks given with flags or in a JSON or CSV file (--file)
+  delete <swift-code>    delete a bank
+  validate               check banks given with flags or in a file, without contacting the server
+  export                 write all banks, or those of one country, as JSON or CSV
+
+Run "bicctl <command> --help" for the flags of a command.
+`
+
+// errInvalid is returned when some of the given banks do not pass validation, after they have been reported.
+var errInvalid = errors.New("invalid bank data")
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, errInvalid) {
+			fmt.Fprintln(os.Stderr, "bicctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("command is required")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "get":
+		return runGet(ctx, args, stdout, stderr)
+	case "list":
+		return runList(ctx, args, stdout, stderr)
+	case "add":
+		return runAdd(ctx, args, stdout, stderr)
+	case "delete":
+		return runDelete(ctx, args, stdout, stderr)
+	case "validate":
+		return runValidate(args, stdout, stderr)
+	case "export":
+		return runExport(ctx, args, stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
@@ -0,0 +1,224 @@
+//go:build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// bicctl runs a command and returns what it wrote to stdout and stderr.
+func bicctl(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func newSQLiteDB(t *testing.T) *storage.SQLiteDB {
+	s, err := storage.NewSQLiteStorage("sqlite://" + filepath.Join(t.TempDir(), "bic.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Db.Close() })
+	db, err := s.Init()
+	require.NoError(t, err)
+	return storage.NewSQLiteDB(db)
+}
+
+// newTestServer serves the real REST API on top of a temporary SQLite database and counts the
+// requests for the SWIFT codes of a country.
+func newTestServer(t *testing.T) (string, *atomic.Int32) {
+	var countryRequests atomic.Int32
+	handler := app.NewAPIServer("", newSQLiteDB(t)).Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/swift-codes/country/") {
+			countryRequests.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &countryRequests
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const banksCSV = `swiftCode,bankName,address,countryISO2,countryName,isHeadquarter
+BREXPLPWXXX,MBANK,WARSAW,PL,Poland,true
+BREXPLPWWAL,MBANK,WALBRZYCH,PL,Poland,false
+DEUTDEFFXXX,DEUTSCHE BANK,FRANKFURT,DE,Germany,true
+`
+
+func TestRun_Usage(t *testing.T) {
+	_, stderr, err := bicctl(t)
+	assert.EqualError(t, err, "command is required")
+	assert.Contains(t, stderr, "Usage: bicctl")
+
+	_, _, err = bicctl(t, "frobnicate")
+	assert.EqualError(t, err, `unknown command "frobnicate"`)
+
+	stdout, _, err := bicctl(t, "help")
+	assert.NoError(t, err)
+	assert.Contains(t, stdout, "Commands:")
+}
+
+func TestValidate(t *testing.T) {
+	stdout, _, err := bicctl(t, "validate", "--swift-code", "BREXPLPWXXX", "--bank-name", "MBANK",
+		"--address", "WARSAW", "--country", "PL", "--country-name", "Poland")
+	assert.NoError(t, err)
+	assert.Equal(t, "1 valid\n", stdout)
+
+	stdout, _, err = bicctl(t, "validate", "--file", writeFile(t, "banks.csv", banksCSV))
+	assert.NoError(t, err)
+	assert.Equal(t, "3 valid\n", stdout)
+
+	_, stderr, err := bicctl(t, "validate", "--file", writeFile(t, "banks.json",
+		`[{"swiftCode": "BREXPLPWWAL", "bankName": "MBANK", "address": "WALBRZYCH", "countryISO2": "PL",
+		"countryName": "Poland", "isHeadquarter": true}, {"swiftCode": "BREXPLPWXXX"}]`))
+	assert.ErrorIs(t, err, errInvalid)
+	assert.Equal(t, "BREXPLPWWAL: swiftCode indicates bank's branch\nBREXPLPWXXX: address is required\n", stderr)
+
+	_, _, err = bicctl(t, "validate", "--file", "banks.csv", "--country", "PL")
+	assert.EqualError(t, err, "validate: --country cannot be combined with --file")
+
+	t.Run("country names", func(t *testing.T) {
+		bank := []string{"validate", "--swift-code", "CHASUS33XXX", "--bank-name", "JPMORGAN CHASE BANK",
+			"--address", "NEW YORK", "--country", "US", "--country-name", "USA"}
+
+		_, _, err := bicctl(t, bank...)
+		assert.NoError(t, err, "aliases are accepted by default")
+
+		_, stderr, err := bicctl(t, append(bank, "--country-names", "strict")...)
+		assert.ErrorIs(t, err, errInvalid)
+		assert.Equal(t, "CHASUS33XXX: countryName does not match ISO2 code\n", stderr)
+
+		_, _, err = bicctl(t, append(bank, "--country-names", "loose")...)
+		assert.ErrorContains(t, err, "use aliases or strict")
+	})
+}
+
+func TestCommands(t *testing.T) {
+	server, countryRequests := newTestServer(t)
+
+	stdout, _, err := bicctl(t, "add", "--server", server, "--file", writeFile(t, "banks.csv", banksCSV))
+	require.NoError(t, err)
+	assert.Equal(t, "added BREXPLPWXXX\nadded BREXPLPWWAL\nadded DEUTDEFFXXX\n", stdout)
+
+	t.Run("add is all or nothing", func(t *testing.T) {
+		_, _, err := bicctl(t, "add", "--server", server, "--file", writeFile(t, "banks.csv",
+			"swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n"+
+				"COBADEFFXXX,COMMERZBANK,FRANKFURT,DE,Germany,true\n"+
+				"DEUTDEFFXXX,DEUTSCHE BANK,FRANKFURT,DE,Germany,true\n"))
+		assert.ErrorIs(t, err, client.ErrConflict)
+
+		_, _, err = bicctl(t, "get", "COBADEFFXXX", "--server", server)
+		assert.ErrorIs(t, err, client.ErrSwiftCodeNotFound)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		stdout, _, err := bicctl(t, "get", "BREXPLPWXXX", "--server", server, "--output", "csv")
+		require.NoError(t, err)
+		assert.Equal(t, "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n"+
+			"BREXPLPWXXX,MBANK,WARSAW,PL,POLAND,true\n"+
+			"BREXPLPWWAL,MBANK,WALBRZYCH,PL,POLAND,false\n", stdout)
+
+		stdout, _, err = bicctl(t, "get", "BREXPLPWXXX", "--server", server)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stdout, "SWIFTCODE    BANKNAME"), stdout)
+
+		_, _, err = bicctl(t, "get", "BREXPLPWKRK", "--server", server)
+		assert.ErrorIs(t, err, client.ErrSwiftCodeNotFound)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		stdout, _, err := bicctl(t, "list", "--country", "pl", "--server", server, "--output", "json")
+		require.NoError(t, err)
+		var countryBanks client.CountryBanks
+		require.NoError(t, json.Unmarshal([]byte(stdout), &countryBanks))
+		assert.Equal(t, "POLAND", countryBanks.CountryName)
+		assert.Len(t, countryBanks.SwiftCodes, 2)
+
+		_, _, err = bicctl(t, "list", "--server", server)
+		assert.EqualError(t, err, "list: --country is required")
+	})
+
+	t.Run("export", func(t *testing.T) {
+		countryRequests.Store(0)
+		stdout, _, err := bicctl(t, "export", "--server", server)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), countryRequests.Load(), "only the countries with SWIFT codes are requested")
+
+		var exported []client.Bank
+		require.NoError(t, json.Unmarshal([]byte(stdout), &exported))
+		var swiftCodes []string
+		for _, bank := range exported {
+			swiftCodes = append(swiftCodes, *bank.SwiftCode)
+		}
+		assert.Equal(t, []string{"DEUTDEFFXXX", "BREXPLPWWAL", "BREXPLPWXXX"}, swiftCodes)
+
+		stdout, _, err = bicctl(t, "validate", "--file", writeFile(t, "export.json", stdout))
+		assert.NoError(t, err, "the export is read back by --file")
+		assert.Equal(t, "3 valid\n", stdout)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, _, err := bicctl(t, "delete", "BREXPLPWWAL", "--server", server)
+		assert.EqualError(t, err, "delete: either --etag or --force is required")
+
+		_, _, err = bicctl(t, "delete", "BREXPLPWWAL", "--server", server, "--etag", `"5"`)
+		assert.ErrorIs(t, err, client.ErrVersionMismatch)
+
+		stdout, _, err := bicctl(t, "delete", "BREXPLPWWAL", "--server", server, "--force")
+		require.NoError(t, err)
+		assert.Equal(t, "deleted BREXPLPWWAL\n", stdout)
+
+		_, _, err = bicctl(t, "get", "BREXPLPWWAL", "--server", server)
+		assert.ErrorIs(t, err, client.ErrSwiftCodeNotFound)
+	})
+}
+
+func TestAdd_WithoutBatches(t *testing.T) {
+	// the storage hides ApplyChanges, so the server has no batch endpoint
+	server := httptest.NewServer(app.NewAPIServer("", struct{ storage.Storage }{newSQLiteDB(t)}).Handler())
+	t.Cleanup(server.Close)
+
+	stdout, stderr, err := bicctl(t, "add", "--server", server.URL, "--file", writeFile(t, "banks.csv", banksCSV))
+	require.NoError(t, err)
+	assert.Equal(t, "added BREXPLPWXXX\nadded BREXPLPWWAL\nadded DEUTDEFFXXX\n", stdout)
+	assert.Contains(t, stderr, "one at a time")
+}
+
+func TestAPIKey(t *testing.T) {
+	var authorization atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"countryISO2": "PL", "countryName": "POLAND", "swiftCode": []}`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, _, err := bicctl(t, "list", "--country", "PL", "--server", server.URL, "--api-key", "k3y")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer k3y", authorization.Load())
+
+	t.Setenv("BICCTL_API_KEY", "3nv")
+	_, _, err = bicctl(t, "list", "--country", "PL", "--server", server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer 3nv", authorization.Load())
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"github.com/pkacprzak5/bic-data-service/pkg/client"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// csvMarshaler is implemented by the types written by the commands, whose CSV rows are also shown as tables.
+type csvMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// csvHeader is the header of the service's CSV responses, which the rows of banks follow.
+var csvHeader = []string{"swiftCode", "bankName", "address", "countryISO2", "countryName", "isHeadquarter"}
+
+// bankDetails renders a bank as the service's CSV response: the bank followed by its branches.
+type bankDetails client.Bank
+
+func (b bankDetails) MarshalCSV() ([][]string, error) {
+	countryName := ptr.Value(b.CountryName)
+	records := [][]string{csvHeader, {
+		ptr.Value(b.SwiftCode),
+		ptr.Value(b.BankName),
+		ptr.Value(b.Address),
+		ptr.Value(b.CountryISO2),
+		countryName,
+		ptr.FormatBool(b.IsHeadquarter),
+	}}
+	for _, branch := range b.Branches {
+		records = append(records, branchRecord(branch, countryName))
+	}
+	return records, nil
+}
+
+// countryDetails renders the SWIFT codes of a country as the service's CSV response.
+type countryDetails client.CountryBanks
+
+func (c countryDetails) MarshalCSV() ([][]string, error) {
+	records := [][]string{csvHeader}
+	for _, branch := range c.SwiftCodes {
+		records = append(records, branchRecord(branch, c.CountryName))
+	}
+	return records, nil
+}
+
+func branchRecord(b client.BankBranch, countryName string) []string {
+	return []string{b.SwiftCode, b.BankName, b.Address, b.CountryISO2, countryName, strconv.FormatBool(b.IsHeadquarter)}
+}
+
+// banks renders bank records as the service's CSV responses, one row per bank.
+type banks []client.Bank
+
+func (b banks) MarshalCSV() ([][]string, error) {
+	records := [][]string{csvHeader}
+	for _, bank := range b {
+		rows, err := bankDetails(bank).MarshalCSV()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rows[1:]...)
+	}
+	return records, nil
+}
+
+// write renders v in the given format.
+func write(out io.Writer, format string, v csvMarshaler) error {
+	if format == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	records, err := v.MarshalCSV()
+	if err != nil {
+		return err
+	}
+
+	if format == outputCSV {
+		writer := csv.NewWriter(out)
+		if err := writer.WriteAll(records); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, record := range records {
+		if i == 0 {
+			record = tableHeader(record)
+		}
+		fmt.Fprintln(writer, strings.Join(record, "\t"))
+	}
+	return writer.Flush()
+}
+
+func tableHeader(columns []string) []string {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	return header
+}
@@ -2,42 +2,209 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/config"
+	"github.com/pkacprzak5/bic-data-service/internal/outbox"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/internal/webhooks"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"time"
 )
 
 func main() {
-	dbConfig := storage.PostgresConfig{
-		Host:     storage.Envs.Host,
-		DB_Port:  storage.Envs.DB_Port,
-		User:     storage.Envs.User,
-		Password: storage.Envs.Password,
-		Database: storage.Envs.Database,
-	}
-	postgresDB, err := storage.NewPostgreSQLStorage(dbConfig)
-	if err != nil {
-		log.Fatalf("Failed to initialize PostgreSQL storage: %v", err)
-	}
-
-	db, err := postgresDB.Init()
-	if err != nil {
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
 		log.Fatalln(err)
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	port := fmt.Sprintf(":%v", storage.Envs.Port)
+	countryNames := bankdata.CountryNames(cfg.CountryNames)
 
-	store := storage.NewRelationalDB(db)
-	api := app.NewAPIServer(port, store)
+	if len(args) > 0 {
+		switch args[0] {
+		case "sync":
+			if err := runSync(ctx, cfg.Database, countryNames, args[1:], os.Stdout); err != nil {
+				log.Fatalln(err)
+			}
+		case "snapshot":
+			if err := runSnapshot(ctx, cfg.Database, countryNames, args[1:], os.Stdout); err != nil {
+				log.Fatalln(err)
+			}
+		case "config":
+			if err := cfg.Print(os.Stdout); err != nil {
+				log.Fatalln(err)
+			}
+		default:
+			log.Fatalf("Unknown command %q", args[0])
+		}
+		return
+	}
+
+	log.Println("Effective configuration:")
+	if err := cfg.Print(log.Writer()); err != nil {
+		log.Fatalln(err)
+	}
+
+	db, err := openDatabase(ctx, cfg.Database)
+	if err != nil && errors.Is(err, context.Canceled) {
+		log.Println("Interrupted while connecting to database")
+		return
+	} else if err != nil {
+		log.Fatalln(err)
+	}
+
+	// a background task which fails stops the server through ctx, so that it still shuts down cleanly
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+
+	var bankStorage storage.Storage
+	var opts []app.Option
+	if storage.IsSQLite(cfg.Database.URL) {
+		// a single instance owns a SQLite database, there are no other replicas to notify and no outbox
+		log.Println("Using SQLite storage, change events, webhooks and idempotency keys are not available")
+		bankStorage = storage.NewSQLiteDB(db)
+		if cfg.Cache.Size > 0 {
+			bankStorage = storage.NewCachedStorage(bankStorage, cfg.Cache)
+		}
+	} else {
+		bankStorage, opts, err = startPostgres(ctx, fail, cfg, db)
+		if err != nil && errors.Is(err, context.Canceled) {
+			log.Println("Interrupted while connecting to read replicas")
+			return
+		} else if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	opts = append(opts, app.WithCountryNames(countryNames))
+	if cfg.GRPCPort != "" {
+		opts = append(opts, app.WithGRPC(fmt.Sprintf(":%v", cfg.GRPCPort)))
+	}
+
+	api := app.NewAPIServer(fmt.Sprintf(":%v", cfg.Port), bankStorage, opts...)
 	err = api.Start(ctx)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+		log.Fatalln(cause)
+	}
+}
+
+// startPostgres sets up the storage on top of the PostgreSQL database db, and starts streaming the
+// changes to the event stream, the cache, webhook subscriptions and the outbox publisher. fail is called
+// with the error of a background task which cannot continue.
+func startPostgres(ctx context.Context, fail context.CancelCauseFunc, cfg *config.Config, db *sql.DB) (storage.Storage, []app.Option, error) {
+	events := app.NewEventBroker(cfg.EventsLogSize)
+
+	replicas, err := openReplicas(cfg.Database, cfg.Replicas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store := storage.NewRelationalDB(db, replicas...)
+	var bankStorage storage.Storage = store
+	changeHandlers := []storage.ChangeHandler{events}
+	if cfg.Cache.Size > 0 {
+		cache := storage.NewCachedStorage(store, cfg.Cache)
+		bankStorage = cache
+		changeHandlers = append(changeHandlers, storage.InvalidateOnChange(cache))
+	}
+
+	// changes made by any replica are streamed to clients and evicted from the cache
+	go func() {
+		if err := storage.ListenForChanges(ctx, cfg.Database.ConnString(), changeHandlers...); err != nil {
+			fail(fmt.Errorf("Failed to listen for changes: %w", err))
+		}
+	}()
+
+	publisher, err := newOutboxPublisher(cfg.Outbox)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// changes are always fanned out to webhook subscriptions, and to the configured publisher if any
+	publishers := outbox.MultiPublisher{webhooks.NewDispatcher(store)}
+	if publisher != nil {
+		publishers = append(publishers, publisher)
+	}
+	go outbox.NewRelay(store, publishers, outbox.DefaultConfig()).Run(ctx)
+	go webhooks.NewDeliverer(store, nil, webhooks.DefaultConfig()).Run(ctx)
+
+	opts := []app.Option{
+		app.WithIdempotency(store, cfg.IdempotencyTTL),
+		app.WithWebhooks(store),
+		app.WithEvents(events),
+	}
+	return bankStorage, opts, nil
+}
+
+// openDatabase opens the database selected by the scheme of the database URL: SQLite for sqlite: URLs,
+// PostgreSQL otherwise.
+func openDatabase(ctx context.Context, dbConfig storage.PostgresConfig) (*sql.DB, error) {
+	if storage.IsSQLite(dbConfig.URL) {
+		sqliteDB, err := storage.NewSQLiteStorage(dbConfig.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize SQLite storage: %w", err)
+		}
+		return sqliteDB.Init()
+	}
+
+	postgresDB, err := storage.NewPostgreSQLStorage(ctx, dbConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
+	}
+
+	return postgresDB.Init()
+}
+
+// openReplicas opens the read replicas, which use the same settings as the primary database apart
+// from the connection string. They are not connected to, so that an unreachable replica does not
+// prevent startup; it is ejected when it fails its first read.
+func openReplicas(dbConfig storage.PostgresConfig, urls []string) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for i, url := range urls {
+		replicaConfig := dbConfig
+		replicaConfig.URL = url
+		replica, err := storage.OpenPostgreSQL(replicaConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize read replica %d: %w", i, err)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// newOutboxPublisher creates the publisher of the change data feed selected by the configuration.
+// Without it changes are only delivered to webhook subscriptions.
+func newOutboxPublisher(cfg config.OutboxConfig) (outbox.Publisher, error) {
+	switch cfg.Publisher {
+	case "":
+		return nil, nil
+	case "stdout":
+		return outbox.NewWriterPublisher(os.Stdout), nil
+	case "file":
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open outbox file: %w", err)
+		}
+		return outbox.NewWriterPublisher(file), nil
+	case "webhook":
+		return outbox.NewWebhookPublisher(cfg.WebhookURL, &http.Client{Timeout: 10 * time.Second}), nil
+	default:
+		return nil, fmt.Errorf("Invalid outbox publisher: %q", cfg.Publisher)
+	}
 }
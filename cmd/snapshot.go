@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/dirsync"
+	"github.com/pkacprzak5/bic-data-service/internal/snapshot"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runSnapshot implements the "snapshot create" and "snapshot restore" commands, which back up all
+// bank records to an archive and load them back, possibly into another backend.
+func runSnapshot(ctx context.Context, dbConfig storage.PostgresConfig, countryNames bankdata.CountryNames, args []string,
+	out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("snapshot: use snapshot create or snapshot restore")
+	}
+	switch args[0] {
+	case "create":
+		return runSnapshotCreate(ctx, dbConfig, args[1:])
+	case "restore":
+		return runSnapshotRestore(ctx, dbConfig, countryNames, args[1:], out)
+	default:
+		return fmt.Errorf("snapshot: unknown command %q, use create or restore", args[0])
+	}
+}
+
+func runSnapshotCreate(ctx context.Context, dbConfig storage.PostgresConfig, args []string) error {
+	flags := flag.NewFlagSet("snapshot create", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the archive to write, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" && flags.NArg() == 1 {
+		*file = flags.Arg(0)
+	}
+	if *file == "" {
+		return errors.New("snapshot create: archive file is required (--file)")
+	}
+
+	store, db, err := openStore(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	banks, err := store.ListSwiftCodes()
+	if err != nil {
+		return fmt.Errorf("snapshot create: failed to read records: %w", err)
+	}
+	manifest := snapshot.Manifest{CreatedAt: time.Now().UTC(), Backend: backendName(dbConfig)}
+
+	if *file == "-" {
+		return snapshot.Write(os.Stdout, banks, manifest)
+	}
+
+	// the archive is written next to its destination and renamed, so that an interrupted run does
+	// not leave a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(*file), filepath.Base(*file)+".*")
+	if err != nil {
+		return fmt.Errorf("snapshot create: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := snapshot.Write(tmp, banks, manifest); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("snapshot create: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("snapshot create: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), *file); err != nil {
+		return fmt.Errorf("snapshot create: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %d records to %s.\n", len(banks), *file)
+	return nil
+}
+
+func runSnapshotRestore(ctx context.Context, dbConfig storage.PostgresConfig, countryNames bankdata.CountryNames, args []string,
+	out io.Writer) error {
+	flags := flag.NewFlagSet("snapshot restore", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the archive to restore, - for stdin")
+	mode := flags.String("mode", string(snapshot.Replace),
+		"replace: remove the records missing from the archive, merge: keep them")
+	format := flags.String("format", "text", "diff output format: text or json")
+	dryRun := flags.Bool("dry-run", false, "only print the diff, change nothing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" && flags.NArg() == 1 {
+		*file = flags.Arg(0)
+	}
+	if *file == "" {
+		return errors.New("snapshot restore: archive file is required (--file)")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("snapshot restore: unknown format %q", *format)
+	}
+
+	var archive io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		archive = f
+	}
+
+	manifest, banks, err := snapshot.Read(archive)
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Snapshot of %d records taken from %s at %s.\n",
+		manifest.Records, manifest.Backend, manifest.CreatedAt.Format(time.RFC3339))
+
+	store, db, err := openStore(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	diff, err := snapshot.Plan(store, banks, snapshot.Mode(*mode), app.BankValidator(countryNames))
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %w", err)
+	}
+
+	if *format == "json" {
+		err = dirsync.WriteJSON(out, diff)
+	} else {
+		err = dirsync.WriteText(out, diff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintln(os.Stderr, "Dry run, nothing restored.")
+		return nil
+	}
+
+	source := "snapshot:" + manifest.CreatedAt.Format(time.RFC3339)
+	if err := snapshot.Restore(store, diff, source); err != nil {
+		return fmt.Errorf("snapshot restore: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Snapshot restored.")
+	return nil
+}
+
+// openStore opens the database for the commands, which use it directly, without read replicas or a cache.
+func openStore(ctx context.Context, dbConfig storage.PostgresConfig) (snapshot.Store, *sql.DB, error) {
+	db, err := openDatabase(ctx, dbConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	if storage.IsSQLite(dbConfig.URL) {
+		return storage.NewSQLiteDB(db), db, nil
+	}
+	return storage.NewRelationalDB(db), db, nil
+}
+
+func backendName(dbConfig storage.PostgresConfig) string {
+	if storage.IsSQLite(dbConfig.URL) {
+		return "sqlite"
+	}
+	return "postgres"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/dirsync"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runSync implements the "sync" command, which compares a BIC directory snapshot with the
+// database and, when --dry-run=false is given, applies the differences in one transaction.
+func runSync(ctx context.Context, dbConfig storage.PostgresConfig, countryNames bankdata.CountryNames, args []string,
+	out io.Writer) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	file := flags.String("file", "", "path to the directory snapshot (CSV)")
+	format := flags.String("format", "text", "diff output format: text or json")
+	dryRun := flags.Bool("dry-run", true, "only print the diff, use --dry-run=false to apply it")
+	source := flags.String("source", "", "source recorded in audit entries (defaults to the snapshot file name)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" && flags.NArg() == 1 {
+		*file = flags.Arg(0)
+	}
+	if *file == "" {
+		return errors.New("sync: snapshot file is required (--file)")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("sync: unknown format %q", *format)
+	}
+	if *source == "" {
+		*source = "sync:" + filepath.Base(*file)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	snapshot, err := dirsync.ReadSnapshot(f)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	store, db, err := openStore(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	syncer := dirsync.NewSyncer(store, app.BankValidator(countryNames))
+	diff, err := syncer.Plan(snapshot)
+	if err != nil {
+		return fmt.Errorf("sync: failed to compute diff: %w", err)
+	}
+
+	if *format == "json" {
+		err = dirsync.WriteJSON(out, diff)
+	} else {
+		err = dirsync.WriteText(out, diff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintln(os.Stderr, "Dry run, no changes applied. Use --dry-run=false to apply them.")
+		return nil
+	}
+
+	if err := syncer.Apply(diff, *source); err != nil {
+		return fmt.Errorf("sync: failed to apply changes: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Changes applied.")
+	return nil
+}
@@ -4,6 +4,7 @@ package e2e_tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
@@ -27,7 +28,7 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	s.serverURL = fmt.Sprintf("http://localhost:%s", storage.GetEnv("PORT", "8081"))
 	initTestEnvs()
 	s.dbConfig = storage.PostgresConfig{
-		DB_Port:  storage.GetEnv("DB_PORT", "5432"),
+		Port:     storage.GetEnv("DB_PORT", "5432"),
 		User:     storage.GetEnv("DB_USER", "test_user"),
 		Password: storage.GetEnv("DB_PASSWORD", "Test@1234"),
 		Host:     storage.GetEnv("DB_HOST", "localhost"),
@@ -94,7 +95,7 @@ func (s *IntegrationTestSuite) AfterTest(_, _ string) {
 		}
 	}
 
-	postgresDB, err := storage.NewPostgreSQLStorage(s.dbConfig)
+	postgresDB, err := storage.NewPostgreSQLStorage(context.Background(), s.dbConfig)
 	if err != nil {
 		s.T().Fatal(err)
 	}
@@ -109,9 +110,9 @@ func (s *IntegrationTestSuite) AfterTest(_, _ string) {
 		s.T().Errorf("Failed to terminate database connections: %v", err)
 	}
 
-	_, err = postgresDB.Db.Exec("DROP TABLE IF EXISTS BanksData CASCADE")
+	_, err = postgresDB.Db.Exec("DROP TABLE IF EXISTS BanksData, BanksDataHistory, BanksAudit, IdempotencyKeys, Outbox, OutboxDeadLetter, WebhookDeliveries, WebhookSubscriptions CASCADE")
 	if err != nil {
-		s.T().Errorf("Failed to drop table BanksData: %v", err)
+		s.T().Errorf("Failed to drop tables: %v", err)
 	}
 	s.serverCmd = nil
 }
@@ -169,7 +170,7 @@ func (s *IntegrationTestSuite) TestFullAPIFlow() {
 	}
 	assert.Equal(s.T(), http.StatusOK, getResp.StatusCode)
 
-	// Test DELETE
+	// Test DELETE without If-Match
 	deleteURL := fmt.Sprintf("%s/v1/swift-codes/%s", s.serverURL, swiftCode)
 	req, err := http.NewRequest(http.MethodDelete, deleteURL, nil)
 	if err != nil {
@@ -180,6 +181,16 @@ func (s *IntegrationTestSuite) TestFullAPIFlow() {
 	if err != nil {
 		s.T().Fatalf("DELETE request failed: %v", err)
 	}
+	deleteResp.Body.Close()
+	assert.Equal(s.T(), http.StatusPreconditionRequired, deleteResp.StatusCode)
+
+	// Test DELETE
+	req.Header.Set("If-Match", getResp.Header.Get("ETag"))
+
+	deleteResp, err = client.Do(req)
+	if err != nil {
+		s.T().Fatalf("DELETE request failed: %v", err)
+	}
 	defer deleteResp.Body.Close()
 	assert.Equal(s.T(), http.StatusOK, deleteResp.StatusCode)
 
@@ -2,8 +2,11 @@ package app
 
 import (
 	"context"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
 	"log"
+	"net"
 	"net/http"
 	"time"
 )
@@ -11,27 +14,93 @@ import (
 type APIServer struct {
 	address string
 	storage storage.Storage
+
+	idempotencyStore storage.IdempotencyStore
+	idempotencyTTL   time.Duration
+
+	webhookStore storage.WebhookStore
+
+	events *EventBroker
+
+	grpcAddress string
+
+	countryNames bankdata.CountryNames
+}
+
+type Option func(*APIServer)
+
+// WithIdempotency enables Idempotency-Key support on POST endpoints, keeping responses for ttl.
+func WithIdempotency(store storage.IdempotencyStore, ttl time.Duration) Option {
+	return func(s *APIServer) {
+		s.idempotencyStore = store
+		s.idempotencyTTL = ttl
+	}
 }
 
-func NewAPIServer(address string, storage storage.Storage) *APIServer {
-	return &APIServer{address: address, storage: storage}
+// WithWebhooks exposes the management of webhook subscriptions under /v1/webhooks.
+func WithWebhooks(store storage.WebhookStore) Option {
+	return func(s *APIServer) {
+		s.webhookStore = store
+	}
 }
 
-func (s *APIServer) Start(ctx context.Context) error {
+// WithEvents exposes the stream of changes at /v1/swift-codes/events.
+func WithEvents(broker *EventBroker) Option {
+	return func(s *APIServer) {
+		s.events = broker
+	}
+}
+
+// WithGRPC serves the BicDirectory gRPC service at address, next to the REST API.
+func WithGRPC(address string) Option {
+	return func(s *APIServer) {
+		s.grpcAddress = address
+	}
+}
+
+// WithCountryNames sets how the country names of new and updated records are checked, by default
+// bankdata.CountryNamesAliases.
+func WithCountryNames(countryNames bankdata.CountryNames) Option {
+	return func(s *APIServer) {
+		s.countryNames = countryNames
+	}
+}
+
+func NewAPIServer(address string, storage storage.Storage, opts ...Option) *APIServer {
+	s := &APIServer{address: address, storage: storage, countryNames: bankdata.CountryNamesAliases}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the REST API, as served by Start.
+func (s *APIServer) Handler() http.Handler {
 	router := http.NewServeMux()
 	subrouter := http.NewServeMux()
 
-	router.Handle("/v1/", http.StripPrefix("/v1", subrouter))
-
-	bankService := NewBankService(s.storage)
-	bankService.RegisterRoutes(subrouter)
+	router.Handle("/v1/", http.StripPrefix("/v1", utils.RequireAcceptable(subrouter)))
+	s.registerRoutes(router, subrouter)
+	return readPrimary(router)
+}
 
+func (s *APIServer) Start(ctx context.Context) error {
 	server := &http.Server{
 		Addr:    s.address,
-		Handler: router,
+		Handler: s.Handler(),
 	}
+	if s.events != nil {
+		// Shutdown waits for active requests, which open event streams would never finish
+		server.RegisterOnShutdown(s.events.Close)
+	}
+
+	ch := make(chan error, 2)
+	stopGRPC, err := s.startGRPC(ch)
+	if err != nil {
+		return err
+	}
+	defer stopGRPC()
 
-	ch := make(chan error, 1)
 	go func() {
 		log.Println("Starting API server at", s.address)
 
@@ -39,11 +108,11 @@ func (s *APIServer) Start(ctx context.Context) error {
 		if err != nil {
 			ch <- err
 		}
-		close(ch)
 	}()
 
 	select {
 	case err := <-ch:
+		_ = server.Close()
 		return err
 	case <-ctx.Done():
 		log.Println("Received shutdown signal, shutting down the server...")
@@ -53,3 +122,64 @@ func (s *APIServer) Start(ctx context.Context) error {
 		return server.Shutdown(timeout)
 	}
 }
+
+// registerRoutes registers the endpoints of all enabled services. Routes of subrouter are served under /v1
+// and subject to content negotiation, routes of router are registered with their full path.
+func (s *APIServer) registerRoutes(router, subrouter Router) {
+	// the specification and event streams are not subject to content negotiation of the other endpoints
+	router.HandleFunc("GET /v1/openapi.json", handleOpenAPI)
+	if s.events != nil {
+		router.Handle("GET /v1/swift-codes/events", s.events)
+	}
+
+	bankService := NewBankService(s.storage)
+	bankService.countryNames = s.countryNames
+	if s.idempotencyStore != nil {
+		bankService.idempotency = newIdempotencyGuard(s.idempotencyStore, s.idempotencyTTL)
+	}
+	bankService.RegisterRoutes(subrouter)
+
+	if s.webhookStore != nil {
+		NewWebhookService(s.webhookStore).RegisterRoutes(subrouter)
+	}
+}
+
+// startGRPC starts the gRPC server, if enabled, reporting its failure on ch. The returned function
+// stops it, waiting up to 10 seconds for the calls in progress.
+func (s *APIServer) startGRPC(ch chan<- error) (func(), error) {
+	if s.grpcAddress == "" {
+		return func() {}, nil
+	}
+
+	listener, err := net.Listen("tcp", s.grpcAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	directory := NewDirectoryServer(s.storage)
+	directory.countryNames = s.countryNames
+	server, healthServer := newGRPCServer(directory)
+	go func() {
+		log.Println("Starting gRPC server at", s.grpcAddress)
+
+		if err := server.Serve(listener); err != nil {
+			ch <- err
+		}
+	}()
+
+	return func() {
+		healthServer.Shutdown()
+
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(10 * time.Second):
+			server.Stop()
+		}
+	}, nil
+}
@@ -18,7 +18,16 @@ type mockStorageApi struct {
 	getSwiftCodeDetailsFunc     func(string) (*storage.Bank, error)
 	getSwiftCodesForCountryFunc func(string) (*storage.CountryBanks, error)
 	addSwiftCodeEntryFunc       func(storage.Bank) error
-	deleteSwiftCodeEntryFunc    func(string) error
+	updateSwiftCodeEntryFunc    func(storage.Bank, int64) error
+	deleteSwiftCodeEntryFunc    func(string, int64) error
+}
+
+func (m *mockStorageApi) GetSwiftCodeDetailsAsOf(swiftCode string, _ time.Time) (*storage.Bank, error) {
+	return m.GetSwiftCodeDetails(swiftCode)
+}
+
+func (m *mockStorageApi) GetSwiftCodesForCountryAsOf(iso2Code string, _ time.Time) (*storage.CountryBanks, error) {
+	return m.GetSwiftCodesForCountry(iso2Code)
 }
 
 func (m *mockStorageApi) GetSwiftCodeDetails(swiftCode string) (*storage.Bank, error) {
@@ -42,9 +51,16 @@ func (m *mockStorageApi) AddSwiftCodeEntry(b storage.Bank) error {
 	return storage.ErrSwiftCodeExists
 }
 
-func (m *mockStorageApi) DeleteSwiftCodeEntry(swiftCode string) error {
+func (m *mockStorageApi) UpdateSwiftCodeEntry(b storage.Bank, version int64) error {
+	if m.updateSwiftCodeEntryFunc != nil {
+		return m.updateSwiftCodeEntryFunc(b, version)
+	}
+	return storage.ErrSwiftCodeNotFound
+}
+
+func (m *mockStorageApi) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
 	if m.deleteSwiftCodeEntryFunc != nil {
-		return m.deleteSwiftCodeEntryFunc(swiftCode)
+		return m.deleteSwiftCodeEntryFunc(swiftCode, version)
 	}
 	return storage.ErrSwiftCodeNotFound
 }
@@ -156,6 +172,7 @@ func TestAPIServer_Routes(t *testing.T) {
 		{"GET", "/v1/swift-codes/TEST33PLXXX", http.StatusBadRequest},
 		{"GET", "/v1/swift-codes/country/PL3", http.StatusBadRequest},
 		{"POST", "/v1/swift-codes", http.StatusBadRequest},
+		{"PUT", "/v1/swift-codes/INVALID", http.StatusBadRequest},
 		{"DELETE", "/v1/swift-codes/INVALID", http.StatusBadRequest},
 		{"GET", "/v1/non-existent-route", http.StatusNotFound},
 	}
@@ -0,0 +1,142 @@
+//go:build unit
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	country "github.com/mikekonan/go-countries"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countryStorage is a mockStorage which can count the records of each country.
+type countryStorage struct {
+	mockStorage
+	CountriesFunc func() ([]storage.CountryStats, error)
+}
+
+func (m *countryStorage) Countries() ([]storage.CountryStats, error) {
+	return m.CountriesFunc()
+}
+
+func (m *countryStorage) CountryCounts(iso2Code string) (*storage.CountryStats, error) {
+	countries, err := m.CountriesFunc()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range countries {
+		if c.CountryISO2 == iso2Code {
+			return &c, nil
+		}
+	}
+	return nil, storage.ErrISO2CodeNotFound
+}
+
+func TestNumericCodes(t *testing.T) {
+	seen := make(map[string]string)
+	for code := range numericCodes {
+		_, ok := country.ByAlpha2CodeStr(code)
+		assert.True(t, ok, "%s is not a known country", code)
+	}
+	for _, code := range allISO2Codes() {
+		numeric, ok := numericCodes[code]
+		if assert.True(t, ok, "%s has no numeric code", code) {
+			assert.Regexp(t, `^[0-9]{3}$`, numeric)
+			assert.Empty(t, seen[numeric], "%s has the numeric code of %s", code, seen[numeric])
+			seen[numeric] = code
+		}
+	}
+}
+
+func allISO2Codes() []string {
+	var codes []string
+	for first := 'A'; first <= 'Z'; first++ {
+		for second := 'A'; second <= 'Z'; second++ {
+			if _, ok := country.ByAlpha2CodeStr(string([]rune{first, second})); ok {
+				codes = append(codes, string([]rune{first, second}))
+			}
+		}
+	}
+	return codes
+}
+
+func TestCountriesRoutes(t *testing.T) {
+	poland := storage.CountryStats{CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1}
+	s := &countryStorage{CountriesFunc: func() ([]storage.CountryStats, error) {
+		return []storage.CountryStats{poland}, nil
+	}}
+
+	get := func(s storage.Storage, path, accept string) *httptest.ResponseRecorder {
+		router := http.NewServeMux()
+		NewBankService(s).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rec := get(s, "/countries", "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"countries":[
+			{"countryISO2":"PL","countryName":"POLAND","swiftCodes":3,"headquarters":1,"branches":2,"institutions":1}]}`,
+			rec.Body.String())
+	})
+
+	t.Run("list as csv", func(t *testing.T) {
+		rec := get(s, "/countries", "text/csv")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "countryISO2,countryName,swiftCodes,headquarters,branches,institutions\nPL,POLAND,3,1,2,1\n", rec.Body.String())
+	})
+
+	t.Run("country with records", func(t *testing.T) {
+		rec := get(s, "/countries/PL", "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var c storage.Country
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&c))
+		assert.Equal(t, storage.Country{
+			CountryISO2: "PL", CountryISO3: "POL", NumericCode: "616", Name: "Poland", CountryName: "POLAND",
+			SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1,
+		}, c)
+	})
+
+	t.Run("country without records", func(t *testing.T) {
+		rec := get(s, "/countries/DE", "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"countryISO2":"DE","countryISO3":"DEU","numericCode":"276","name":"Germany","countryName":"GERMANY",
+			"swiftCodes":0,"headquarters":0,"branches":0,"institutions":0}`, rec.Body.String())
+	})
+
+	t.Run("invalid country", func(t *testing.T) {
+		for _, code := range []string{"pl", "XX", "POL"} {
+			rec := get(s, "/countries/"+code, "application/json")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, code)
+			assert.JSONEq(t, `{"message":"countryISO2code is invalid"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		failing := &countryStorage{CountriesFunc: func() ([]storage.CountryStats, error) {
+			return nil, errors.New("connection reset")
+		}}
+
+		assert.Equal(t, http.StatusInternalServerError, get(failing, "/countries", "application/json").Code)
+		assert.Equal(t, http.StatusInternalServerError, get(failing, "/countries/PL", "application/json").Code)
+	})
+
+	t.Run("storage without countries", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(&mockStorage{}, "/countries", "application/json").Code)
+	})
+}
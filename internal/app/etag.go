@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// representations are the content types a bank is rendered in, each of which has its own ETag.
+var representations = []string{utils.ContentTypeJSON, utils.ContentTypeXML, utils.ContentTypeCSV}
+
+// entityTag returns a strong ETag for the representation of the bank in the given content type. It
+// is derived from the record version and, for a headquarter, from the versions of its branches, so
+// that it changes whenever the returned branch list does. As RFC 9110 requires of strong tags, the
+// representations differ in their tags: the JSON one, which is the default, has none of the suffixes
+// of the others.
+func entityTag(b *storage.Bank, contentType string) string {
+	tag := strconv.FormatInt(b.Version, 10)
+	if len(b.Branches) > 0 {
+		h := fnv.New64a()
+		for _, branch := range b.Branches {
+			fmt.Fprintf(h, "%s:%d;", branch.SwiftCode, branch.Version)
+		}
+		tag = fmt.Sprintf("%s-%x", tag, h.Sum64())
+	}
+
+	switch contentType {
+	case utils.ContentTypeXML:
+		tag += "-xml"
+	case utils.ContentTypeCSV:
+		tag += "-csv"
+	}
+	return strconv.Quote(tag)
+}
+
+// responseEntityTag returns the ETag of the bank in the representation negotiated for the request.
+func responseEntityTag(r *http.Request, b *storage.Bank) string {
+	contentType, _ := utils.NegotiateContentType(r.Header.Get("Accept"))
+	return entityTag(b, contentType)
+}
+
+// matchesIfMatch reports whether the current version of the bank satisfies an If-Match header. The
+// tag of any of its representations does, since a write replaces all of them. As required by
+// RFC 9110, weak tags never match.
+func matchesIfMatch(header string, b *storage.Bank) bool {
+	for _, tag := range splitEntityTags(header) {
+		if tag == "*" {
+			return true
+		}
+		for _, contentType := range representations {
+			if tag == entityTag(b, contentType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesIfNoneMatch reports whether an If-None-Match header matches etag, using weak comparison.
+func matchesIfNoneMatch(header, etag string) bool {
+	for _, tag := range splitEntityTags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func splitEntityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	eventsHeartbeatInterval = 15 * time.Second
+	eventsRetryMillis       = 3000
+	eventsSubscriberBuffer  = 64
+)
+
+// changeEvent is a message of the Server-Sent Events stream. Reset events carry no id.
+type changeEvent struct {
+	id          string
+	name        string
+	data        []byte
+	countryISO2 string
+}
+
+var resetEvent = changeEvent{name: "reset", data: []byte("{}")}
+
+type eventSubscriber struct {
+	countryISO2 string
+	events      chan changeEvent
+}
+
+// EventBroker streams changes of the dataset to clients of GET /v1/swift-codes/events. It is fed with
+// the changes announced by every replica, and keeps the most recent of them so that reconnecting
+// clients can resume from the Last-Event-ID they have seen.
+type EventBroker struct {
+	mu          sync.Mutex
+	log         []changeEvent
+	size        int
+	subscribers map[*eventSubscriber]struct{}
+	closed      bool
+
+	heartbeat time.Duration
+}
+
+// NewEventBroker creates a broker keeping the last size events for resumption.
+func NewEventBroker(size int) *EventBroker {
+	return &EventBroker{
+		size:        size,
+		subscribers: make(map[*eventSubscriber]struct{}),
+		heartbeat:   eventsHeartbeatInterval,
+	}
+}
+
+func (b *EventBroker) HandleChange(change storage.ChangeNotification) {
+	if change.EventID == 0 {
+		return
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		log.Printf("Failed to encode change event %d: %v", change.EventID, err)
+		return
+	}
+
+	b.publish(changeEvent{
+		id:          strconv.FormatInt(change.EventID, 10),
+		name:        "change",
+		data:        data,
+		countryISO2: change.CountryISO2,
+	})
+}
+
+// HandleReconnect tells the clients to resynchronise, as changes made while the listener was
+// disconnected are not known. Resuming from the events seen before is no longer possible.
+func (b *EventBroker) HandleReconnect() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.log = nil
+	for sub := range b.subscribers {
+		b.send(sub, resetEvent)
+	}
+}
+
+// Close ends all streams, so that they do not hold up the shutdown of the server.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		close(sub.events)
+		delete(b.subscribers, sub)
+	}
+}
+
+func (b *EventBroker) publish(event changeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.size > 0 {
+		b.log = append(b.log, event)
+		if len(b.log) > b.size {
+			b.log = b.log[len(b.log)-b.size:]
+		}
+	}
+
+	for sub := range b.subscribers {
+		if sub.countryISO2 == "" || sub.countryISO2 == event.countryISO2 {
+			b.send(sub, event)
+		}
+	}
+}
+
+// send must be called with b.mu held. A subscriber which does not keep up is disconnected;
+// it can resume from its last event after reconnecting.
+func (b *EventBroker) send(sub *eventSubscriber, event changeEvent) {
+	select {
+	case sub.events <- event:
+	default:
+		close(sub.events)
+		delete(b.subscribers, sub)
+	}
+}
+
+// subscribe registers a subscriber and returns the logged events following lastEventID. reset is true
+// if lastEventID is no longer in the log, and so the client may have missed changes.
+func (b *EventBroker) subscribe(countryISO2, lastEventID string) (sub *eventSubscriber, replay []changeEvent, reset bool, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, false, false
+	}
+
+	if lastEventID != "" {
+		reset = true
+		for i, event := range b.log {
+			if event.id == lastEventID {
+				reset = false
+				for _, missed := range b.log[i+1:] {
+					if countryISO2 == "" || missed.countryISO2 == countryISO2 {
+						replay = append(replay, missed)
+					}
+				}
+				break
+			}
+		}
+	}
+
+	sub = &eventSubscriber{countryISO2: countryISO2, events: make(chan changeEvent, eventsSubscriberBuffer)}
+	b.subscribers[sub] = struct{}{}
+	return sub, replay, reset, true
+}
+
+func (b *EventBroker) unsubscribe(sub *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		close(sub.events)
+		delete(b.subscribers, sub)
+	}
+}
+
+// ServeHTTP streams the changes as Server-Sent Events. The stream may be limited to one country
+// with the country query parameter.
+func (b *EventBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// error responses are always JSON, as the client asked for an event stream
+	countryISO2 := r.URL.Query().Get("country")
+	if countryISO2 != "" && !bankdata.ValidISO2(countryISO2) {
+		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "country is invalid"})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteJSON(w, http.StatusInternalServerError, storage.Response{Message: "Streaming is not supported"})
+		return
+	}
+
+	sub, replay, reset, ok := b.subscribe(countryISO2, r.Header.Get("Last-Event-ID"))
+	if !ok {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, storage.Response{Message: "Server is shutting down"})
+		return
+	}
+	defer b.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetryMillis)
+	if reset {
+		writeEvent(w, resetEvent)
+	}
+	for _, event := range replay {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(b.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event changeEvent) {
+	if event.id != "" {
+		fmt.Fprintf(w, "id: %s\n", event.id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
+}
@@ -0,0 +1,191 @@
+//go:build unit
+
+package app
+
+import (
+	"bufio"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func change(id int64, action storage.ChangeAction, swiftCode string) storage.ChangeNotification {
+	return storage.ChangeNotification{
+		EventID:     id,
+		Action:      action,
+		SwiftCode:   swiftCode,
+		CountryISO2: swiftCode[4:6],
+		OccurredAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// openStream connects to the broker and returns a function reading the next event, without comments.
+func openStream(t *testing.T, server *httptest.Server, query string, header http.Header) (*http.Response, func() string) {
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/swift-codes/events"+query, nil)
+	require.NoError(t, err)
+	req.Header = header
+
+	res, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	reader := bufio.NewReader(res.Body)
+	next := func() string {
+		var event strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return event.String()
+			}
+			if line == "\n" {
+				if event.Len() > 0 {
+					return event.String()
+				}
+				continue
+			}
+			if !strings.HasPrefix(line, ":") && !strings.HasPrefix(line, "retry:") {
+				event.WriteString(line)
+			}
+		}
+	}
+	return res, next
+}
+
+// newEventsServer serves the broker until the end of the test. It is closed after the streams opened
+// by the test, as closing the server waits for them.
+func newEventsServer(t *testing.T, broker *EventBroker) *httptest.Server {
+	router := http.NewServeMux()
+	router.Handle("GET /v1/swift-codes/events", broker)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// waitForSubscribers waits until the streams have subscribed, so that published events reach them.
+func waitForSubscribers(t *testing.T, broker *EventBroker, count int) {
+	require.Eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return len(broker.subscribers) == count
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestEventBroker_Stream(t *testing.T) {
+	broker := NewEventBroker(10)
+	server := newEventsServer(t, broker)
+
+	res, next := openStream(t, server, "", nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+
+	_, nextPL := openStream(t, server, "?country=PL", nil)
+	waitForSubscribers(t, broker, 2)
+
+	broker.HandleChange(change(1, storage.ChangeAdd, "TESTDEFFXXX"))
+	broker.HandleChange(change(2, storage.ChangeRemove, "TESTPLPWXXX"))
+	broker.HandleChange(storage.ChangeNotification{SwiftCode: "TESTPLPWXXX"}) // without outbox id
+
+	first := "id: 1\nevent: change\ndata: {\"eventId\":1,\"action\":\"add\",\"swiftCode\":\"TESTDEFFXXX\"," +
+		"\"countryISO2\":\"DE\",\"occurredAt\":\"2026-03-01T12:00:00Z\"}\n"
+	second := "id: 2\nevent: change\ndata: {\"eventId\":2,\"action\":\"remove\",\"swiftCode\":\"TESTPLPWXXX\"," +
+		"\"countryISO2\":\"PL\",\"occurredAt\":\"2026-03-01T12:00:00Z\"}\n"
+
+	assert.Equal(t, first, next())
+	assert.Equal(t, second, next())
+	assert.Equal(t, second, nextPL())
+}
+
+func TestEventBroker_Resume(t *testing.T) {
+	broker := NewEventBroker(2)
+	server := newEventsServer(t, broker)
+
+	broker.HandleChange(change(1, storage.ChangeAdd, "TESTPLPWXXX"))
+	broker.HandleChange(change(2, storage.ChangeAdd, "TESTDEFFXXX"))
+	broker.HandleChange(change(3, storage.ChangeAdd, "TESTPLPWABC"))
+
+	t.Run("from logged event", func(t *testing.T) {
+		_, next := openStream(t, server, "", http.Header{"Last-Event-Id": {"2"}})
+		assert.Contains(t, next(), "id: 3\n")
+	})
+
+	t.Run("with country filter", func(t *testing.T) {
+		_, next := openStream(t, server, "?country=DE", http.Header{"Last-Event-Id": {"2"}})
+		waitForSubscribers(t, broker, 1)
+
+		broker.HandleChange(change(4, storage.ChangeAdd, "TESTDEFFABC"))
+		assert.Contains(t, next(), "id: 4\n")
+	})
+
+	t.Run("from event no longer logged", func(t *testing.T) {
+		_, next := openStream(t, server, "", http.Header{"Last-Event-Id": {"1"}})
+		assert.Equal(t, "event: reset\ndata: {}\n", next())
+		waitForSubscribers(t, broker, 1)
+
+		broker.HandleChange(change(5, storage.ChangeAdd, "TESTPLPWDEF"))
+		assert.Contains(t, next(), "id: 5\n")
+	})
+}
+
+func TestEventBroker_Reconnect(t *testing.T) {
+	broker := NewEventBroker(10)
+	server := newEventsServer(t, broker)
+
+	broker.HandleChange(change(1, storage.ChangeAdd, "TESTPLPWXXX"))
+	_, next := openStream(t, server, "", nil)
+	waitForSubscribers(t, broker, 1)
+
+	broker.HandleReconnect()
+	assert.Equal(t, "event: reset\ndata: {}\n", next())
+
+	// changes before the reconnect can no longer be resumed from
+	_, next = openStream(t, server, "", http.Header{"Last-Event-Id": {"1"}})
+	assert.Equal(t, "event: reset\ndata: {}\n", next())
+}
+
+func TestEventBroker_Close(t *testing.T) {
+	broker := NewEventBroker(10)
+	server := newEventsServer(t, broker)
+
+	_, next := openStream(t, server, "", nil)
+	waitForSubscribers(t, broker, 1)
+
+	broker.Close()
+	assert.Equal(t, "", next(), "stream should end")
+
+	res, _ := openStream(t, server, "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
+
+func TestEventBroker_InvalidCountry(t *testing.T) {
+	server := newEventsServer(t, NewEventBroker(10))
+
+	res, _ := openStream(t, server, "?country=XX", http.Header{"Accept": {"text/event-stream"}})
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+}
+
+func TestEventBroker_Heartbeat(t *testing.T) {
+	broker := NewEventBroker(10)
+	broker.heartbeat = 10 * time.Millisecond
+	server := newEventsServer(t, broker)
+
+	res, err := server.Client().Get(server.URL + "/v1/swift-codes/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line == ": keep-alive\n" {
+			return
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	bicv1 "github.com/pkacprzak5/bic-data-service/api/bic/v1"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxBatchLookupSize = 1000
+	exportPageSize     = 1000
+)
+
+// DirectoryServer implements the BicDirectory gRPC service on top of the same storage and validation
+// as BankService.
+type DirectoryServer struct {
+	bicv1.UnimplementedBicDirectoryServer
+
+	storage      storage.Storage
+	countryNames bankdata.CountryNames
+}
+
+func NewDirectoryServer(s storage.Storage) *DirectoryServer {
+	return &DirectoryServer{storage: s, countryNames: bankdata.CountryNamesAliases}
+}
+
+// newGRPCServer creates a server with the BicDirectory service, gRPC health checking and reflection.
+func newGRPCServer(directory *DirectoryServer) (*grpc.Server, *health.Server) {
+	server := grpc.NewServer()
+	bicv1.RegisterBicDirectoryServer(server, directory)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(bicv1.BicDirectory_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server, healthServer
+}
+
+func (s *DirectoryServer) GetSwiftCode(ctx context.Context, req *bicv1.GetSwiftCodeRequest) (*bicv1.Bank, error) {
+	if !isValidSwiftCode(req.GetSwiftCode()) {
+		return nil, status.Error(codes.InvalidArgument, "swiftCode is invalid")
+	}
+
+	store := s.storageFor(ctx)
+	var bank *storage.Bank
+	var err error
+	if req.GetAsOf() != nil {
+		bank, err = store.GetSwiftCodeDetailsAsOf(req.GetSwiftCode(), req.GetAsOf().AsTime())
+	} else {
+		bank, err = store.GetSwiftCodeDetails(req.GetSwiftCode())
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return bankToProto(bank), nil
+}
+
+func (s *DirectoryServer) ListByCountry(ctx context.Context, req *bicv1.ListByCountryRequest) (*bicv1.CountryBanks, error) {
+	if !bankdata.ValidISO2(req.GetCountryIso2()) {
+		return nil, status.Error(codes.InvalidArgument, "countryISO2code is invalid")
+	}
+
+	store := s.storageFor(ctx)
+	var countryBanks *storage.CountryBanks
+	var err error
+	if req.GetAsOf() != nil {
+		countryBanks, err = store.GetSwiftCodesForCountryAsOf(req.GetCountryIso2(), req.GetAsOf().AsTime())
+	} else {
+		countryBanks, err = store.GetSwiftCodesForCountry(req.GetCountryIso2())
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &bicv1.CountryBanks{
+		CountryIso2: countryBanks.CountryISO2,
+		CountryName: countryBanks.CountryName,
+		SwiftCodes:  branchesToProto(countryBanks.SwiftCodes),
+	}, nil
+}
+
+func (s *DirectoryServer) BatchLookup(ctx context.Context, req *bicv1.BatchLookupRequest) (*bicv1.BatchLookupResponse, error) {
+	if len(req.GetSwiftCodes()) > maxBatchLookupSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d SWIFT codes can be looked up at once", maxBatchLookupSize)
+	}
+
+	store := s.storageFor(ctx)
+	results := make([]*bicv1.LookupResult, 0, len(req.GetSwiftCodes()))
+	for _, swiftCode := range req.GetSwiftCodes() {
+		result := &bicv1.LookupResult{SwiftCode: swiftCode}
+		if !isValidSwiftCode(swiftCode) {
+			result.Error = "swiftCode is invalid"
+			results = append(results, result)
+			continue
+		}
+
+		bank, err := store.GetSwiftCodeDetails(swiftCode)
+		if err != nil && errors.Is(err, storage.ErrSwiftCodeNotFound) {
+			result.Error = err.Error()
+		} else if err != nil {
+			return nil, grpcError(err)
+		} else {
+			result.Bank = bankToProto(bank)
+		}
+		results = append(results, result)
+	}
+
+	return &bicv1.BatchLookupResponse{Results: results}, nil
+}
+
+func (s *DirectoryServer) Create(_ context.Context, req *bicv1.CreateRequest) (*bicv1.Bank, error) {
+	if req.GetBank() == nil {
+		return nil, status.Error(codes.InvalidArgument, "bank is required")
+	}
+
+	bank := bankFromProto(req.GetBank())
+	if err := validateBankData(bank, s.countryNames); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.storage.AddSwiftCodeEntry(bank); err != nil {
+		return nil, grpcError(err)
+	}
+
+	created, err := storage.Primary(s.storage).GetSwiftCodeDetails(*bank.SwiftCode)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return bankToProto(created), nil
+}
+
+func (s *DirectoryServer) Delete(_ context.Context, req *bicv1.DeleteRequest) (*bicv1.DeleteResponse, error) {
+	if !isValidSwiftCode(req.GetSwiftCode()) {
+		return nil, status.Error(codes.InvalidArgument, "swift-code is invalid")
+	}
+
+	// like If-Match of the REST API, the version guards against deleting a record modified meanwhile
+	if req.GetVersion() == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "version of the record is required")
+	}
+
+	if err := s.storage.DeleteSwiftCodeEntry(req.GetSwiftCode(), req.GetVersion()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &bicv1.DeleteResponse{}, nil
+}
+
+func (s *DirectoryServer) Export(req *bicv1.ExportRequest, stream grpc.ServerStreamingServer[bicv1.Bank]) error {
+	if req.GetCountryIso2() != "" && !bankdata.ValidISO2(req.GetCountryIso2()) {
+		return status.Error(codes.InvalidArgument, "countryISO2code is invalid")
+	}
+
+	pager, ok := s.storageFor(stream.Context()).(storage.SwiftCodePager)
+	if !ok {
+		return status.Error(codes.Unimplemented, "Export is not supported by the storage")
+	}
+
+	// the records are read page by page, so that an export does not hold the whole directory in memory
+	after := ""
+	for {
+		banks, err := pager.ListSwiftCodesPage(req.GetCountryIso2(), after, exportPageSize)
+		if err != nil {
+			return grpcError(err)
+		}
+		if len(banks) == 0 {
+			return nil
+		}
+
+		for _, bank := range banks {
+			if err := stream.Send(bankToProto(&bank)); err != nil {
+				return err
+			}
+		}
+		after = *banks[len(banks)-1].SwiftCode
+	}
+}
+
+// storageFor returns the storage serving a call. Reads are sent to the primary database when the
+// context asks for it, or the call has x-read-primary: true metadata.
+func (s *DirectoryServer) storageFor(ctx context.Context) storage.Storage {
+	if values := metadata.ValueFromIncomingContext(ctx, readPrimaryMetadata); len(values) > 0 && isTrue(values[0]) {
+		ctx = storage.WithPrimaryReads(ctx)
+	}
+	return storage.ForContext(ctx, s.storage)
+}
+
+// grpcError translates storage errors to the gRPC status codes documented in directory.proto.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrSwiftCodeNotFound), errors.Is(err, storage.ErrISO2CodeNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrSwiftCodeExists), errors.Is(err, storage.ErrUniqueViolation):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrVersionMismatch), errors.Is(err, storage.ErrConcurrentModification),
+		errors.Is(err, storage.ErrSerializationFailure):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, storage.ErrCheckViolation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrForeignKeyViolation):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, fmt.Sprintf("storage error: %v", err))
+	}
+}
+
+func isValidSwiftCode(swiftCode string) bool {
+	return len(swiftCode) >= 8 && bankdata.ValidSWIFT(swiftCode, swiftCode[4:6])
+}
+
+func bankToProto(b *storage.Bank) *bicv1.Bank {
+	return &bicv1.Bank{
+		SwiftCode:     ptr.Value(b.SwiftCode),
+		BankName:      ptr.Value(b.BankName),
+		Address:       ptr.Value(b.Address),
+		CountryIso2:   ptr.Value(b.CountryISO2),
+		CountryName:   ptr.Value(b.CountryName),
+		IsHeadquarter: b.IsHeadquarter != nil && *b.IsHeadquarter,
+		Branches:      branchesToProto(b.Branches),
+		Version:       b.Version,
+	}
+}
+
+func branchesToProto(branches []storage.BankBranch) []*bicv1.BankBranch {
+	converted := make([]*bicv1.BankBranch, 0, len(branches))
+	for _, branch := range branches {
+		converted = append(converted, &bicv1.BankBranch{
+			SwiftCode:     branch.SwiftCode,
+			BankName:      branch.BankName,
+			Address:       branch.Address,
+			CountryIso2:   branch.CountryISO2,
+			IsHeadquarter: branch.IsHeadquarter,
+		})
+	}
+	return converted
+}
+
+// bankFromProto converts a bank sent by a client. Proto3 cannot tell an empty string from a missing one,
+// so empty fields are treated as missing, apart from the address which may legitimately be empty.
+func bankFromProto(b *bicv1.Bank) storage.Bank {
+	address := b.GetAddress()
+	isHeadquarter := b.GetIsHeadquarter()
+	return storage.Bank{
+		Address:       &address,
+		BankName:      optionalString(b.GetBankName()),
+		CountryISO2:   optionalString(b.GetCountryIso2()),
+		CountryName:   optionalString(b.GetCountryName()),
+		IsHeadquarter: &isHeadquarter,
+		SwiftCode:     optionalString(b.GetSwiftCode()),
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
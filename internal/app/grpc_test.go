@@ -0,0 +1,311 @@
+//go:build unit
+
+package app
+
+import (
+	"context"
+	bicv1 "github.com/pkacprzak5/bic-data-service/api/bic/v1"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// listingStorage adds ListSwiftCodesPage to mockStorage, for the Export RPC. Its pages hold at most
+// two records, and the position of each page requested is kept in afters.
+type listingStorage struct {
+	*mockStorage
+	banks  []storage.Bank
+	afters []string
+}
+
+func (l *listingStorage) ListSwiftCodesPage(iso2Code, after string, limit int) ([]storage.Bank, error) {
+	l.afters = append(l.afters, after)
+	var page []storage.Bank
+	for _, bank := range l.banks {
+		if *bank.SwiftCode > after && (iso2Code == "" || *bank.CountryISO2 == iso2Code) && len(page) < min(limit, 2) {
+			page = append(page, bank)
+		}
+	}
+	return page, nil
+}
+
+func testBank(swiftCode string, isHeadquarter bool) storage.Bank {
+	return storage.Bank{
+		Address:       strPtr("UL. SENATORSKA 18"),
+		BankName:      strPtr("BRE BANK SA"),
+		CountryISO2:   strPtr(swiftCode[4:6]),
+		CountryName:   strPtr(bankdata.CountryName(swiftCode[4:6])),
+		IsHeadquarter: boolPtr(isHeadquarter),
+		SwiftCode:     strPtr(swiftCode),
+		Version:       3,
+	}
+}
+
+// newDirectoryClient serves the BicDirectory service over an in-memory connection.
+func newDirectoryClient(t *testing.T, s storage.Storage) bicv1.BicDirectoryClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server, _ := newGRPCServer(NewDirectoryServer(s))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return bicv1.NewBicDirectoryClient(conn)
+}
+
+func TestDirectoryServer_GetSwiftCode(t *testing.T) {
+	bank := testBank("BREXPLPWXXX", true)
+	bank.Branches = []storage.BankBranch{{SwiftCode: "BREXPLPWWAL", BankName: "BRE BANK SA", CountryISO2: "PL"}}
+
+	client := newDirectoryClient(t, &mockStorage{
+		GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+			if swiftCode == "BREXPLPWXXX" {
+				return &bank, nil
+			}
+			return nil, storage.ErrSwiftCodeNotFound
+		},
+	})
+	ctx := context.Background()
+
+	got, err := client.GetSwiftCode(ctx, &bicv1.GetSwiftCodeRequest{SwiftCode: "BREXPLPWXXX"})
+	require.NoError(t, err)
+	assert.Equal(t, "BREXPLPWXXX", got.SwiftCode)
+	assert.Equal(t, "POLAND", got.CountryName)
+	assert.True(t, got.IsHeadquarter)
+	assert.Equal(t, int64(3), got.Version)
+	require.Len(t, got.Branches, 1)
+	assert.Equal(t, "BREXPLPWWAL", got.Branches[0].SwiftCode)
+
+	_, err = client.GetSwiftCode(ctx, &bicv1.GetSwiftCodeRequest{SwiftCode: "BREXDEPWXXX"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetSwiftCode(ctx, &bicv1.GetSwiftCodeRequest{SwiftCode: "BREX"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDirectoryServer_ListByCountry(t *testing.T) {
+	client := newDirectoryClient(t, &mockStorage{
+		GetSwiftCodesForCountryFunc: func(iso2Code string) (*storage.CountryBanks, error) {
+			if iso2Code != "PL" {
+				return nil, storage.ErrISO2CodeNotFound
+			}
+			return &storage.CountryBanks{
+				CountryISO2: "PL",
+				CountryName: "POLAND",
+				SwiftCodes:  []storage.BankBranch{{SwiftCode: "BREXPLPWXXX", IsHeadquarter: true, CountryISO2: "PL"}},
+			}, nil
+		},
+	})
+	ctx := context.Background()
+
+	got, err := client.ListByCountry(ctx, &bicv1.ListByCountryRequest{CountryIso2: "PL"})
+	require.NoError(t, err)
+	assert.Equal(t, "POLAND", got.CountryName)
+	require.Len(t, got.SwiftCodes, 1)
+	assert.True(t, got.SwiftCodes[0].IsHeadquarter)
+
+	_, err = client.ListByCountry(ctx, &bicv1.ListByCountryRequest{CountryIso2: "DE"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ListByCountry(ctx, &bicv1.ListByCountryRequest{CountryIso2: "pl"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDirectoryServer_BatchLookup(t *testing.T) {
+	bank := testBank("BREXPLPWXXX", true)
+	client := newDirectoryClient(t, &mockStorage{
+		GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+			if swiftCode == "BREXPLPWXXX" {
+				return &bank, nil
+			}
+			return nil, storage.ErrSwiftCodeNotFound
+		},
+	})
+
+	got, err := client.BatchLookup(context.Background(), &bicv1.BatchLookupRequest{
+		SwiftCodes: []string{"BREXPLPWXXX", "BREXDEPWXXX", "invalid"},
+	})
+	require.NoError(t, err)
+	require.Len(t, got.Results, 3)
+	assert.Equal(t, "BREXPLPWXXX", got.Results[0].Bank.GetSwiftCode())
+	assert.Empty(t, got.Results[0].Error)
+	assert.Nil(t, got.Results[1].Bank)
+	assert.Equal(t, storage.ErrSwiftCodeNotFound.Error(), got.Results[1].Error)
+	assert.Equal(t, "invalid", got.Results[2].SwiftCode)
+	assert.Equal(t, "swiftCode is invalid", got.Results[2].Error)
+
+	_, err = client.BatchLookup(context.Background(), &bicv1.BatchLookupRequest{
+		SwiftCodes: make([]string, maxBatchLookupSize+1),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDirectoryServer_Create(t *testing.T) {
+	var added []storage.Bank
+	client := newDirectoryClient(t, &mockStorage{
+		AddSwiftCodeEntryFunc: func(b storage.Bank) error {
+			if *b.SwiftCode == "BREXPLPWXXX" {
+				return storage.ErrSwiftCodeExists
+			}
+			added = append(added, b)
+			return nil
+		},
+		GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+			bank := added[len(added)-1]
+			bank.Version = 1
+			return &bank, nil
+		},
+	})
+	ctx := context.Background()
+
+	newBank := &bicv1.Bank{
+		SwiftCode:     "BREXPLPWWAL",
+		BankName:      "BRE BANK SA",
+		Address:       "UL. SENATORSKA 18",
+		CountryIso2:   "PL",
+		CountryName:   "poland",
+		IsHeadquarter: false,
+	}
+	got, err := client.Create(ctx, &bicv1.CreateRequest{Bank: newBank})
+	require.NoError(t, err)
+	assert.Equal(t, "BREXPLPWWAL", got.SwiftCode)
+	assert.Equal(t, "POLAND", got.CountryName)
+	assert.Equal(t, int64(1), got.Version)
+	require.Len(t, added, 1)
+
+	newBank.SwiftCode = "BREXPLPWXXX"
+	newBank.IsHeadquarter = true
+	_, err = client.Create(ctx, &bicv1.CreateRequest{Bank: newBank})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	newBank.BankName = ""
+	_, err = client.Create(ctx, &bicv1.CreateRequest{Bank: newBank})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "bankName is required", status.Convert(err).Message())
+
+	_, err = client.Create(ctx, &bicv1.CreateRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDirectoryServer_Delete(t *testing.T) {
+	var deletedVersion int64
+	client := newDirectoryClient(t, &mockStorage{
+		DeleteSwiftCodeEntryFunc: func(swiftCode string, version int64) error {
+			if version != 3 {
+				return storage.ErrVersionMismatch
+			}
+			deletedVersion = version
+			return nil
+		},
+	})
+	ctx := context.Background()
+
+	_, err := client.Delete(ctx, &bicv1.DeleteRequest{SwiftCode: "BREXPLPWWAL", Version: 2})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.Delete(ctx, &bicv1.DeleteRequest{SwiftCode: "BREXPLPWWAL"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.Delete(ctx, &bicv1.DeleteRequest{SwiftCode: "BREXPLPWWAL", Version: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deletedVersion)
+
+	_, err = client.Delete(ctx, &bicv1.DeleteRequest{SwiftCode: "BREX"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDirectoryServer_Export(t *testing.T) {
+	store := &listingStorage{
+		mockStorage: &mockStorage{},
+		banks: []storage.Bank{
+			testBank("BREXDEFFXXX", true),
+			testBank("BREXPLPWWAL", false),
+			testBank("BREXPLPWXXX", true),
+		},
+	}
+	client := newDirectoryClient(t, store)
+
+	export := func(req *bicv1.ExportRequest) []string {
+		stream, err := client.Export(context.Background(), req)
+		require.NoError(t, err)
+
+		var swiftCodes []string
+		for {
+			bank, err := stream.Recv()
+			if err == io.EOF {
+				return swiftCodes
+			}
+			require.NoError(t, err)
+			swiftCodes = append(swiftCodes, bank.SwiftCode)
+		}
+	}
+
+	assert.Equal(t, []string{"BREXDEFFXXX", "BREXPLPWWAL", "BREXPLPWXXX"}, export(&bicv1.ExportRequest{}))
+	assert.Equal(t, []string{"", "BREXPLPWWAL", "BREXPLPWXXX"}, store.afters, "each page follows the last record sent")
+
+	store.afters = nil
+	assert.Equal(t, []string{"BREXPLPWWAL", "BREXPLPWXXX"}, export(&bicv1.ExportRequest{CountryIso2: "PL"}))
+	assert.Equal(t, []string{"", "BREXPLPWXXX"}, store.afters)
+
+	t.Run("storage without listing", func(t *testing.T) {
+		client := newDirectoryClient(t, &mockStorage{})
+		stream, err := client.Export(context.Background(), &bicv1.ExportRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestAPIServer_GRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcAddress := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := NewAPIServer("127.0.0.1:0", &mockStorageApi{}, WithGRPC(grpcAddress))
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Start(ctx)
+	}()
+
+	conn, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Eventually(t, func() bool {
+		res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+			Service: bicv1.BicDirectory_ServiceDesc.ServiceName,
+		})
+		return err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+	}, 2*time.Second, 20*time.Millisecond)
+
+	_, err = bicv1.NewBicDirectoryClient(conn).GetSwiftCode(ctx, &bicv1.GetSwiftCodeRequest{SwiftCode: "BREXPLPWXXX"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	cancel()
+	select {
+	case err := <-errChan:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server did not shut down within the expected time")
+	}
+}
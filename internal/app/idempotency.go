@@ -0,0 +1,161 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// replayedHeaders are the response headers, besides Content-Type, which are stored with the body of a
+// response and sent again when it is replayed.
+var replayedHeaders = []string{"ETag", "Last-Modified", "Link", "Location", "Vary"}
+
+// idempotencyGuard makes POST handlers safe to retry. The response to the first request with a given
+// Idempotency-Key is stored for ttl and replayed for every retry with the same payload.
+type idempotencyGuard struct {
+	store storage.IdempotencyStore
+	ttl   time.Duration
+}
+
+func newIdempotencyGuard(store storage.IdempotencyStore, ttl time.Duration) *idempotencyGuard {
+	return &idempotencyGuard{store: store, ttl: ttl}
+}
+
+func (g *idempotencyGuard) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "Error reading request body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashRequest(r, body)
+		record, err := g.store.ReserveIdempotencyKey(key, requestHash, g.ttl)
+		if err != nil {
+			utils.WriteResponse(w, r, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+			return
+		}
+
+		if record != nil {
+			g.replay(w, r, record, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := g.store.ReleaseIdempotencyKey(key); err != nil {
+					log.Printf("Failed to release idempotency key %q: %v", key, err)
+				}
+			}
+		}()
+
+		next(recorder, r)
+
+		// server errors are not stored, the client should be able to retry them
+		if recorder.status >= http.StatusInternalServerError {
+			return
+		}
+
+		err = g.store.CompleteIdempotencyKey(key, recorder.status, recorder.Header().Get("Content-Type"),
+			storedHeaders(recorder.Header()), recorder.body.Bytes())
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+func (g *idempotencyGuard) replay(w http.ResponseWriter, r *http.Request, record *storage.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		utils.WriteResponse(w, r, http.StatusUnprocessableEntity,
+			storage.Response{Message: "Idempotency-Key has already been used with a different request"})
+		return
+	}
+
+	if record.StatusCode == 0 {
+		utils.WriteResponse(w, r, http.StatusConflict,
+			storage.Response{Message: "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	for name, values := range record.Headers {
+		w.Header()[http.CanonicalHeaderKey(name)] = values
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// storedHeaders returns the replayedHeaders set on a response.
+func storedHeaders(header http.Header) map[string][]string {
+	stored := make(map[string][]string)
+	for _, name := range replayedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			stored[name] = values
+		}
+	}
+	return stored
+}
+
+// hashRequest identifies the request payload and the representation of its response, so that a key
+// reused for another request, or with another Accept header, can be detected.
+func hashRequest(r *http.Request, body []byte) string {
+	contentType, _ := utils.NegotiateContentType(r.Header.Get("Accept"))
+
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	io.WriteString(h, "\n")
+	io.WriteString(h, r.URL.Path)
+	io.WriteString(h, "\n")
+	io.WriteString(h, contentType)
+	io.WriteString(h, "\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
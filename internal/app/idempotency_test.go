@@ -0,0 +1,214 @@
+//go:build unit
+
+package app
+
+import (
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryIdempotencyStore is an in-memory storage.IdempotencyStore used in tests.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*storage.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*storage.IdempotencyRecord)}
+}
+
+func (m *memoryIdempotencyStore) ReserveIdempotencyKey(key, requestHash string, ttl time.Duration) (*storage.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.records[key]; ok && record.ExpiresAt.After(time.Now()) {
+		copied := *record
+		return &copied, nil
+	}
+	m.records[key] = &storage.IdempotencyRecord{Key: key, RequestHash: requestHash, ExpiresAt: time.Now().Add(ttl)}
+	return nil, nil
+}
+
+func (m *memoryIdempotencyStore) CompleteIdempotencyKey(key string, statusCode int, contentType string, headers map[string][]string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record := m.records[key]
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Headers = headers
+	record.Body = body
+	return nil
+}
+
+func (m *memoryIdempotencyStore) ReleaseIdempotencyKey(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+	return nil
+}
+
+func TestIdempotencyGuard(t *testing.T) {
+	validBank := `{
+		"address": "123 Test St",
+		"bankName": "Test Bank",
+		"countryISO2": "PL",
+		"countryName": "POLAND",
+		"isHeadquarter": true,
+		"swiftCode": "TESTPL33XXX"
+	}`
+
+	newService := func(store storage.IdempotencyStore, addFunc func(storage.Bank) error) *BankService {
+		service := NewBankService(&mockStorage{AddSwiftCodeEntryFunc: addFunc})
+		service.idempotency = newIdempotencyGuard(store, time.Hour)
+		return service
+	}
+
+	post := func(service *BankService, key, body string, accept ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(body))
+		for _, a := range accept {
+			req.Header.Set("Accept", a)
+		}
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		service.idempotent(service.handleAddSwiftCodeDetails)(rec, req)
+		return rec
+	}
+
+	t.Run("retry replays the original response", func(t *testing.T) {
+		calls := 0
+		service := newService(newMemoryIdempotencyStore(), func(_ storage.Bank) error {
+			calls++
+			if calls > 1 {
+				return storage.ErrSwiftCodeExists
+			}
+			return nil
+		})
+
+		first := post(service, "key-1", validBank)
+		require.Equal(t, http.StatusOK, first.Code)
+
+		retry := post(service, "key-1", validBank)
+		assert.Equal(t, http.StatusOK, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(idempotencyReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("retry replays the response headers", func(t *testing.T) {
+		calls := 0
+		guard := newIdempotencyGuard(newMemoryIdempotencyStore(), time.Hour)
+		handler := guard.wrap(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("ETag", fmt.Sprintf(`"%d"`, calls))
+			w.Header().Set("Location", "/v1/swift-codes/TESTPL33XXX")
+			w.Header().Set("X-Request-Id", fmt.Sprint(calls))
+			utils.WriteResponse(w, r, http.StatusCreated, storage.Response{Message: "created"})
+		})
+		post := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(validBank))
+			req.Header.Set(idempotencyKeyHeader, "key-1")
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			return rec
+		}
+
+		first := post()
+		require.Equal(t, http.StatusCreated, first.Code)
+
+		retry := post()
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+		assert.Equal(t, "/v1/swift-codes/TESTPL33XXX", retry.Header().Get("Location"))
+		assert.Equal(t, first.Header().Values("Vary"), retry.Header().Values("Vary"))
+		assert.Empty(t, retry.Header().Get("X-Request-Id"), "only the headers describing the response are replayed")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("key reused with different payload", func(t *testing.T) {
+		service := newService(newMemoryIdempotencyStore(), func(_ storage.Bank) error { return nil })
+
+		require.Equal(t, http.StatusOK, post(service, "key-1", validBank).Code)
+
+		other := strings.Replace(validBank, "Test Bank", "Other Bank", 1)
+		rec := post(service, "key-1", other)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("key reused with different Accept", func(t *testing.T) {
+		service := newService(newMemoryIdempotencyStore(), func(_ storage.Bank) error { return nil })
+
+		require.Equal(t, http.StatusOK, post(service, "key-1", validBank, "application/json").Code)
+
+		rec := post(service, "key-1", validBank, "application/xml")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("request still in progress", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		service := newService(store, func(_ storage.Bank) error { return nil })
+
+		req := httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(validBank))
+		_, err := store.ReserveIdempotencyKey("key-1", hashRequest(req, []byte(validBank)), time.Hour)
+		require.NoError(t, err)
+
+		rec := post(service, "key-1", validBank)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		calls := 0
+		service := newService(newMemoryIdempotencyStore(), func(_ storage.Bank) error {
+			calls++
+			if calls == 1 {
+				return assert.AnError
+			}
+			return nil
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, post(service, "key-1", validBank).Code)
+		assert.Equal(t, http.StatusOK, post(service, "key-1", validBank).Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("client errors are replayed", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		service := newService(store, nil)
+
+		first := post(service, "key-1", `{"bankName": "No Address"}`)
+		require.Equal(t, http.StatusBadRequest, first.Code)
+
+		retry := post(service, "key-1", `{"bankName": "No Address"}`)
+		assert.Equal(t, http.StatusBadRequest, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get(idempotencyReplayedHeader))
+	})
+
+	t.Run("requests without key are not tracked", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		service := newService(store, func(_ storage.Bank) error { return nil })
+
+		assert.Equal(t, http.StatusOK, post(service, "", validBank).Code)
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		service := newService(newMemoryIdempotencyStore(), nil)
+
+		rec := post(service, strings.Repeat("k", maxIdempotencyKeyLength+1), validBank)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
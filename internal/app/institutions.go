@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"net/http"
+	"regexp"
+)
+
+var bic8Pattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}$`)
+
+// handleGetInstitution returns the headquarter and all branches sharing the BIC8, also when the
+// headquarter itself is not stored.
+func (s *BankService) handleGetInstitution(w http.ResponseWriter, r *http.Request) {
+	bic8 := r.PathValue("bic8")
+	if !bic8Pattern.MatchString(bic8) || !bankdata.ValidISO2(bic8[4:6]) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "bic8 is invalid"})
+		return
+	}
+
+	reader, err := readerFor[storage.InstitutionReader](r, s.storage)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	institution, err := reader.GetInstitution(bic8)
+	if err != nil && errors.Is(err, storage.ErrInstitutionNotFound) {
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
+		return
+	} else if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK, institution)
+}
+
+// handleListInstitutions lists the institutions with the numbers of their branches, of the country
+// given by the country query parameter or of all countries.
+func (s *BankService) handleListInstitutions(w http.ResponseWriter, r *http.Request) {
+	countryISO2 := r.URL.Query().Get("country")
+	if countryISO2 != "" && !bankdata.ValidISO2(countryISO2) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "country is invalid"})
+		return
+	}
+
+	reader, err := readerFor[storage.InstitutionReader](r, s.storage)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	institutions, err := reader.ListInstitutions(countryISO2)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK, storage.InstitutionList{Institutions: institutions})
+}
@@ -0,0 +1,117 @@
+//go:build unit
+
+package app
+
+import (
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// institutionStorage is a mockStorage which can group its records by BIC8.
+type institutionStorage struct {
+	mockStorage
+	GetInstitutionFunc   func(bic8 string) (*storage.Institution, error)
+	ListInstitutionsFunc func(iso2Code string) ([]storage.InstitutionSummary, error)
+}
+
+func (m *institutionStorage) GetInstitution(bic8 string) (*storage.Institution, error) {
+	return m.GetInstitutionFunc(bic8)
+}
+
+func (m *institutionStorage) ListInstitutions(iso2Code string) ([]storage.InstitutionSummary, error) {
+	return m.ListInstitutionsFunc(iso2Code)
+}
+
+func TestInstitutionsRoutes(t *testing.T) {
+	s := &institutionStorage{
+		GetInstitutionFunc: func(bic8 string) (*storage.Institution, error) {
+			if bic8 != "BREXPLPW" {
+				return nil, storage.ErrInstitutionNotFound
+			}
+			return &storage.Institution{
+				BIC8: bic8, BankName: "MBANK S.A.", CountryISO2: "PL", CountryName: "POLAND",
+				Branches: []storage.BankBranch{
+					{Address: "WARSZAWA", BankName: "MBANK S.A.", CountryISO2: "PL", SwiftCode: "BREXPLPWWAL"},
+				},
+			}, nil
+		},
+		ListInstitutionsFunc: func(iso2Code string) ([]storage.InstitutionSummary, error) {
+			return []storage.InstitutionSummary{
+				{BIC8: "BREXPLPW", BankName: "MBANK S.A.", CountryISO2: iso2Code, HasHeadquarter: true, Branches: 2},
+			}, nil
+		},
+	}
+
+	get := func(s storage.Storage, path, accept string) *httptest.ResponseRecorder {
+		router := http.NewServeMux()
+		NewBankService(s).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("institution without headquarter", func(t *testing.T) {
+		rec := get(s, "/institutions/BREXPLPW", "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"bic8":"BREXPLPW","bankName":"MBANK S.A.","countryISO2":"PL","countryName":"POLAND","headquarter":null,
+			"branches":[{"address":"WARSZAWA","bankName":"MBANK S.A.","countryISO2":"PL","isHeadquarter":false,"swiftCode":"BREXPLPWWAL"}]}`,
+			rec.Body.String())
+	})
+
+	t.Run("institution as csv", func(t *testing.T) {
+		rec := get(s, "/institutions/BREXPLPW", "text/csv")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n"+
+			"BREXPLPWWAL,MBANK S.A.,WARSZAWA,PL,POLAND,false\n", rec.Body.String())
+	})
+
+	t.Run("institution not found", func(t *testing.T) {
+		rec := get(s, "/institutions/ALBPPLPW", "application/json")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.JSONEq(t, `{"message":"Institution with given BIC8 not found","code":"institution_not_found"}`, rec.Body.String())
+	})
+
+	t.Run("invalid bic8", func(t *testing.T) {
+		for _, bic8 := range []string{"BREXPLPWXXX", "brexplpw", "BREXXXPW", "BREX"} {
+			rec := get(s, "/institutions/"+bic8, "application/json")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, bic8)
+			assert.JSONEq(t, `{"message":"bic8 is invalid"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := get(s, "/institutions?country=PL", "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"institutions":[{"bic8":"BREXPLPW","bankName":"MBANK S.A.","countryISO2":"PL","hasHeadquarter":true,"branches":2}]}`,
+			rec.Body.String())
+	})
+
+	t.Run("list as csv", func(t *testing.T) {
+		rec := get(s, "/institutions?country=PL", "text/csv")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "bic8,bankName,countryISO2,hasHeadquarter,branches\nBREXPLPW,MBANK S.A.,PL,true,2\n", rec.Body.String())
+	})
+
+	t.Run("list with invalid country", func(t *testing.T) {
+		rec := get(s, "/institutions?country=pl", "application/json")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"message":"country is invalid"}`, rec.Body.String())
+	})
+
+	t.Run("storage without institutions", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(&mockStorage{}, "/institutions?country=PL", "application/json").Code)
+	})
+}
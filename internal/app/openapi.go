@@ -0,0 +1,19 @@
+package app
+
+import (
+	_ "embed"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"net/http"
+)
+
+// openAPISpec describes every route of the REST API. TestOpenAPISpec_CoversRoutes fails when a route
+// is registered without being described here.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", utils.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,164 @@
+//go:build unit
+
+package app
+
+import (
+	"encoding/json"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingRouter collects the patterns registered with it.
+type recordingRouter struct {
+	prefix   string
+	patterns *[]string
+}
+
+func (r recordingRouter) Handle(pattern string, _ http.Handler) {
+	r.record(pattern)
+}
+
+func (r recordingRouter) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	r.record(pattern)
+}
+
+func (r recordingRouter) record(pattern string) {
+	method, path, _ := strings.Cut(pattern, " ")
+	*r.patterns = append(*r.patterns, method+" "+r.prefix+path)
+}
+
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Servers []struct{ URL string }                `json:"servers"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func loadOpenAPISpec(t *testing.T) openAPIDocument {
+	var spec openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &spec))
+	return spec
+}
+
+// registeredRoutes returns the routes of an APIServer with every optional service enabled.
+func registeredRoutes() []string {
+	var patterns []string
+	server := NewAPIServer(":0", storage.NewCachedStorage(&mockStorage{}, storage.CacheConfig{Size: 1, TTL: time.Minute}),
+		WithIdempotency(newMemoryIdempotencyStore(), time.Hour),
+		WithWebhooks(newMemoryWebhookStore()),
+		WithEvents(NewEventBroker(1)))
+	server.registerRoutes(recordingRouter{patterns: &patterns}, recordingRouter{prefix: "/v1", patterns: &patterns})
+	return patterns
+}
+
+func TestOpenAPISpec_CoversRoutes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	require.Len(t, spec.Servers, 1)
+	prefix := spec.Servers[0].URL
+
+	documented := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method != "parameters" {
+				documented[strings.ToUpper(method)+" "+prefix+path] = true
+			}
+		}
+	}
+
+	routes := registeredRoutes()
+	require.NotEmpty(t, routes)
+	for _, route := range routes {
+		assert.True(t, documented[route], "route %s is missing from openapi.json", route)
+		delete(documented, route)
+	}
+	for operation := range documented {
+		t.Errorf("openapi.json describes %s, which is not registered", operation)
+	}
+}
+
+func TestOpenAPISpec_Valid(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	assert.Equal(t, "3.1.0", spec.OpenAPI)
+
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(openAPISpec, &document))
+
+	// every reference must point to a defined component
+	refs := regexp.MustCompile(`"\$ref":\s*"#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(openAPISpec), -1)
+	require.NotEmpty(t, refs)
+	components := document["components"].(map[string]any)
+	for _, ref := range refs {
+		section, ok := components[ref[1]].(map[string]any)
+		require.True(t, ok, "components/%s is not defined", ref[1])
+		assert.Contains(t, section, ref[2], "components/%s/%s is not defined", ref[1], ref[2])
+	}
+
+	// every path parameter must be declared by every operation of the path
+	var parameters struct {
+		Components struct {
+			Parameters map[string]openAPIParameter `json:"parameters"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(openAPISpec, &parameters))
+	declared := func(raw json.RawMessage) map[string]bool {
+		var operation struct {
+			Parameters []openAPIParameter `json:"parameters"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &operation))
+
+		names := make(map[string]bool)
+		for _, parameter := range operation.Parameters {
+			if name, ok := strings.CutPrefix(parameter.Ref, "#/components/parameters/"); ok {
+				parameter = parameters.Components.Parameters[name]
+			}
+			if parameter.In == "path" {
+				names[parameter.Name] = true
+			}
+		}
+		return names
+	}
+
+	for path, item := range spec.Paths {
+		var shared map[string]bool
+		if raw, ok := item["parameters"]; ok {
+			shared = declared(json.RawMessage(`{"parameters":` + string(raw) + `}`))
+		}
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			names := declared(raw)
+			for _, match := range regexp.MustCompile(`\{(\w+)\}`).FindAllStringSubmatch(path, -1) {
+				assert.True(t, names[match[1]] || shared[match[1]],
+					"path parameter %s is not declared by %s %s", match[1], method, path)
+			}
+		}
+	}
+}
+
+type openAPIParameter struct {
+	Ref  string `json:"$ref"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	router := http.NewServeMux()
+	NewAPIServer(":0", &mockStorage{}).registerRoutes(router, http.NewServeMux())
+
+	// served as JSON whatever the client accepts
+	req := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(openAPISpec), rr.Body.String())
+}
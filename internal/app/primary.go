@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// Requests carrying X-Read-Primary: true (x-read-primary metadata in gRPC) read from the primary
+// database rather than a read replica, so that they see writes which replicas have not received yet.
+const (
+	readPrimaryHeader   = "X-Read-Primary"
+	readPrimaryMetadata = "x-read-primary"
+)
+
+// readPrimary marks the context of requests carrying the X-Read-Primary header with storage.WithPrimaryReads.
+func readPrimary(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isTrue(r.Header.Get(readPrimaryHeader)) {
+			r = r.WithContext(storage.WithPrimaryReads(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isTrue(value string) bool {
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+// readerFor returns the storage serving the reads of a request as T, one of the optional interfaces
+// of storages such as storage.StatsProvider. The storage of storage.ForContext is used if it implements
+// T, otherwise s; an error is returned if neither does.
+func readerFor[T any](r *http.Request, s storage.Storage) (T, error) {
+	if reader, ok := storage.ForContext(r.Context(), s).(T); ok {
+		return reader, nil
+	}
+	reader, ok := s.(T)
+	if !ok {
+		return reader, fmt.Errorf("%s is not implemented by the storage", reflect.TypeFor[T]())
+	}
+	return reader, nil
+}
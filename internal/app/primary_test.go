@@ -0,0 +1,94 @@
+//go:build unit
+
+package app
+
+import (
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// replicatedStorage reads from replica, and from primary when primary reads are requested.
+type replicatedStorage struct {
+	*mockStorage
+	primary *mockStorage
+}
+
+func (s *replicatedStorage) Primary() storage.Storage {
+	return s.primary
+}
+
+func newReplicatedStorage(reads map[string]int) *replicatedStorage {
+	lookup := func(name string) func(string) (*storage.Bank, error) {
+		return func(swiftCode string) (*storage.Bank, error) {
+			reads[name]++
+			bank := testBank(swiftCode, true)
+			return &bank, nil
+		}
+	}
+	deleteEntry := func(string, int64) error { return nil }
+	return &replicatedStorage{
+		mockStorage: &mockStorage{GetSwiftCodeDetailsFunc: lookup("replica"), DeleteSwiftCodeEntryFunc: deleteEntry},
+		primary:     &mockStorage{GetSwiftCodeDetailsFunc: lookup("primary"), DeleteSwiftCodeEntryFunc: deleteEntry},
+	}
+}
+
+func TestReadPrimary(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		header   http.Header
+		expected string
+	}{
+		{name: "reads from replica", method: http.MethodGet, expected: "replica"},
+		{name: "header forces primary", method: http.MethodGet, header: http.Header{"X-Read-Primary": {"true"}}, expected: "primary"},
+		{name: "false header", method: http.MethodGet, header: http.Header{"X-Read-Primary": {"false"}}, expected: "replica"},
+		{name: "If-Match checked on primary", method: http.MethodDelete, header: http.Header{"If-Match": {"*"}}, expected: "primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reads := make(map[string]int)
+			handler := NewAPIServer("", newReplicatedStorage(reads)).Handler()
+
+			req := httptest.NewRequest(tt.method, "/v1/swift-codes/BREXPLPWXXX", nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, map[string]int{tt.expected: 1}, reads)
+		})
+	}
+}
+
+// replicatedStats provides stats from its replicas only, its primary is a plain storage.
+type replicatedStats struct {
+	*statsStorage
+}
+
+func (s replicatedStats) Primary() storage.Storage {
+	return &mockStorage{}
+}
+
+func TestReaderFor(t *testing.T) {
+	replica := &statsStorage{}
+	primaryReads := httptest.NewRequest(http.MethodGet, "/v1/stats", nil)
+	primaryReads = primaryReads.WithContext(storage.WithPrimaryReads(primaryReads.Context()))
+
+	provider, err := readerFor[storage.StatsProvider](httptest.NewRequest(http.MethodGet, "/v1/stats", nil), replica)
+	assert.NoError(t, err)
+	assert.Same(t, replica, provider)
+
+	// a primary without the interface falls back to the storage
+	provider, err = readerFor[storage.StatsProvider](primaryReads, replicatedStats{replica})
+	assert.NoError(t, err)
+	assert.Equal(t, replicatedStats{replica}, provider)
+
+	_, err = readerFor[storage.StatsProvider](primaryReads, &mockStorage{})
+	assert.EqualError(t, err, "storage.StatsProvider is not implemented by the storage")
+}
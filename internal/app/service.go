@@ -4,139 +4,536 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
 	"github.com/pkacprzak5/bic-data-service/pkg/utils"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxBatchSize    = 1000
 )
 
 type BankService struct {
-	storage storage.Storage
+	storage      storage.Storage
+	idempotency  *idempotencyGuard
+	countryNames bankdata.CountryNames
 }
 
 func NewBankService(s storage.Storage) *BankService {
-	return &BankService{storage: s}
+	return &BankService{storage: s, countryNames: bankdata.CountryNamesAliases}
+}
+
+// Router is the part of http.ServeMux that services register their routes with.
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
 }
 
-func (s *BankService) RegisterRoutes(router *http.ServeMux) {
+func (s *BankService) RegisterRoutes(router Router) {
 	router.HandleFunc("GET /swift-codes/{swiftCode}", s.handleGetSwiftCodeDetails)
 	router.HandleFunc("GET /swift-codes/country/{countryISO2code}", s.handleGetCountrySwiftCodes)
-	router.HandleFunc("POST /swift-codes", s.handleAddSwiftCodeDetails)
+	router.HandleFunc("POST /swift-codes", s.idempotent(s.handleAddSwiftCodeDetails))
+	router.HandleFunc("PUT /swift-codes/{swiftCode}", s.handleUpdateSwiftCodeDetails)
 	router.HandleFunc("DELETE /swift-codes/{swiftCode}", s.handleDeleteSwiftCode)
+
+	if _, ok := s.storage.(storage.ChangeApplier); ok {
+		router.HandleFunc("POST /swift-codes/batch", s.idempotent(s.handleAddSwiftCodesBatch))
+	}
+	if cache, ok := s.storage.(cacheStatsProvider); ok {
+		router.HandleFunc("GET /cache/stats", func(w http.ResponseWriter, r *http.Request) {
+			utils.WriteResponse(w, r, http.StatusOK, cache.CacheStats())
+		})
+	}
+	if _, ok := s.storage.(storage.StatsProvider); ok {
+		router.HandleFunc("GET /stats", s.handleGetStats)
+	}
+	if _, ok := s.storage.(storage.CountryLister); ok {
+		router.HandleFunc("GET /countries", s.handleListCountries)
+		router.HandleFunc("GET /countries/{countryISO2code}", s.handleGetCountry)
+	}
+	if _, ok := s.storage.(storage.InstitutionReader); ok {
+		router.HandleFunc("GET /institutions", s.handleListInstitutions)
+		router.HandleFunc("GET /institutions/{bic8}", s.handleGetInstitution)
+	}
+}
+
+// cacheStatsProvider is implemented by storages which cache lookups, such as storage.CachedStorage.
+type cacheStatsProvider interface {
+	CacheStats() storage.CacheStats
+}
+
+// idempotent enables Idempotency-Key support for the handler, if an idempotency store is configured.
+func (s *BankService) idempotent(handler http.HandlerFunc) http.HandlerFunc {
+	if s.idempotency == nil {
+		return handler
+	}
+	return s.idempotency.wrap(handler)
 }
 
 func (s *BankService) handleGetSwiftCodeDetails(w http.ResponseWriter, r *http.Request) {
 	swiftCode := r.PathValue("swiftCode")
 	if swiftCode == "" {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "swiftCode not found in path"})
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swiftCode not found in path"})
+		return
+	}
+
+	if len(swiftCode) < 8 || !bankdata.ValidSWIFT(swiftCode, swiftCode[4:6]) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swiftCode is invalid"})
 		return
 	}
 
-	if !isValidSWIFT(swiftCode, swiftCode[4:6]) {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "swiftCode is invalid"})
+	asOf, ok, err := parseAsOf(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
 		return
 	}
 
-	bank, err := s.storage.GetSwiftCodeDetails(swiftCode)
+	store := storage.ForContext(r.Context(), s.storage)
+	var bank *storage.Bank
+	if ok {
+		bank, err = store.GetSwiftCodeDetailsAsOf(swiftCode, asOf)
+	} else {
+		bank, err = store.GetSwiftCodeDetails(swiftCode)
+	}
 	if err != nil && errors.Is(err, storage.ErrSwiftCodeNotFound) {
-		utils.WriteJSON(w, http.StatusNotFound, storage.Response{Message: err.Error()})
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
 		return
 	} else if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+		writeStorageError(w, r, err)
+		return
+	}
+
+	etag := responseEntityTag(r, bank)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && matchesIfNoneMatch(ifNoneMatch, etag) {
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, bank)
+	utils.WriteResponse(w, r, http.StatusOK, bank)
 }
 
 func (s *BankService) handleGetCountrySwiftCodes(w http.ResponseWriter, r *http.Request) {
 	countryISO2code := r.PathValue("countryISO2code")
 	if countryISO2code == "" {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "countryISO2code not found in path"})
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "countryISO2code not found in path"})
+		return
+	}
+
+	if countryISO2code != strings.ToUpper(countryISO2code) || !bankdata.ValidISO2(countryISO2code) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "countryISO2code is invalid"})
 		return
 	}
 
-	if countryISO2code != strings.ToUpper(countryISO2code) || !isValidISO2(countryISO2code) {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "countryISO2code is invalid"})
+	asOf, ok, err := parseAsOf(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
 		return
 	}
 
-	swiftCodes, err := s.storage.GetSwiftCodesForCountry(countryISO2code)
+	page, paginated, err := parsePage(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
+		return
+	}
+
+	store := storage.ForContext(r.Context(), s.storage)
+	pager, canPage := store.(storage.CountryPager)
+	var swiftCodes *storage.CountryBanks
+	switch {
+	case paginated && canPage && ok:
+		swiftCodes, err = pager.GetSwiftCodesForCountryPageAsOf(countryISO2code, page.after, page.limit+1, asOf)
+	case paginated && canPage:
+		swiftCodes, err = pager.GetSwiftCodesForCountryPage(countryISO2code, page.after, page.limit+1)
+	case ok:
+		swiftCodes, err = store.GetSwiftCodesForCountryAsOf(countryISO2code, asOf)
+	default:
+		swiftCodes, err = store.GetSwiftCodesForCountry(countryISO2code)
+	}
 	if err != nil && errors.Is(err, storage.ErrISO2CodeNotFound) {
-		utils.WriteJSON(w, http.StatusNotFound, storage.Response{Message: err.Error()})
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
 		return
 	} else if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+		writeStorageError(w, r, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, swiftCodes)
+	if paginated {
+		if !canPage {
+			swiftCodes.SwiftCodes = page.slice(swiftCodes.SwiftCodes)
+		}
+		page.trim(w, r, swiftCodes)
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK, swiftCodes)
+}
+
+// swiftCodePage is the page selected by the optional limit and after query parameters. Paginated lists are
+// ordered by SWIFT code, and the next page is announced in a Link header with rel="next".
+type swiftCodePage struct {
+	after string
+	limit int
+}
+
+// parsePage reads the page of the request, paginated is false if neither parameter is given, in which case
+// the whole list is returned, in storage order.
+func parsePage(r *http.Request) (page swiftCodePage, paginated bool, err error) {
+	query := r.URL.Query()
+	if !query.Has("limit") && !query.Has("after") {
+		return page, false, nil
+	}
+
+	page = swiftCodePage{after: query.Get("after"), limit: defaultPageSize}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			return page, false, fmt.Errorf("limit must be a number between 1 and %d", maxPageSize)
+		}
+		page.limit = parsed
+	}
+	return page, true, nil
+}
+
+// slice selects the page from a whole list, for storages which cannot page through it themselves. Like a
+// storage.CountryPager asked for limit+1 codes, it keeps one code past the page to tell if there is a next one.
+func (p swiftCodePage) slice(swiftCodes []storage.BankBranch) []storage.BankBranch {
+	swiftCodes = slices.Clone(swiftCodes)
+	slices.SortFunc(swiftCodes, func(a, b storage.BankBranch) int {
+		return strings.Compare(a.SwiftCode, b.SwiftCode)
+	})
+
+	start, _ := slices.BinarySearchFunc(swiftCodes, p.after, func(branch storage.BankBranch, target string) int {
+		if branch.SwiftCode <= target {
+			return -1
+		}
+		return 1
+	})
+	swiftCodes = swiftCodes[start:]
+	return swiftCodes[:min(len(swiftCodes), p.limit+1)]
+}
+
+// trim cuts the codes following the page and links to the next page if there were any.
+func (p swiftCodePage) trim(w http.ResponseWriter, r *http.Request, countryBanks *storage.CountryBanks) {
+	if len(countryBanks.SwiftCodes) <= p.limit {
+		return
+	}
+	countryBanks.SwiftCodes = countryBanks.SwiftCodes[:p.limit]
+
+	query := r.URL.Query()
+	next := url.URL{Path: requestPath(r)}
+	query.Set("after", countryBanks.SwiftCodes[p.limit-1].SwiftCode)
+	query.Set("limit", strconv.Itoa(p.limit))
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
+
+// requestPath returns the path requested by the client, before any prefix was stripped by the router.
+func requestPath(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil && r.RequestURI != "" {
+		return u.Path
+	}
+	return r.URL.Path
 }
 
 func (s *BankService) handleAddSwiftCodeDetails(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+	bank, err := decodeBankBody(r)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "Error reading request body"})
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
 		return
 	}
 
-	defer func(Body io.ReadCloser) {
-		if err := Body.Close(); err != nil {
-			log.Println(fmt.Sprintf("Error closing request body: %v", err))
+	if err := validateBankData(bank, s.countryNames); err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
+		return
+	}
+
+	err = s.storage.AddSwiftCodeEntry(bank)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK,
+		storage.Response{
+			Message:     fmt.Sprintf("Successfully added bank with swift code %s", *bank.SwiftCode),
+			CountryName: *bank.CountryName,
+		})
+}
+
+// handleAddSwiftCodesBatch adds all banks of the request in a single transaction: either every one of
+// them is added or, if any is invalid or already exists, none is.
+func (s *BankService) handleAddSwiftCodesBatch(w http.ResponseWriter, r *http.Request) {
+	var banks []storage.Bank
+	if err := json.NewDecoder(r.Body).Decode(&banks); err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "Error parsing request body"})
+		return
+	}
+
+	if len(banks) == 0 || len(banks) > maxBatchSize {
+		utils.WriteResponse(w, r, http.StatusBadRequest,
+			storage.Response{Message: fmt.Sprintf("a batch must contain between 1 and %d banks", maxBatchSize)})
+		return
+	}
+
+	changes := make([]storage.Change, len(banks))
+	seen := make(map[string]bool, len(banks))
+	for i := range banks {
+		if err := validateBankData(banks[i], s.countryNames); err != nil {
+			utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: fmt.Sprintf("bank %d: %v", i, err)})
 			return
 		}
-	}(r.Body)
+		if seen[*banks[i].SwiftCode] {
+			utils.WriteResponse(w, r, http.StatusBadRequest,
+				storage.Response{Message: fmt.Sprintf("bank %d: swiftCode %s is repeated in the batch", i, *banks[i].SwiftCode)})
+			return
+		}
+		seen[*banks[i].SwiftCode] = true
+		changes[i] = storage.Change{Action: storage.ChangeAdd, After: &banks[i]}
+	}
 
-	var bank storage.Bank
-	err = json.Unmarshal(body, &bank)
+	err := s.storage.(storage.ChangeApplier).ApplyChanges(changes, "api:batch")
 	if err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "Error parsing request body"})
+		writeStorageError(w, r, err)
 		return
 	}
 
-	if err := validateBankData(bank); err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: err.Error()})
+	utils.WriteResponse(w, r, http.StatusOK,
+		storage.Response{Message: fmt.Sprintf("Successfully added %d banks", len(banks))})
+}
+
+func (s *BankService) handleUpdateSwiftCodeDetails(w http.ResponseWriter, r *http.Request) {
+	swiftCode := r.PathValue("swiftCode")
+	if swiftCode == "" {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swift-code not found in path"})
 		return
 	}
 
-	err = s.storage.AddSwiftCodeEntry(bank)
-	if err != nil && errors.Is(err, storage.ErrSwiftCodeExists) {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: err.Error()})
+	if len(swiftCode) < 8 || !bankdata.ValidSWIFT(swiftCode, swiftCode[4:6]) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swift-code is invalid"})
 		return
-	} else if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+	}
+
+	bank, err := decodeBankBody(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK,
-		storage.Response{Message: fmt.Sprintf("Successfully added bank with swift code %s", *bank.SwiftCode)})
+	if bank.SwiftCode == nil {
+		bank.SwiftCode = &swiftCode
+	} else if *bank.SwiftCode != swiftCode {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swiftCode does not match the one in path"})
+		return
+	}
+
+	if err := validateBankData(bank, s.countryNames); err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
+		return
+	}
+
+	current, ok := s.checkIfMatch(w, r, swiftCode)
+	if !ok {
+		return
+	}
+
+	err = s.storage.UpdateSwiftCodeEntry(bank, current.Version)
+	if !writeVersionedError(w, r, err) {
+		return
+	}
+
+	current.Version++
+	w.Header().Set("ETag", responseEntityTag(r, current))
+	utils.WriteResponse(w, r, http.StatusOK,
+		storage.Response{
+			Message:     fmt.Sprintf("Successfully updated bank with swift code %s", swiftCode),
+			CountryName: *bank.CountryName,
+		})
 }
 
 func (s *BankService) handleDeleteSwiftCode(w http.ResponseWriter, r *http.Request) {
 	swiftCode := r.PathValue("swiftCode")
 	if swiftCode == "" {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "swift-code not found in path"})
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swift-code not found in path"})
 		return
 	}
 
-	iso2Code := swiftCode[4:6] // part of SWIFT code requirements
-
-	if !isValidISO2(iso2Code) || !isValidSWIFT(swiftCode, iso2Code) {
-		utils.WriteJSON(w, http.StatusBadRequest, storage.Response{Message: "swift-code is invalid"})
+	if len(swiftCode) < 8 || !bankdata.ValidISO2(swiftCode[4:6]) || !bankdata.ValidSWIFT(swiftCode, swiftCode[4:6]) {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "swift-code is invalid"})
 		return
 	}
 
-	err := s.storage.DeleteSwiftCodeEntry(swiftCode)
-	if err != nil && errors.Is(err, storage.ErrSwiftCodeNotFound) {
-		utils.WriteJSON(w, http.StatusNotFound, storage.Response{Message: err.Error()})
+	current, ok := s.checkIfMatch(w, r, swiftCode)
+	if !ok {
 		return
-	} else if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+	}
+
+	err := s.storage.DeleteSwiftCodeEntry(swiftCode, current.Version)
+	if !writeVersionedError(w, r, err) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK,
+	utils.WriteResponse(w, r, http.StatusOK,
 		storage.Response{Message: fmt.Sprintf("Bank with swift code: %s has been deleted", swiftCode)})
 }
+
+func (s *BankService) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	provider, err := readerFor[storage.StatsProvider](r, s.storage)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	stats, err := provider.Stats()
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+
+	if stats.LastModified != nil {
+		w.Header().Set("Last-Modified", stats.LastModified.UTC().Format(http.TimeFormat))
+	}
+	utils.WriteResponse(w, r, http.StatusOK, stats)
+}
+
+// checkIfMatch loads the current record and verifies the If-Match precondition of a write request.
+// It writes the error response and returns false if the request must not proceed.
+func (s *BankService) checkIfMatch(w http.ResponseWriter, r *http.Request, swiftCode string) (*storage.Bank, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		utils.WriteResponse(w, r, http.StatusPreconditionRequired,
+			storage.Response{Message: "If-Match header with the ETag of the record is required"})
+		return nil, false
+	}
+
+	// the precondition is checked against the latest version, not a possibly lagging replica
+	current, err := storage.Primary(s.storage).GetSwiftCodeDetails(swiftCode)
+	if err != nil && errors.Is(err, storage.ErrSwiftCodeNotFound) {
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
+		return nil, false
+	} else if err != nil {
+		writeStorageError(w, r, err)
+		return nil, false
+	}
+
+	if !matchesIfMatch(ifMatch, current) {
+		w.Header().Set("ETag", responseEntityTag(r, current))
+		utils.WriteResponse(w, r, http.StatusPreconditionFailed, errorResponse(storage.ErrVersionMismatch))
+		return nil, false
+	}
+
+	return current, true
+}
+
+// writeVersionedError writes the error response of a conditional write and reports whether err was nil.
+func writeVersionedError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err != nil && errors.Is(err, storage.ErrSwiftCodeNotFound) {
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
+		return false
+	} else if err != nil && errors.Is(err, storage.ErrVersionMismatch) {
+		utils.WriteResponse(w, r, http.StatusPreconditionFailed, errorResponse(err))
+		return false
+	} else if err != nil {
+		writeStorageError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// writeStorageError writes the response to an error of the storage, with the status code of its class.
+func writeStorageError(w http.ResponseWriter, r *http.Request, err error) {
+	utils.WriteResponse(w, r, storageErrorStatus(err), errorResponse(err))
+}
+
+// errorCodes identify the errors of the storage in error responses. They are part of the API and
+// must not change.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{storage.ErrSwiftCodeNotFound, "swift_code_not_found"},
+	{storage.ErrISO2CodeNotFound, "iso2_code_not_found"},
+	{storage.ErrInstitutionNotFound, "institution_not_found"},
+	{storage.ErrSwiftCodeExists, "swift_code_exists"},
+	{storage.ErrVersionMismatch, "version_mismatch"},
+	{storage.ErrConcurrentModification, "concurrent_modification"},
+	{storage.ErrWebhookNotFound, "webhook_not_found"},
+	{storage.ErrWebhookDeliveryNotFound, "webhook_delivery_not_found"},
+}
+
+// errorResponse is the response to err, with the code of the storage error it wraps, if any.
+func errorResponse(err error) storage.Response {
+	response := storage.Response{Message: err.Error()}
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			response.Code = known.code
+			break
+		}
+	}
+	return response
+}
+
+func storageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrSwiftCodeNotFound), errors.Is(err, storage.ErrISO2CodeNotFound),
+		errors.Is(err, storage.ErrInstitutionNotFound), errors.Is(err, storage.ErrWebhookNotFound), errors.Is(err, storage.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrSwiftCodeExists), errors.Is(err, storage.ErrUniqueViolation),
+		errors.Is(err, storage.ErrConcurrentModification), errors.Is(err, storage.ErrSerializationFailure):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrCheckViolation), errors.Is(err, storage.ErrForeignKeyViolation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, storage.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// decodeBankBody reads the JSON bank representation sent in the request body.
+func decodeBankBody(r *http.Request) (storage.Bank, error) {
+	var bank storage.Bank
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return bank, errors.New("Error reading request body")
+	}
+
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Println(fmt.Sprintf("Error closing request body: %v", err))
+			return
+		}
+	}(r.Body)
+
+	if err := json.Unmarshal(body, &bank); err != nil {
+		return bank, errors.New("Error parsing request body")
+	}
+	return bank, nil
+}
+
+// parseAsOf reads the optional asOf query parameter used for point-in-time queries.
+func parseAsOf(r *http.Request) (time.Time, bool, error) {
+	value := r.URL.Query().Get("asOf")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, errors.New("asOf must be an RFC 3339 timestamp, e.g. 2026-03-01T00:00:00Z")
+	}
+	return asOf, true, nil
+}
@@ -3,14 +3,20 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/outbox"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/internal/webhooks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Helper to set path variables in the request context
@@ -23,6 +29,7 @@ func setPathVars(r *http.Request, vars map[string]string) *http.Request {
 
 type IntegrationTestSuite struct {
 	suite.Suite
+	config    storage.PostgresConfig
 	db        *storage.PostgreSQLStorage
 	service   *BankService
 	testSwift string
@@ -30,15 +37,17 @@ type IntegrationTestSuite struct {
 
 func (s *IntegrationTestSuite) SetupSuite() {
 	config := storage.PostgresConfig{
-		DB_Port:  storage.GetEnv("DB_PORT", "5432"),
+		Port:     storage.GetEnv("DB_PORT", "5432"),
 		User:     storage.GetEnv("DB_USER", "test_user"),
 		Password: storage.GetEnv("DB_PASSWORD", "Test@1234"),
 		Host:     storage.GetEnv("DB_HOST", "localhost"),
 		Database: storage.GetEnv("DB_NAME", "testdatabase"),
 	}
 
+	s.config = config
+
 	var err error
-	s.db, err = storage.NewPostgreSQLStorage(config)
+	s.db, err = storage.NewPostgreSQLStorage(context.Background(), config)
 	if err != nil {
 		s.T().Fatalf("Failed to connect to test DB: %v", err)
 	}
@@ -76,7 +85,7 @@ func (s *IntegrationTestSuite) BeforeTest(_, _ string) {
 }
 
 func (s *IntegrationTestSuite) AfterTest(_, _ string) {
-	_, _ = s.db.Db.Exec("DROP TABLE IF EXISTS BanksData CASCADE")
+	_, _ = s.db.Db.Exec("DROP TABLE IF EXISTS BanksData, BanksDataHistory, BanksAudit, IdempotencyKeys, Outbox, OutboxDeadLetter, WebhookDeliveries, WebhookSubscriptions CASCADE")
 }
 
 func TestIntegrationSuite(t *testing.T) {
@@ -124,10 +133,35 @@ func (s *IntegrationTestSuite) TestFullFlow() {
 	getRec := httptest.NewRecorder()
 	s.service.handleGetSwiftCodeDetails(getRec, getReq)
 	assert.Equal(s.T(), http.StatusOK, getRec.Result().StatusCode)
+	etag := getRec.Result().Header.Get("ETag")
+	assert.Equal(s.T(), `"1"`, etag)
+
+	// updating test
+	updateReq := httptest.NewRequest(http.MethodPut, "/swift-codes/TESTPL34XXX", strings.NewReader(`{
+		"address": "Updated Address",
+		"bankName": "New Bank",
+		"countryISO2": "PL",
+		"countryName": "POLAND",
+		"isHeadquarter": true
+	}`))
+	updateReq = setPathVars(updateReq, map[string]string{"swiftCode": "TESTPL34XXX"})
+	updateReq.Header.Set("If-Match", etag)
+	updateRec := httptest.NewRecorder()
+	s.service.handleUpdateSwiftCodeDetails(updateRec, updateReq)
+	assert.Equal(s.T(), http.StatusOK, updateRec.Result().StatusCode)
+
+	// the old ETag is stale now
+	staleReq := httptest.NewRequest(http.MethodDelete, "/swift-codes/TESTPL34XXX", nil)
+	staleReq = setPathVars(staleReq, map[string]string{"swiftCode": "TESTPL34XXX"})
+	staleReq.Header.Set("If-Match", etag)
+	staleRec := httptest.NewRecorder()
+	s.service.handleDeleteSwiftCode(staleRec, staleReq)
+	assert.Equal(s.T(), http.StatusPreconditionFailed, staleRec.Result().StatusCode)
 
 	// deleting test
 	deleteReq := httptest.NewRequest(http.MethodDelete, "/swift-codes/TESTPL34XXX", nil)
 	deleteReq = setPathVars(deleteReq, map[string]string{"swiftCode": "TESTPL34XXX"})
+	deleteReq.Header.Set("If-Match", updateRec.Result().Header.Get("ETag"))
 	deleteRec := httptest.NewRecorder()
 	s.service.handleDeleteSwiftCode(deleteRec, deleteReq)
 	assert.Equal(s.T(), http.StatusOK, deleteRec.Result().StatusCode)
@@ -171,3 +205,133 @@ func (s *IntegrationTestSuite) TestCountryCodeWithNoData() {
 
 	assert.Equal(s.T(), http.StatusNotFound, res.StatusCode)
 }
+
+func (s *IntegrationTestSuite) TestPointInTimeQuery() {
+	for _, body := range []string{`{
+		"address": "Historic Address",
+		"bankName": "Historic Bank",
+		"countryISO2": "PL",
+		"countryName": "POLAND",
+		"isHeadquarter": true,
+		"swiftCode": "TESTPL55XXX"
+	}`, `{
+		"address": "Historic Branch Address",
+		"bankName": "Historic Bank",
+		"countryISO2": "PL",
+		"countryName": "POLAND",
+		"isHeadquarter": false,
+		"swiftCode": "TESTPL55ABC"
+	}`} {
+		addReq := httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(body))
+		addRec := httptest.NewRecorder()
+		s.service.handleAddSwiftCodeDetails(addRec, addReq)
+		assert.Equal(s.T(), http.StatusOK, addRec.Result().StatusCode)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	beforeDelete := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(10 * time.Millisecond)
+
+	deleteReq := httptest.NewRequest(http.MethodDelete, "/swift-codes/TESTPL55ABC", nil)
+	deleteReq = setPathVars(deleteReq, map[string]string{"swiftCode": "TESTPL55ABC"})
+	deleteReq.Header.Set("If-Match", "*")
+	deleteRec := httptest.NewRecorder()
+	s.service.handleDeleteSwiftCode(deleteRec, deleteReq)
+	assert.Equal(s.T(), http.StatusOK, deleteRec.Result().StatusCode)
+
+	getHeadquarter := func(query string) storage.Bank {
+		getReq := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL55XXX"+query, nil)
+		getReq = setPathVars(getReq, map[string]string{"swiftCode": "TESTPL55XXX"})
+		getRec := httptest.NewRecorder()
+		s.service.handleGetSwiftCodeDetails(getRec, getReq)
+		assert.Equal(s.T(), http.StatusOK, getRec.Result().StatusCode)
+
+		var bank storage.Bank
+		assert.NoError(s.T(), json.NewDecoder(getRec.Body).Decode(&bank))
+		return bank
+	}
+
+	assert.Len(s.T(), getHeadquarter("?asOf="+beforeDelete).Branches, 1)
+	assert.Empty(s.T(), getHeadquarter("").Branches)
+}
+
+func (s *IntegrationTestSuite) TestCacheInvalidationAcrossReplicas() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// replica A caches lookups and listens for changes, replica B writes directly
+	cache := storage.NewCachedStorage(storage.NewRelationalDB(s.db.Db),
+		storage.CacheConfig{Size: 100, TTL: time.Hour, NegativeTTL: time.Hour})
+	go storage.ListenForChanges(ctx, s.config.ConnString(), storage.InvalidateOnChange(cache))
+
+	_, err := cache.GetSwiftCodeDetails(s.testSwift)
+	assert.NoError(s.T(), err)
+
+	// give the listener time to subscribe before writing
+	time.Sleep(500 * time.Millisecond)
+
+	err = storage.NewRelationalDB(s.db.Db).DeleteSwiftCodeEntry(s.testSwift, 1)
+	assert.NoError(s.T(), err)
+
+	assert.Eventually(s.T(), func() bool {
+		_, err := cache.GetSwiftCodeDetails(s.testSwift)
+		return errors.Is(err, storage.ErrSwiftCodeNotFound)
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func (s *IntegrationTestSuite) TestWebhookDelivery() {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{r.Header, body}
+	}))
+	defer receiver.Close()
+
+	store := storage.NewRelationalDB(s.db.Db)
+	router := http.NewServeMux()
+	NewWebhookService(store).RegisterRoutes(router)
+
+	// only the German subscription matches the added bank
+	for _, body := range []string{
+		`{"url":"` + receiver.URL + `","countryISO2":"PL","secret":"pl-secret"}`,
+		`{"url":"` + receiver.URL + `","bicPrefix":"TESTDE","secret":"de-secret"}`,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+		assert.Equal(s.T(), http.StatusCreated, rec.Code)
+	}
+
+	bank := `{"address":"Berlin","bankName":"Test Bank","countryISO2":"DE","countryName":"GERMANY","isHeadquarter":true,"swiftCode":"TESTDE11XXX"}`
+	rec := httptest.NewRecorder()
+	s.service.handleAddSwiftCodeDetails(rec, httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(bank)))
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	_, err := outbox.NewRelay(store, webhooks.NewDispatcher(store), outbox.DefaultConfig()).ProcessBatch(context.Background())
+	assert.NoError(s.T(), err)
+	_, err = webhooks.NewDeliverer(store, nil, webhooks.DefaultConfig()).ProcessBatch(context.Background())
+	assert.NoError(s.T(), err)
+
+	select {
+	case d := <-received:
+		assert.True(s.T(), webhooks.Verify("de-secret", d.header.Get(webhooks.TimestampHeader), d.header.Get(webhooks.SignatureHeader), d.body))
+
+		var event storage.OutboxEvent
+		assert.NoError(s.T(), json.Unmarshal(d.body, &event))
+		assert.Equal(s.T(), storage.ChangeAdd, event.Action)
+		assert.Equal(s.T(), "TESTDE11XXX", event.SwiftCode)
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("webhook was not delivered")
+	}
+	assert.Len(s.T(), received, 0)
+
+	deliveries, err := store.ListWebhookDeliveries(2, 10)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), deliveries, 1) {
+		assert.Equal(s.T(), storage.DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(s.T(), http.StatusOK, *deliveries[0].ResponseStatus)
+	}
+}
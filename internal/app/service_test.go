@@ -5,13 +5,17 @@ package app
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Mock Storage implementing the storage.Storage interface
@@ -19,7 +23,11 @@ type mockStorage struct {
 	GetSwiftCodeDetailsFunc     func(swiftCode string) (*storage.Bank, error)
 	GetSwiftCodesForCountryFunc func(iso2Code string) (*storage.CountryBanks, error)
 	AddSwiftCodeEntryFunc       func(b storage.Bank) error
-	DeleteSwiftCodeEntryFunc    func(swiftCode string) error
+	UpdateSwiftCodeEntryFunc    func(b storage.Bank, version int64) error
+	DeleteSwiftCodeEntryFunc    func(swiftCode string, version int64) error
+
+	GetSwiftCodeDetailsAsOfFunc     func(swiftCode string, asOf time.Time) (*storage.Bank, error)
+	GetSwiftCodesForCountryAsOfFunc func(iso2Code string, asOf time.Time) (*storage.CountryBanks, error)
 }
 
 func (m *mockStorage) GetSwiftCodeDetails(swiftCode string) (*storage.Bank, error) {
@@ -30,12 +38,24 @@ func (m *mockStorage) GetSwiftCodesForCountry(iso2Code string) (*storage.Country
 	return m.GetSwiftCodesForCountryFunc(iso2Code)
 }
 
+func (m *mockStorage) GetSwiftCodeDetailsAsOf(swiftCode string, asOf time.Time) (*storage.Bank, error) {
+	return m.GetSwiftCodeDetailsAsOfFunc(swiftCode, asOf)
+}
+
+func (m *mockStorage) GetSwiftCodesForCountryAsOf(iso2Code string, asOf time.Time) (*storage.CountryBanks, error) {
+	return m.GetSwiftCodesForCountryAsOfFunc(iso2Code, asOf)
+}
+
 func (m *mockStorage) AddSwiftCodeEntry(b storage.Bank) error {
 	return m.AddSwiftCodeEntryFunc(b)
 }
 
-func (m *mockStorage) DeleteSwiftCodeEntry(swiftCode string) error {
-	return m.DeleteSwiftCodeEntryFunc(swiftCode)
+func (m *mockStorage) UpdateSwiftCodeEntry(b storage.Bank, version int64) error {
+	return m.UpdateSwiftCodeEntryFunc(b, version)
+}
+
+func (m *mockStorage) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
+	return m.DeleteSwiftCodeEntryFunc(swiftCode, version)
 }
 
 // Helper to set path variables in the request context
@@ -61,6 +81,13 @@ func TestHandleGetSwiftCodeDetails(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "swiftCode not found in path",
 		},
+		{
+			name:           "swift code too short",
+			swiftCode:      "ABC",
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "swiftCode is invalid",
+		},
 		{
 			name:      "swift code not found",
 			swiftCode: "INVALID_CODE",
@@ -280,7 +307,7 @@ func TestHandleAddSwiftCodeDetails(t *testing.T) {
 					return storage.ErrSwiftCodeExists
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusConflict,
 			expectedMsg:    storage.ErrSwiftCodeExists.Error(),
 		},
 		{
@@ -327,10 +354,164 @@ func TestHandleAddSwiftCodeDetails(t *testing.T) {
 	}
 }
 
+func TestHandleAddSwiftCodeDetails_CountryNames(t *testing.T) {
+	body := `{
+		"address": "1600 Pennsylvania Avenue NW",
+		"bankName": "Test Bank",
+		"countryISO2": "US",
+		"countryName": "United States",
+		"isHeadquarter": true,
+		"swiftCode": "TESTUS33XXX"
+	}`
+
+	t.Run("alias is stored and echoed as the canonical name", func(t *testing.T) {
+		var stored storage.Bank
+		service := NewBankService(&mockStorage{
+			AddSwiftCodeEntryFunc: func(b storage.Bank) error {
+				stored = b
+				return nil
+			},
+		})
+
+		rec := httptest.NewRecorder()
+		service.handleAddSwiftCodeDetails(rec, httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "UNITED STATES OF AMERICA (THE)", *stored.CountryName)
+
+		var resp storage.Response
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "UNITED STATES OF AMERICA (THE)", resp.CountryName)
+	})
+
+	t.Run("alias is rejected in strict mode", func(t *testing.T) {
+		service := NewBankService(&mockStorage{})
+		service.countryNames = bankdata.CountryNamesStrict
+
+		rec := httptest.NewRecorder()
+		service.handleAddSwiftCodeDetails(rec, httptest.NewRequest(http.MethodPost, "/swift-codes", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp storage.Response
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "countryName does not match ISO2 code", resp.Message)
+	})
+}
+
+// batchStorage is a mockStorage which can apply several changes atomically.
+type batchStorage struct {
+	mockStorage
+	ApplyChangesFunc func(changes []storage.Change, source string) error
+}
+
+func (m *batchStorage) ApplyChanges(changes []storage.Change, source string) error {
+	return m.ApplyChangesFunc(changes, source)
+}
+
+func TestHandleAddSwiftCodesBatch(t *testing.T) {
+	bank := func(swiftCode string) string {
+		return `{"address": "123 Test St", "bankName": "Test Bank", "countryISO2": "PL",
+			"countryName": "Poland", "isHeadquarter": true, "swiftCode": "` + swiftCode + `"}`
+	}
+
+	post := func(s storage.Storage, body string) *httptest.ResponseRecorder {
+		router := http.NewServeMux()
+		NewBankService(s).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodPost, "/swift-codes/batch", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("adds all banks in one call", func(t *testing.T) {
+		var applied []storage.Change
+		var source string
+		rec := post(&batchStorage{ApplyChangesFunc: func(changes []storage.Change, s string) error {
+			applied, source = changes, s
+			return nil
+		}}, "["+bank("TESTPL33XXX")+","+bank("OTHRPL33XXX")+"]")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"message":"Successfully added 2 banks"}`, rec.Body.String())
+		assert.Equal(t, "api:batch", source)
+		if assert.Len(t, applied, 2) {
+			assert.Equal(t, storage.ChangeAdd, applied[0].Action)
+			assert.Equal(t, "TESTPL33XXX", applied[0].SwiftCode())
+			assert.Equal(t, "POLAND", *applied[1].After.CountryName)
+		}
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		applyErr       error
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:           "invalid JSON",
+			body:           `{"swiftCode": "TESTPL33XXX"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Error parsing request body",
+		},
+		{
+			name:           "empty batch",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "a batch must contain between 1 and 1000 banks",
+		},
+		{
+			name:           "invalid bank",
+			body:           "[" + bank("TESTPL33XXX") + `,{"bankName": "No Address"}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "bank 1: address is required",
+		},
+		{
+			name:           "repeated swift code",
+			body:           "[" + bank("TESTPL33XXX") + "," + bank("TESTPL33XXX") + "]",
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "bank 1: swiftCode TESTPL33XXX is repeated in the batch",
+		},
+		{
+			name:           "swift code exists",
+			body:           "[" + bank("TESTPL33XXX") + "]",
+			applyErr:       fmt.Errorf("add TESTPL33XXX: %w", storage.ErrSwiftCodeExists),
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "add TESTPL33XXX: " + storage.ErrSwiftCodeExists.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(&batchStorage{ApplyChangesFunc: func(_ []storage.Change, _ string) error {
+				return tt.applyErr
+			}}, tt.body)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			var resp storage.Response
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.expectedMsg, resp.Message)
+		})
+	}
+
+	t.Run("storage without batch support", func(t *testing.T) {
+		rec := post(&mockStorage{}, "["+bank("TESTPL33XXX")+"]")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
 func TestHandleDeleteSwiftCode(t *testing.T) {
+	storedBank := func(swiftCode string) (*storage.Bank, error) {
+		return &storage.Bank{SwiftCode: &swiftCode, Version: 2}, nil
+	}
+
 	tests := []struct {
 		name           string
 		swiftCode      string
+		ifMatch        string
 		mockStorage    *mockStorage
 		expectedStatus int
 		expectedMsg    string
@@ -349,22 +530,62 @@ func TestHandleDeleteSwiftCode(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "swift-code is invalid",
 		},
+		{
+			name:           "swift code too short",
+			swiftCode:      "ABC",
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "swift-code is invalid",
+		},
+		{
+			name:           "missing If-Match",
+			swiftCode:      "TESTPL33XXX",
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedMsg:    "If-Match header with the ETag of the record is required",
+		},
 		{
 			name:      "swift code not found",
 			swiftCode: "TESTPL33XXX",
+			ifMatch:   `"2"`,
 			mockStorage: &mockStorage{
-				DeleteSwiftCodeEntryFunc: func(_ string) error {
-					return storage.ErrSwiftCodeNotFound
+				GetSwiftCodeDetailsFunc: func(_ string) (*storage.Bank, error) {
+					return nil, storage.ErrSwiftCodeNotFound
 				},
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedMsg:    storage.ErrSwiftCodeNotFound.Error(),
 		},
+		{
+			name:      "stale ETag",
+			swiftCode: "TESTPL33XXX",
+			ifMatch:   `"1"`,
+			mockStorage: &mockStorage{
+				GetSwiftCodeDetailsFunc: storedBank,
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    storage.ErrVersionMismatch.Error(),
+		},
+		{
+			name:      "modified between check and delete",
+			swiftCode: "TESTPL33XXX",
+			ifMatch:   `"2"`,
+			mockStorage: &mockStorage{
+				GetSwiftCodeDetailsFunc: storedBank,
+				DeleteSwiftCodeEntryFunc: func(_ string, _ int64) error {
+					return storage.ErrVersionMismatch
+				},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    storage.ErrVersionMismatch.Error(),
+		},
 		{
 			name:      "storage error",
 			swiftCode: "TESTPL33XXX",
+			ifMatch:   `"2"`,
 			mockStorage: &mockStorage{
-				DeleteSwiftCodeEntryFunc: func(_ string) error {
+				GetSwiftCodeDetailsFunc: storedBank,
+				DeleteSwiftCodeEntryFunc: func(_ string, _ int64) error {
 					return errors.New("storage error")
 				},
 			},
@@ -374,8 +595,26 @@ func TestHandleDeleteSwiftCode(t *testing.T) {
 		{
 			name:      "success",
 			swiftCode: "TESTPL33XXX",
+			ifMatch:   `"2"`,
 			mockStorage: &mockStorage{
-				DeleteSwiftCodeEntryFunc: func(_ string) error {
+				GetSwiftCodeDetailsFunc: storedBank,
+				DeleteSwiftCodeEntryFunc: func(_ string, version int64) error {
+					if version != 2 {
+						return storage.ErrVersionMismatch
+					}
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "Bank with swift code: TESTPL33XXX has been deleted",
+		},
+		{
+			name:      "wildcard If-Match",
+			swiftCode: "TESTPL33XXX",
+			ifMatch:   "*",
+			mockStorage: &mockStorage{
+				GetSwiftCodeDetailsFunc: storedBank,
+				DeleteSwiftCodeEntryFunc: func(_ string, _ int64) error {
 					return nil
 				},
 			},
@@ -388,6 +627,9 @@ func TestHandleDeleteSwiftCode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodDelete, "/swift-codes/"+tt.swiftCode, nil)
 			req = setPathVars(req, map[string]string{"swiftCode": tt.swiftCode})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			rec := httptest.NewRecorder()
 
@@ -405,3 +647,524 @@ func TestHandleDeleteSwiftCode(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleUpdateSwiftCodeDetails(t *testing.T) {
+	validBank := `{
+		"address": "Updated St",
+		"bankName": "Test Bank",
+		"countryISO2": "PL",
+		"countryName": "POLAND",
+		"isHeadquarter": false,
+		"swiftCode": "TESTPL33ABC"
+	}`
+	storedBank := func(swiftCode string) (*storage.Bank, error) {
+		return &storage.Bank{SwiftCode: &swiftCode, Version: 5}, nil
+	}
+
+	tests := []struct {
+		name           string
+		swiftCode      string
+		body           string
+		ifMatch        string
+		mockStorage    *mockStorage
+		expectedStatus int
+		expectedMsg    string
+		expectedETag   string
+	}{
+		{
+			name:           "invalid swift code",
+			swiftCode:      "TEST",
+			body:           validBank,
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "swift-code is invalid",
+		},
+		{
+			name:           "swift code differs from path",
+			swiftCode:      "TESTPL33DEF",
+			body:           validBank,
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "swiftCode does not match the one in path",
+		},
+		{
+			name:           "validation error",
+			swiftCode:      "TESTPL33ABC",
+			body:           `{"bankName": "No Address"}`,
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "address is required",
+		},
+		{
+			name:           "missing If-Match",
+			swiftCode:      "TESTPL33ABC",
+			body:           validBank,
+			mockStorage:    &mockStorage{},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedMsg:    "If-Match header with the ETag of the record is required",
+		},
+		{
+			name:      "weak ETag does not match",
+			swiftCode: "TESTPL33ABC",
+			body:      validBank,
+			ifMatch:   `W/"5"`,
+			mockStorage: &mockStorage{
+				GetSwiftCodeDetailsFunc: storedBank,
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    storage.ErrVersionMismatch.Error(),
+			expectedETag:   `"5"`,
+		},
+		{
+			name:      "success",
+			swiftCode: "TESTPL33ABC",
+			body:      validBank,
+			ifMatch:   `"4", "5"`,
+			mockStorage: &mockStorage{
+				GetSwiftCodeDetailsFunc: storedBank,
+				UpdateSwiftCodeEntryFunc: func(b storage.Bank, version int64) error {
+					if version != 5 || *b.Address != "Updated St" {
+						return errors.New("unexpected update")
+					}
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "Successfully updated bank with swift code TESTPL33ABC",
+			expectedETag:   `"6"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/swift-codes/"+tt.swiftCode, strings.NewReader(tt.body))
+			req = setPathVars(req, map[string]string{"swiftCode": tt.swiftCode})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			rec := httptest.NewRecorder()
+
+			service := NewBankService(tt.mockStorage)
+			service.handleUpdateSwiftCodeDetails(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			assert.Equal(t, tt.expectedETag, res.Header.Get("ETag"))
+
+			var resp storage.Response
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.Equal(t, tt.expectedMsg, resp.Message)
+		})
+	}
+}
+
+func TestHandleGetSwiftCodeDetails_ETag(t *testing.T) {
+	branches := []storage.BankBranch{{SwiftCode: "TESTPL33ABC", Version: 1}}
+	mock := &mockStorage{
+		GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+			return &storage.Bank{SwiftCode: &swiftCode, IsHeadquarter: boolPtr(true), Version: 3, Branches: branches}, nil
+		},
+	}
+	service := NewBankService(mock)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL33XXX", nil)
+		req = setPathVars(req, map[string]string{"swiftCode": "TESTPL33XXX"})
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		service.handleGetSwiftCodeDetails(rec, req)
+		return rec
+	}
+
+	first := get("")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^"3-[0-9a-f]+"$`, etag)
+
+	notModified := get(etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotModified, get("W/"+etag).Code, "If-None-Match uses weak comparison")
+
+	// a new branch changes the representation of the headquarter
+	branches = append(branches, storage.BankBranch{SwiftCode: "TESTPL33DEF", Version: 1})
+	changed := get(etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
+
+func TestEntityTag_Representations(t *testing.T) {
+	bank := &storage.Bank{Version: 4}
+	tags := map[string]bool{}
+	for _, contentType := range representations {
+		tags[entityTag(bank, contentType)] = true
+	}
+	assert.Len(t, tags, len(representations), "every representation has its own strong ETag")
+	assert.Equal(t, `"4"`, entityTag(bank, utils.ContentTypeJSON))
+
+	for tag := range tags {
+		assert.True(t, matchesIfMatch(tag, bank), "If-Match accepts the tag of any representation, got %s", tag)
+	}
+	assert.False(t, matchesIfMatch(`"3-xml"`, bank))
+	assert.False(t, matchesIfMatch(`W/"4"`, bank))
+
+	req := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL33XXX", nil)
+	req.Header.Set("Accept", "text/csv")
+	assert.Equal(t, `"4-csv"`, responseEntityTag(req, bank))
+}
+
+func TestHandleGetSwiftCodeDetails_ContentNegotiation(t *testing.T) {
+	mock := &mockStorage{
+		GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+			return &storage.Bank{
+				Address:       strPtr("123 Test St"),
+				BankName:      strPtr("Test Bank"),
+				CountryISO2:   strPtr("PL"),
+				CountryName:   strPtr("POLAND"),
+				IsHeadquarter: boolPtr(true),
+				SwiftCode:     &swiftCode,
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{"application/json", http.StatusOK, "application/json"},
+		{"application/xml", http.StatusOK, "application/xml"},
+		{"text/csv", http.StatusOK, "text/csv; charset=utf-8"},
+		{"application/pdf", http.StatusNotAcceptable, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL33XXX", nil)
+			req = setPathVars(req, map[string]string{"swiftCode": "TESTPL33XXX"})
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+
+			NewBankService(mock).handleGetSwiftCodeDetails(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedContentType, rec.Header().Get("Content-Type"))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "TESTPL33XXX")
+			}
+		})
+	}
+}
+
+func TestHandlers_AsOf(t *testing.T) {
+	asOf := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock := &mockStorage{
+		GetSwiftCodeDetailsAsOfFunc: func(swiftCode string, at time.Time) (*storage.Bank, error) {
+			if !at.Equal(asOf) {
+				return nil, storage.ErrSwiftCodeNotFound
+			}
+			return &storage.Bank{SwiftCode: &swiftCode, ValidFrom: &at}, nil
+		},
+		GetSwiftCodesForCountryAsOfFunc: func(iso2Code string, at time.Time) (*storage.CountryBanks, error) {
+			if !at.Equal(asOf) {
+				return nil, storage.ErrISO2CodeNotFound
+			}
+			return &storage.CountryBanks{CountryISO2: iso2Code}, nil
+		},
+	}
+	service := NewBankService(mock)
+
+	t.Run("swift code as of instant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL33XXX?asOf=2026-03-01T00:00:00Z", nil)
+		req = setPathVars(req, map[string]string{"swiftCode": "TESTPL33XXX"})
+		rec := httptest.NewRecorder()
+
+		service.handleGetSwiftCodeDetails(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var bank storage.Bank
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&bank))
+		assert.Equal(t, "TESTPL33XXX", *bank.SwiftCode)
+		assert.True(t, asOf.Equal(*bank.ValidFrom))
+	})
+
+	t.Run("swift code not existing at instant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/swift-codes/TESTPL33XXX?asOf=2025-01-01T00:00:00Z", nil)
+		req = setPathVars(req, map[string]string{"swiftCode": "TESTPL33XXX"})
+		rec := httptest.NewRecorder()
+
+		service.handleGetSwiftCodeDetails(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("country as of instant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/swift-codes/country/PL?asOf=2026-03-01T01:00:00%2B01:00", nil)
+		req = setPathVars(req, map[string]string{"countryISO2code": "PL"})
+		rec := httptest.NewRecorder()
+
+		service.handleGetCountrySwiftCodes(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/swift-codes/country/PL?asOf=yesterday", nil)
+		req = setPathVars(req, map[string]string{"countryISO2code": "PL"})
+		rec := httptest.NewRecorder()
+
+		service.handleGetCountrySwiftCodes(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var resp storage.Response
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "asOf must be an RFC 3339 timestamp, e.g. 2026-03-01T00:00:00Z", resp.Message)
+	})
+}
+
+func TestCacheStatsRoute(t *testing.T) {
+	t.Run("cached storage", func(t *testing.T) {
+		cache := storage.NewCachedStorage(&mockStorage{
+			GetSwiftCodeDetailsFunc: func(swiftCode string) (*storage.Bank, error) {
+				return nil, storage.ErrSwiftCodeNotFound
+			},
+		}, storage.CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+		cache.GetSwiftCodeDetails("TESTPLPWXXX")
+		cache.GetSwiftCodeDetails("TESTPLPWXXX")
+
+		router := http.NewServeMux()
+		NewBankService(cache).RegisterRoutes(router)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"hits":1,"misses":1,"evictions":0,"entries":1}`, rec.Body.String())
+	})
+
+	t.Run("storage without cache", func(t *testing.T) {
+		router := http.NewServeMux()
+		NewBankService(&mockStorage{}).RegisterRoutes(router)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// statsStorage is a mockStorage which can summarise its records.
+type statsStorage struct {
+	mockStorage
+	StatsFunc func() (*storage.Stats, error)
+}
+
+func (m *statsStorage) Stats() (*storage.Stats, error) {
+	return m.StatsFunc()
+}
+
+func TestStatsRoute(t *testing.T) {
+	lastModified := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	stats := &storage.Stats{
+		SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1, LastModified: &lastModified,
+		Countries: []storage.CountryStats{
+			{CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1},
+		},
+		LargestBranchNetworks: []storage.BranchNetwork{
+			{Institution: "BREXPLPW", BankName: "MBANK S.A.", CountryISO2: "PL", Branches: 2},
+		},
+	}
+
+	get := func(s storage.Storage, accept string) *httptest.ResponseRecorder {
+		router := http.NewServeMux()
+		NewBankService(s).RegisterRoutes(router)
+
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rec := get(&statsStorage{StatsFunc: func() (*storage.Stats, error) { return stats, nil }}, "application/json")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Thu, 01 Oct 2026 12:00:00 GMT", rec.Header().Get("Last-Modified"))
+		assert.JSONEq(t, `{"swiftCodes":3,"headquarters":1,"branches":2,"institutions":1,
+			"lastModified":"2026-10-01T12:00:00Z",
+			"countries":[{"countryISO2":"PL","countryName":"POLAND","swiftCodes":3,"headquarters":1,"branches":2,"institutions":1}],
+			"largestBranchNetworks":[{"institution":"BREXPLPW","bankName":"MBANK S.A.","countryISO2":"PL","branches":2}]}`,
+			rec.Body.String())
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rec := get(&statsStorage{StatsFunc: func() (*storage.Stats, error) { return stats, nil }}, "text/csv")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "countryISO2,countryName,swiftCodes,headquarters,branches,institutions\nPL,POLAND,3,1,2,1\n", rec.Body.String())
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		rec := get(&statsStorage{StatsFunc: func() (*storage.Stats, error) {
+			return nil, &storage.DBError{Class: storage.ErrUnavailable, Err: errors.New("connection refused")}
+		}}, "application/json")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("storage without stats", func(t *testing.T) {
+		rec := get(&mockStorage{}, "application/json")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestHandleGetCountrySwiftCodes_Pagination(t *testing.T) {
+	service := NewBankService(&mockStorage{
+		GetSwiftCodesForCountryFunc: func(iso string) (*storage.CountryBanks, error) {
+			return &storage.CountryBanks{CountryISO2: iso, SwiftCodes: []storage.BankBranch{
+				{SwiftCode: "BREXPLPWXXX"}, {SwiftCode: "ALBPPLPWXXX"}, {SwiftCode: "BREXPLPWWAL"},
+			}}, nil
+		},
+	})
+
+	get := func(query string) (*http.Response, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/swift-codes/country/PL"+query, nil)
+		req = setPathVars(req, map[string]string{"countryISO2code": "PL"})
+		rec := httptest.NewRecorder()
+		service.handleGetCountrySwiftCodes(rec, req)
+
+		var countryBanks storage.CountryBanks
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&countryBanks))
+		}
+		var swiftCodes []string
+		for _, branch := range countryBanks.SwiftCodes {
+			swiftCodes = append(swiftCodes, branch.SwiftCode)
+		}
+		return rec.Result(), swiftCodes
+	}
+
+	res, swiftCodes := get("")
+	assert.Equal(t, []string{"BREXPLPWXXX", "ALBPPLPWXXX", "BREXPLPWWAL"}, swiftCodes)
+	assert.Empty(t, res.Header.Get("Link"))
+
+	res, swiftCodes = get("?limit=2")
+	assert.Equal(t, []string{"ALBPPLPWXXX", "BREXPLPWWAL"}, swiftCodes)
+	assert.Equal(t, `</v1/swift-codes/country/PL?after=BREXPLPWWAL&limit=2>; rel="next"`, res.Header.Get("Link"))
+
+	res, swiftCodes = get("?after=BREXPLPWWAL&limit=2")
+	assert.Equal(t, []string{"BREXPLPWXXX"}, swiftCodes)
+	assert.Empty(t, res.Header.Get("Link"))
+
+	_, swiftCodes = get("?after=BREXPLPWXXX")
+	assert.Empty(t, swiftCodes)
+
+	res, _ = get("?limit=0")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+type pagerStorage struct {
+	mockStorage
+	GetSwiftCodesForCountryPageFunc func(iso2Code, after string, limit int) (*storage.CountryBanks, error)
+}
+
+func (m *pagerStorage) GetSwiftCodesForCountryPage(iso2Code, after string, limit int) (*storage.CountryBanks, error) {
+	return m.GetSwiftCodesForCountryPageFunc(iso2Code, after, limit)
+}
+
+func (m *pagerStorage) GetSwiftCodesForCountryPageAsOf(iso2Code, after string, limit int, asOf time.Time) (*storage.CountryBanks, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestHandleGetCountrySwiftCodes_StoragePages(t *testing.T) {
+	var gotAfter string
+	var gotLimit int
+	service := NewBankService(&pagerStorage{
+		GetSwiftCodesForCountryPageFunc: func(iso string, after string, limit int) (*storage.CountryBanks, error) {
+			gotAfter, gotLimit = after, limit
+			if iso != "PL" {
+				return nil, storage.ErrISO2CodeNotFound
+			}
+			return &storage.CountryBanks{CountryISO2: iso, SwiftCodes: []storage.BankBranch{
+				{SwiftCode: "BREXPLPWWAL"}, {SwiftCode: "BREXPLPWXXX"},
+			}[:min(2, limit)]}, nil
+		},
+	})
+
+	get := func(iso, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/swift-codes/country/"+iso+query, nil)
+		req = setPathVars(req, map[string]string{"countryISO2code": iso})
+		rec := httptest.NewRecorder()
+		service.handleGetCountrySwiftCodes(rec, req)
+		return rec
+	}
+
+	rec := get("PL", "?after=ALBPPLPWXXX&limit=1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ALBPPLPWXXX", gotAfter)
+	assert.Equal(t, 2, gotLimit, "one more code is read to tell if there is a next page")
+	var countryBanks storage.CountryBanks
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&countryBanks))
+	assert.Equal(t, []storage.BankBranch{{SwiftCode: "BREXPLPWWAL"}}, countryBanks.SwiftCodes)
+	assert.Equal(t, `</v1/swift-codes/country/PL?after=BREXPLPWWAL&limit=1>; rel="next"`, rec.Header().Get("Link"))
+
+	rec = get("PL", "?after=ALBPPLPWXXX&limit=2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Link"))
+
+	rec = get("DE", "?limit=2")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestStorageErrorStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{storage.ErrSwiftCodeNotFound, http.StatusNotFound},
+		{storage.ErrSwiftCodeExists, http.StatusConflict},
+		{&storage.DBError{Class: storage.ErrUniqueViolation, Err: errors.New("pq: duplicate key")}, http.StatusConflict},
+		{&storage.DBError{Class: storage.ErrSerializationFailure, Err: errors.New("pq: could not serialize access")}, http.StatusConflict},
+		{storage.ErrVersionMismatch, http.StatusPreconditionFailed},
+		{&storage.DBError{Class: storage.ErrCheckViolation, Err: errors.New("pq: check violation")}, http.StatusUnprocessableEntity},
+		{&storage.DBError{Class: storage.ErrForeignKeyViolation, Err: errors.New("pq: foreign key violation")}, http.StatusUnprocessableEntity},
+		{&storage.DBError{Class: storage.ErrUnavailable, Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+		{errors.New("storage error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, storageErrorStatus(tt.err))
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{storage.ErrSwiftCodeNotFound, "swift_code_not_found"},
+		{fmt.Errorf("add TESTPL33XXX: %w", storage.ErrSwiftCodeExists), "swift_code_exists"},
+		{storage.ErrVersionMismatch, "version_mismatch"},
+		{&storage.DBError{Class: storage.ErrUnavailable, Err: errors.New("connection refused")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			response := errorResponse(tt.err)
+			assert.Equal(t, tt.err.Error(), response.Message)
+			assert.Equal(t, tt.expected, response.Code)
+		})
+	}
+}
@@ -1,85 +1,33 @@
 package app
 
 import (
-	"errors"
-	country "github.com/mikekonan/go-countries"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
 	"github.com/pkacprzak5/bic-data-service/internal/storage"
-	"regexp"
-	"strings"
 )
 
-func isValidISO2(code string) bool {
-	if code != strings.ToUpper(code) {
-		return false
-	}
-	_, flag := country.ByAlpha2CodeStr(code)
-	return flag
-}
-
-func validateBankData(b storage.Bank) error {
-	if b.Address == nil {
-		return errors.New("address is required")
-	}
-
-	if b.BankName == nil || strings.TrimSpace(*b.BankName) == "" {
-		return errors.New("bankName is required")
-	}
-
-	if b.CountryISO2 == nil {
-		return errors.New("countryISO2 is required")
-	}
-
-	if b.CountryName == nil {
-		return errors.New("countryName is required")
-	}
-
-	if b.IsHeadquarter == nil {
-		return errors.New("isHeadquarter is required")
-	}
-
-	if b.SwiftCode == nil {
-		return errors.New("swiftCode is required")
-	}
-
-	if !isValidISO2(*b.CountryISO2) {
-		return errors.New("countryISO2 is invalid")
-	}
-
-	*b.CountryName = strings.ToUpper(*b.CountryName)
-	if *b.CountryName != iso2CodeToCountry(*b.CountryISO2) {
-		return errors.New("countryName does not match ISO2 code")
-	}
-
-	if !isValidSWIFT(*b.SwiftCode, *b.CountryISO2) {
-		return errors.New("swiftCode is invalid")
-	}
-
-	if strings.HasSuffix(*b.SwiftCode, "XXX") && !*b.IsHeadquarter {
-		return errors.New("swiftCode indicates bank's headquarter")
-	}
-
-	if *b.IsHeadquarter && !strings.HasSuffix(*b.SwiftCode, "XXX") {
-		return errors.New("swiftCode indicates bank's branch")
-	}
-
-	return nil
+// ValidateBankData checks a record against the same rules that are applied to POST /v1/swift-codes,
+// accepting the aliases of country names. The countryName of a valid record is replaced by the
+// canonical name of its country.
+func ValidateBankData(b storage.Bank) error {
+	return validateBankData(b, bankdata.CountryNamesAliases)
 }
 
-func iso2CodeToCountry(code string) string {
-	countryByISO2Code, ok := country.ByAlpha2CodeStr(code)
-	if ok {
-		name := countryByISO2Code.NameStr()
-		name = strings.ToUpper(name)
-		return name
+// BankValidator returns the validation of ValidateBankData with the given handling of country names.
+func BankValidator(countryNames bankdata.CountryNames) func(storage.Bank) error {
+	return func(b storage.Bank) error {
+		return validateBankData(b, countryNames)
 	}
-	return ""
 }
 
-func isValidSWIFT(s, iso2Code string) bool {
-	regex := `^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`
-	matched, _ := regexp.MatchString(regex, s)
-	if !matched || s[4:6] != iso2Code { // it should match countryISO2Code of bank location
-		return false
-	}
-	return matched
+// validateBankData checks the record with bankdata.Validate, which shares the fields of b, so that
+// the canonical country name is set on b.
+func validateBankData(b storage.Bank, countryNames bankdata.CountryNames) error {
+	return bankdata.Validate(bankdata.Record{
+		Address:       b.Address,
+		BankName:      b.BankName,
+		CountryISO2:   b.CountryISO2,
+		CountryName:   b.CountryName,
+		IsHeadquarter: b.IsHeadquarter,
+		SwiftCode:     b.SwiftCode,
+	}, countryNames)
 }
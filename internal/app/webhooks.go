@@ -0,0 +1,288 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/pkacprzak5/bic-data-service/pkg/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+var bicPrefixRegex = regexp.MustCompile(`^[A-Z0-9]{1,11}$`)
+
+type WebhookService struct {
+	store storage.WebhookStore
+}
+
+func NewWebhookService(s storage.WebhookStore) *WebhookService {
+	return &WebhookService{store: s}
+}
+
+func (s *WebhookService) RegisterRoutes(router Router) {
+	router.HandleFunc("POST /webhooks", s.handleCreateWebhook)
+	router.HandleFunc("GET /webhooks", s.handleListWebhooks)
+	router.HandleFunc("GET /webhooks/{id}", s.handleGetWebhook)
+	router.HandleFunc("PUT /webhooks/{id}", s.handleUpdateWebhook)
+	router.HandleFunc("DELETE /webhooks/{id}", s.handleDeleteWebhook)
+	router.HandleFunc("GET /webhooks/{id}/deliveries", s.handleListDeliveries)
+	router.HandleFunc("GET /webhooks/{id}/deliveries/{deliveryId}", s.handleGetDelivery)
+	router.HandleFunc("POST /webhooks/{id}/deliveries/{deliveryId}/replay", s.handleReplayDelivery)
+}
+
+func (s *WebhookService) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, err := decodeWebhookBody(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
+		return
+	}
+
+	if subscription.Secret == "" {
+		subscription.Secret, err = generateSecret()
+		if err != nil {
+			utils.WriteResponse(w, r, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+			return
+		}
+	}
+
+	created, err := s.store.CreateWebhook(subscription)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+		return
+	}
+
+	// the secret is returned only once, when the subscription is created
+	w.Header().Set("Location", fmt.Sprintf("/v1/webhooks/%d", created.ID))
+	utils.WriteResponse(w, r, http.StatusCreated, created)
+}
+
+func (s *WebhookService) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := s.store.ListWebhooks()
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	utils.WriteResponse(w, r, http.StatusOK, storage.WebhookList{Webhooks: subscriptions})
+}
+
+func (s *WebhookService) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	subscription.Secret = ""
+	utils.WriteResponse(w, r, http.StatusOK, subscription)
+}
+
+func (s *WebhookService) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := decodeWebhookBody(r)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: err.Error()})
+		return
+	}
+
+	subscription.ID = current.ID
+	subscription.CreatedAt = current.CreatedAt
+	if subscription.Secret == "" {
+		subscription.Secret = current.Secret
+	}
+
+	err = s.store.UpdateWebhook(subscription)
+	if !writeWebhookError(w, r, err) {
+		return
+	}
+
+	subscription.Secret = ""
+	utils.WriteResponse(w, r, http.StatusOK, subscription)
+}
+
+func (s *WebhookService) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.store.DeleteWebhook(id)
+	if !writeWebhookError(w, r, err) {
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK,
+		storage.Response{Message: fmt.Sprintf("Webhook %d has been deleted", id)})
+}
+
+func (s *WebhookService) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeliveriesLimit {
+			utils.WriteResponse(w, r, http.StatusBadRequest,
+				storage.Response{Message: fmt.Sprintf("limit must be a number between 1 and %d", maxDeliveriesLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(subscription.ID, limit)
+	if err != nil {
+		utils.WriteResponse(w, r, http.StatusInternalServerError, storage.Response{Message: err.Error()})
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK, storage.WebhookDeliveryList{Deliveries: deliveries})
+}
+
+func (s *WebhookService) handleGetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryIDs(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.store.GetWebhookDelivery(id, deliveryID)
+	if !writeWebhookError(w, r, err) {
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusOK, delivery)
+}
+
+func (s *WebhookService) handleReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryIDs(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.store.ReplayWebhookDelivery(id, deliveryID)
+	if !writeWebhookError(w, r, err) {
+		return
+	}
+
+	utils.WriteResponse(w, r, http.StatusAccepted, delivery)
+}
+
+// loadWebhook loads the subscription identified by the path. It writes the error response and
+// returns false if it cannot be loaded.
+func (s *WebhookService) loadWebhook(w http.ResponseWriter, r *http.Request) (*storage.WebhookSubscription, bool) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	subscription, err := s.store.GetWebhook(id)
+	if !writeWebhookError(w, r, err) {
+		return nil, false
+	}
+	return subscription, true
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "webhook id is invalid"})
+		return 0, false
+	}
+	return id, true
+}
+
+func deliveryIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	deliveryID, err := strconv.ParseInt(r.PathValue("deliveryId"), 10, 64)
+	if err != nil || deliveryID < 1 {
+		utils.WriteResponse(w, r, http.StatusBadRequest, storage.Response{Message: "delivery id is invalid"})
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
+
+// writeWebhookError writes the error response of a webhook operation and reports whether err was nil.
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err != nil && (errors.Is(err, storage.ErrWebhookNotFound) || errors.Is(err, storage.ErrWebhookDeliveryNotFound)) {
+		utils.WriteResponse(w, r, http.StatusNotFound, errorResponse(err))
+		return false
+	} else if err != nil {
+		writeStorageError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// decodeWebhookBody reads and validates the subscription sent in the request body. Filters are
+// normalised to upper case, empty filters are removed.
+func decodeWebhookBody(r *http.Request) (storage.WebhookSubscription, error) {
+	var subscription storage.WebhookSubscription
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return subscription, errors.New("Error reading request body")
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(body, &subscription); err != nil {
+		return subscription, errors.New("Error parsing request body")
+	}
+
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return subscription, errors.New("url must be an absolute http or https URL")
+	}
+
+	subscription.CountryISO2 = normaliseFilter(subscription.CountryISO2)
+	if subscription.CountryISO2 != nil && !bankdata.ValidISO2(*subscription.CountryISO2) {
+		return subscription, errors.New("countryISO2 is invalid")
+	}
+
+	subscription.BICPrefix = normaliseFilter(subscription.BICPrefix)
+	if subscription.BICPrefix != nil && !bicPrefixRegex.MatchString(*subscription.BICPrefix) {
+		return subscription, errors.New("bicPrefix must consist of 1 to 11 letters or digits")
+	}
+
+	return subscription, nil
+}
+
+func normaliseFilter(value *string) *string {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	normalised := strings.ToUpper(strings.TrimSpace(*value))
+	return &normalised
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,343 @@
+//go:build unit
+
+package app
+
+import (
+	"encoding/json"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryWebhookStore is an in-memory storage.WebhookStore used in tests.
+type memoryWebhookStore struct {
+	subscriptions map[int64]storage.WebhookSubscription
+	deliveries    []storage.WebhookDelivery
+	nextID        int64
+}
+
+func newMemoryWebhookStore() *memoryWebhookStore {
+	return &memoryWebhookStore{subscriptions: map[int64]storage.WebhookSubscription{}}
+}
+
+func (m *memoryWebhookStore) CreateWebhook(s storage.WebhookSubscription) (*storage.WebhookSubscription, error) {
+	m.nextID++
+	s.ID = m.nextID
+	s.CreatedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.subscriptions[s.ID] = s
+	return &s, nil
+}
+
+func (m *memoryWebhookStore) GetWebhook(id int64) (*storage.WebhookSubscription, error) {
+	s, ok := m.subscriptions[id]
+	if !ok {
+		return nil, storage.ErrWebhookNotFound
+	}
+	return &s, nil
+}
+
+func (m *memoryWebhookStore) ListWebhooks() ([]storage.WebhookSubscription, error) {
+	subscriptions := []storage.WebhookSubscription{}
+	for id := int64(1); id <= m.nextID; id++ {
+		if s, ok := m.subscriptions[id]; ok {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (m *memoryWebhookStore) UpdateWebhook(s storage.WebhookSubscription) error {
+	if _, ok := m.subscriptions[s.ID]; !ok {
+		return storage.ErrWebhookNotFound
+	}
+	m.subscriptions[s.ID] = s
+	return nil
+}
+
+func (m *memoryWebhookStore) DeleteWebhook(id int64) error {
+	if _, ok := m.subscriptions[id]; !ok {
+		return storage.ErrWebhookNotFound
+	}
+	delete(m.subscriptions, id)
+	return nil
+}
+
+func (m *memoryWebhookStore) EnqueueWebhookDeliveries(event storage.OutboxEvent) error {
+	payload, _ := json.Marshal(event)
+	for _, s := range m.subscriptions {
+		m.deliveries = append(m.deliveries, storage.WebhookDelivery{
+			ID: int64(len(m.deliveries) + 1), SubscriptionID: s.ID, EventID: event.ID, Event: payload, Status: storage.DeliveryPending,
+		})
+	}
+	return nil
+}
+
+func (m *memoryWebhookStore) ClaimWebhookDeliveries(int, time.Duration) ([]storage.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (m *memoryWebhookStore) RecordWebhookAttempt(int64, storage.WebhookDeliveryStatus, *int, *string, *time.Time) error {
+	return nil
+}
+
+func (m *memoryWebhookStore) ListWebhookDeliveries(subscriptionID int64, limit int) ([]storage.WebhookDelivery, error) {
+	deliveries := []storage.WebhookDelivery{}
+	for i := len(m.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if m.deliveries[i].SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, m.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *memoryWebhookStore) GetWebhookDelivery(subscriptionID, deliveryID int64) (*storage.WebhookDelivery, error) {
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID && d.SubscriptionID == subscriptionID {
+			return &d, nil
+		}
+	}
+	return nil, storage.ErrWebhookDeliveryNotFound
+}
+
+func (m *memoryWebhookStore) ReplayWebhookDelivery(subscriptionID, deliveryID int64) (*storage.WebhookDelivery, error) {
+	original, err := m.GetWebhookDelivery(subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	replay := storage.WebhookDelivery{
+		ID: int64(len(m.deliveries) + 1), SubscriptionID: subscriptionID, EventID: original.EventID,
+		Event: original.Event, Status: storage.DeliveryPending, ReplayOf: &original.ID,
+	}
+	m.deliveries = append(m.deliveries, replay)
+	return &replay, nil
+}
+
+func serveWebhooks(store storage.WebhookStore, method, target, body string) *httptest.ResponseRecorder {
+	router := http.NewServeMux()
+	NewWebhookService(store).RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestHandleCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid json",
+			body:           `{"url":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"message":"Error parsing request body"}`,
+		},
+		{
+			name:           "missing url",
+			body:           `{"countryISO2":"PL"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"message":"url must be an absolute http or https URL"}`,
+		},
+		{
+			name:           "relative url",
+			body:           `{"url":"/hooks"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"message":"url must be an absolute http or https URL"}`,
+		},
+		{
+			name:           "invalid country",
+			body:           `{"url":"https://example.com/hooks","countryISO2":"XX"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"message":"countryISO2 is invalid"}`,
+		},
+		{
+			name:           "invalid bic prefix",
+			body:           `{"url":"https://example.com/hooks","bicPrefix":"BRE-"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"message":"bicPrefix must consist of 1 to 11 letters or digits"}`,
+		},
+		{
+			name:           "normalised filters",
+			body:           `{"url":"https://example.com/hooks","countryISO2":"pl","bicPrefix":" brex ","secret":"s3cret"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{"id":1,"url":"https://example.com/hooks","countryISO2":"PL","bicPrefix":"BREX",` +
+				`"secret":"s3cret","createdAt":"2026-03-01T12:00:00Z"}`,
+		},
+		{
+			name:           "empty filters",
+			body:           `{"url":"https://example.com/hooks","countryISO2":"","secret":"s3cret"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1,"url":"https://example.com/hooks","secret":"s3cret","createdAt":"2026-03-01T12:00:00Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveWebhooks(newMemoryWebhookStore(), http.MethodPost, "/webhooks", tt.body)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+
+	t.Run("generated secret", func(t *testing.T) {
+		store := newMemoryWebhookStore()
+		rec := serveWebhooks(store, http.MethodPost, "/webhooks", `{"url":"https://example.com/hooks"}`)
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/v1/webhooks/1", rec.Header().Get("Location"))
+
+		var created storage.WebhookSubscription
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Len(t, created.Secret, 64)
+		assert.Equal(t, created.Secret, store.subscriptions[1].Secret)
+	})
+}
+
+func TestWebhookSubscriptionLifecycle(t *testing.T) {
+	store := newMemoryWebhookStore()
+	serveWebhooks(store, http.MethodPost, "/webhooks", `{"url":"https://example.com/hooks","secret":"s3cret"}`)
+
+	// the secret is never returned after creation
+	rec := serveWebhooks(store, http.MethodGet, "/webhooks/1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":1,"url":"https://example.com/hooks","createdAt":"2026-03-01T12:00:00Z"}`, rec.Body.String())
+
+	rec = serveWebhooks(store, http.MethodGet, "/webhooks", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"webhooks":[{"id":1,"url":"https://example.com/hooks","createdAt":"2026-03-01T12:00:00Z"}]}`, rec.Body.String())
+
+	// an update without a secret keeps the current one
+	rec = serveWebhooks(store, http.MethodPut, "/webhooks/1", `{"url":"https://example.com/v2","countryISO2":"DE"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":1,"url":"https://example.com/v2","countryISO2":"DE","createdAt":"2026-03-01T12:00:00Z"}`, rec.Body.String())
+	assert.Equal(t, "s3cret", store.subscriptions[1].Secret)
+
+	rec = serveWebhooks(store, http.MethodPut, "/webhooks/1", `{"url":"ftp://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveWebhooks(store, http.MethodDelete, "/webhooks/1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"message":"Webhook 1 has been deleted"}`, rec.Body.String())
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		rec = serveWebhooks(store, method, "/webhooks/1", `{"url":"https://example.com/hooks"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code, method)
+		assert.JSONEq(t, `{"message":"Webhook with given id not found","code":"webhook_not_found"}`, rec.Body.String(), method)
+	}
+
+	rec = serveWebhooks(store, http.MethodGet, "/webhooks/abc", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"webhook id is invalid"}`, rec.Body.String())
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	store := newMemoryWebhookStore()
+	serveWebhooks(store, http.MethodPost, "/webhooks", `{"url":"https://example.com/hooks"}`)
+	require.NoError(t, store.EnqueueWebhookDeliveries(storage.OutboxEvent{ID: 7, Action: storage.ChangeAdd, SwiftCode: "TESTPLPWXXX"}))
+	require.NoError(t, store.EnqueueWebhookDeliveries(storage.OutboxEvent{ID: 8, Action: storage.ChangeRemove, SwiftCode: "TESTPLPWXXX"}))
+
+	t.Run("list newest first", func(t *testing.T) {
+		rec := serveWebhooks(store, http.MethodGet, "/webhooks/1/deliveries?limit=1", "")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list storage.WebhookDeliveryList
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		require.Len(t, list.Deliveries, 1)
+		assert.Equal(t, int64(8), list.Deliveries[0].EventID)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rec := serveWebhooks(store, http.MethodGet, "/webhooks/1/deliveries?limit=0", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"message":"limit must be a number between 1 and 500"}`, rec.Body.String())
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		rec := serveWebhooks(store, http.MethodGet, "/webhooks/1/deliveries/1", "")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var delivery storage.WebhookDelivery
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &delivery))
+		assert.Equal(t, storage.DeliveryPending, delivery.Status)
+		assert.JSONEq(t, `{"id":7,"action":"add","swiftCode":"TESTPLPWXXX","countryISO2":"","data":null,"occurredAt":"0001-01-01T00:00:00Z"}`,
+			string(delivery.Event))
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		rec := serveWebhooks(store, http.MethodPost, "/webhooks/1/deliveries/1/replay", "")
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		var delivery storage.WebhookDelivery
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &delivery))
+		assert.Equal(t, int64(7), delivery.EventID)
+		assert.Equal(t, int64(1), *delivery.ReplayOf)
+		assert.Len(t, store.deliveries, 3)
+	})
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			target := "/webhooks/1/deliveries/99"
+			if method == http.MethodPost {
+				target += "/replay"
+			}
+			rec := serveWebhooks(store, method, target, "")
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, method)
+			assert.JSONEq(t, `{"message":"Webhook delivery with given id not found","code":"webhook_delivery_not_found"}`, rec.Body.String(), method)
+		}
+	})
+
+	t.Run("unknown subscription", func(t *testing.T) {
+		rec := serveWebhooks(store, http.MethodGet, "/webhooks/2/deliveries", "")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWebhooks_CSV(t *testing.T) {
+	store := newMemoryWebhookStore()
+	serveCSV := func(method, target, body string) *httptest.ResponseRecorder {
+		router := http.NewServeMux()
+		NewWebhookService(store).RegisterRoutes(router)
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Accept", "text/csv")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the secret is only returned when the subscription is created, so it must be readable as CSV too
+	rec := serveCSV(http.MethodPost, "/webhooks", `{"url":"https://example.com/hooks","countryISO2":"PL","secret":"s3cret"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "id,url,countryISO2,bicPrefix,secret,createdAt\n1,https://example.com/hooks,PL,,s3cret,2026-03-01T12:00:00Z\n",
+		rec.Body.String())
+
+	rec = serveCSV(http.MethodGet, "/webhooks", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,url,countryISO2,bicPrefix,secret,createdAt\n1,https://example.com/hooks,PL,,,2026-03-01T12:00:00Z\n",
+		rec.Body.String())
+
+	require.NoError(t, store.EnqueueWebhookDeliveries(storage.OutboxEvent{ID: 7, Action: storage.ChangeAdd, SwiftCode: "TESTPLPWXXX", CountryISO2: "PL"}))
+	for _, tt := range []struct {
+		method, target string
+		status         int
+	}{
+		{http.MethodGet, "/webhooks/1/deliveries", http.StatusOK},
+		{http.MethodGet, "/webhooks/1/deliveries/1", http.StatusOK},
+		{http.MethodPost, "/webhooks/1/deliveries/1/replay", http.StatusAccepted},
+	} {
+		rec := serveCSV(tt.method, tt.target, "")
+		assert.Equal(t, tt.status, rec.Code, tt.target)
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "id,subscriptionId,eventId,status,attempts,"), tt.target)
+	}
+}
@@ -0,0 +1,148 @@
+package bankdata
+
+import (
+	country "github.com/mikekonan/go-countries"
+	"regexp"
+	"strings"
+)
+
+// CountryNames tells how the countryName of a record is checked against its countryISO2. Either way,
+// the record is stored with the canonical name of the country, its ISO 3166 name in upper case.
+type CountryNames string
+
+const (
+	// CountryNamesStrict accepts only the canonical name, such as "UNITED STATES OF AMERICA (THE)".
+	CountryNamesStrict CountryNames = "strict"
+	// CountryNamesAliases also accepts the ISO 3166 name without its qualifiers, such as "UNITED
+	// STATES OF AMERICA", its official form, such as "REPUBLIC OF KOREA", and the common names of
+	// countryAliases, such as "USA".
+	CountryNamesAliases CountryNames = "aliases"
+)
+
+// countryAliases are the common names of countries which do not follow from their ISO 3166 names.
+var countryAliases = map[string][]string{
+	"AE": {"UAE"},
+	"BN": {"BRUNEI"},
+	"CD": {"DR CONGO", "DRC", "CONGO-KINSHASA"},
+	"CG": {"REPUBLIC OF THE CONGO", "REPUBLIC OF CONGO", "CONGO-BRAZZAVILLE"},
+	"CI": {"IVORY COAST"},
+	"CV": {"CAPE VERDE"},
+	"CZ": {"CZECH REPUBLIC"},
+	"GB": {"UNITED KINGDOM", "UK", "GREAT BRITAIN"},
+	"KP": {"NORTH KOREA"},
+	"KR": {"SOUTH KOREA"},
+	"LA": {"LAOS"},
+	"MK": {"MACEDONIA"},
+	"MM": {"BURMA"},
+	"MO": {"MACAU"},
+	"RU": {"RUSSIA"},
+	"SY": {"SYRIA"},
+	"SZ": {"SWAZILAND"},
+	"TL": {"EAST TIMOR"},
+	"TR": {"TURKIYE"},
+	"US": {"UNITED STATES", "USA"},
+	"VA": {"VATICAN", "VATICAN CITY"},
+	"VG": {"BRITISH VIRGIN ISLANDS"},
+	"VI": {"US VIRGIN ISLANDS"},
+	"VN": {"VIETNAM"},
+}
+
+var (
+	// qualifierPattern matches the qualifiers of ISO 3166 names: "(the Republic of)", "[Malvinas]" and
+	// ", State of".
+	qualifierPattern = regexp.MustCompile(`\s*(\([^)]*\)|\[[^\]]*\]|,.*$)`)
+	// officialPattern matches the names whose qualifier is the beginning of the official name, such as
+	// "Korea (the Republic of)" or "Palestine, State of".
+	officialPattern = regexp.MustCompile(`^([^(,]+?)(?: \((?:THE )?(.+ OF(?: THE)?)\)|, (?:THE )?(.+ OF))$`)
+)
+
+// countryNameRegistry maps the normalised names of countries to their ISO2 codes, and their ISO2 codes
+// to their canonical names.
+type countryNameRegistry struct {
+	canonical map[string]string
+	aliases   map[string]string
+}
+
+var knownCountryNames = newCountryNameRegistry()
+
+// newCountryNameRegistry registers the names of all countries known to go-countries. A name derived
+// from the ISO 3166 names of two countries, such as "CONGO" or "VIRGIN ISLANDS", is ambiguous and is
+// only accepted if it is listed in countryAliases or is the name of one of them without "(THE)".
+func newCountryNameRegistry() *countryNameRegistry {
+	r := &countryNameRegistry{canonical: make(map[string]string), aliases: make(map[string]string)}
+
+	var codes []string
+	for a := 'A'; a <= 'Z'; a++ {
+		for b := 'A'; b <= 'Z'; b++ {
+			code := string([]rune{a, b})
+			if iso, ok := country.ByAlpha2CodeStr(code); ok {
+				codes = append(codes, code)
+				r.canonical[code] = strings.ToUpper(iso.NameStr())
+			}
+		}
+	}
+
+	// names registered by an earlier pass take precedence over the ones of later passes
+	passes := []func(code string) []string{
+		func(code string) []string {
+			name := r.canonical[code]
+			return []string{name, strings.ReplaceAll(name, " (THE)", "")}
+		},
+		func(code string) []string {
+			return countryAliases[code]
+		},
+		func(code string) []string {
+			name := r.canonical[code]
+			names := []string{qualifierPattern.ReplaceAllString(name, "")}
+			if m := officialPattern.FindStringSubmatch(name); m != nil {
+				names = append(names, m[2]+m[3]+" "+m[1])
+			}
+			return names
+		},
+	}
+	for _, pass := range passes {
+		found := make(map[string]string)
+		for _, code := range codes {
+			for _, name := range pass(code) {
+				name = normaliseCountryName(name)
+				if _, ok := r.aliases[name]; ok {
+					continue
+				}
+				if other, ok := found[name]; ok && other != code {
+					found[name] = ""
+					continue
+				}
+				found[name] = code
+			}
+		}
+		for name, code := range found {
+			r.aliases[name] = code
+		}
+	}
+	return r
+}
+
+// resolve returns the canonical name of the country with the given ISO2 code, if name is one of its
+// names. Ambiguous names are registered with an empty code, which matches no country.
+func (r *countryNameRegistry) resolve(name, iso2Code string, mode CountryNames) (string, bool) {
+	canonical, ok := r.canonical[iso2Code]
+	if !ok {
+		return "", false
+	}
+	if mode == CountryNamesStrict {
+		return canonical, strings.ToUpper(name) == canonical
+	}
+	return canonical, r.aliases[normaliseCountryName(name)] == iso2Code
+}
+
+var countryNameReplacer = strings.NewReplacer(
+	"&", " AND ", ".", "", "’", "'",
+	"Å", "A", "Ç", "C", "É", "E", "Ô", "O", "Ü", "U",
+)
+
+// normaliseCountryName upper-cases the name, drops accents and dots, spells out "&" and collapses
+// whitespace, so that "Bosnia & Herzegovina" and "Côte d'Ivoire" match their ISO 3166 names.
+func normaliseCountryName(name string) string {
+	name = countryNameReplacer.Replace(strings.ToUpper(name))
+	return strings.Join(strings.Fields(name), " ")
+}
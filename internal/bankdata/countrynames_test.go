@@ -0,0 +1,114 @@
+//go:build unit
+
+package bankdata
+
+import (
+	country "github.com/mikekonan/go-countries"
+	"testing"
+)
+
+func TestValidate_CountryNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		iso2Code     string
+		countryName  string
+		countryNames CountryNames
+		want         string // empty if the name is rejected
+	}{
+		{name: "canonical", iso2Code: "US", countryName: "UNITED STATES OF AMERICA (THE)",
+			countryNames: CountryNamesStrict, want: "UNITED STATES OF AMERICA (THE)"},
+		{name: "canonical in lower case", iso2Code: "PL", countryName: "poland",
+			countryNames: CountryNamesStrict, want: "POLAND"},
+		{name: "strict without (THE)", iso2Code: "US", countryName: "UNITED STATES OF AMERICA",
+			countryNames: CountryNamesStrict},
+		{name: "strict common name", iso2Code: "GB", countryName: "UNITED KINGDOM", countryNames: CountryNamesStrict},
+		{name: "without (THE)", iso2Code: "US", countryName: "United States of America",
+			countryNames: CountryNamesAliases, want: "UNITED STATES OF AMERICA (THE)"},
+		{name: "common name", iso2Code: "US", countryName: "USA",
+			countryNames: CountryNamesAliases, want: "UNITED STATES OF AMERICA (THE)"},
+		{name: "common name with dots", iso2Code: "US", countryName: "U.S.A.",
+			countryNames: CountryNamesAliases, want: "UNITED STATES OF AMERICA (THE)"},
+		{name: "without qualifier", iso2Code: "BO", countryName: "BOLIVIA",
+			countryNames: CountryNamesAliases, want: "BOLIVIA (PLURINATIONAL STATE OF)"},
+		{name: "official name", iso2Code: "KR", countryName: "Republic of Korea",
+			countryNames: CountryNamesAliases, want: "KOREA (THE REPUBLIC OF)"},
+		{name: "official name after comma", iso2Code: "TZ", countryName: "UNITED REPUBLIC OF TANZANIA",
+			countryNames: CountryNamesAliases, want: "TANZANIA, THE UNITED REPUBLIC OF"},
+		{name: "official name of the", iso2Code: "CD", countryName: "DEMOCRATIC REPUBLIC OF THE CONGO",
+			countryNames: CountryNamesAliases, want: "CONGO (THE DEMOCRATIC REPUBLIC OF THE)"},
+		{name: "without accents", iso2Code: "CI", countryName: "COTE D'IVOIRE",
+			countryNames: CountryNamesAliases, want: "CÔTE D'IVOIRE"},
+		{name: "ampersand and whitespace", iso2Code: "BA", countryName: " Bosnia  & Herzegovina ",
+			countryNames: CountryNamesAliases, want: "BOSNIA AND HERZEGOVINA"},
+		{name: "name of the other Congo", iso2Code: "CD", countryName: "CONGO", countryNames: CountryNamesAliases},
+		{name: "ambiguous", iso2Code: "VG", countryName: "VIRGIN ISLANDS", countryNames: CountryNamesAliases},
+		{name: "other country", iso2Code: "KP", countryName: "SOUTH KOREA", countryNames: CountryNamesAliases},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := recordInCountry(tt.iso2Code, tt.countryName)
+			err := Validate(record, tt.countryNames)
+			if tt.want == "" {
+				if err == nil || err.Error() != "countryName does not match ISO2 code" {
+					t.Errorf("Validate() returned %v, should reject %q", err, tt.countryName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *record.CountryName != tt.want {
+				t.Errorf("countryName is %q, should be %q", *record.CountryName, tt.want)
+			}
+		})
+	}
+}
+
+// TestCountryNameRegistry checks that every country is accepted by its canonical name and the names
+// of countryAliases, so that an alias which collides with another name does not go unnoticed.
+func TestCountryNameRegistry(t *testing.T) {
+	for _, code := range allISO2Codes() {
+		names := append([]string{CountryName(code)}, countryAliases[code]...)
+		for _, name := range names {
+			for _, countryNames := range []CountryNames{CountryNamesStrict, CountryNamesAliases} {
+				if countryNames == CountryNamesStrict && name != CountryName(code) {
+					continue
+				}
+				canonical, ok := knownCountryNames.resolve(name, code, countryNames)
+				if !ok || canonical != CountryName(code) {
+					t.Errorf("%s: %q is not accepted in %s mode", code, name, countryNames)
+				}
+			}
+		}
+	}
+
+	for code := range countryAliases {
+		if CountryName(code) == "" {
+			t.Errorf("countryAliases has unknown country %s", code)
+		}
+	}
+}
+
+func recordInCountry(iso2Code, countryName string) Record {
+	return Record{
+		Address:       strPtr("1 Test Street"),
+		BankName:      strPtr("Test Bank"),
+		CountryISO2:   strPtr(iso2Code),
+		CountryName:   strPtr(countryName),
+		IsHeadquarter: boolPtr(true),
+		SwiftCode:     strPtr("TEST" + iso2Code + "33XXX"),
+	}
+}
+
+func allISO2Codes() []string {
+	var codes []string
+	for first := 'A'; first <= 'Z'; first++ {
+		for second := 'A'; second <= 'Z'; second++ {
+			if _, ok := country.ByAlpha2CodeStr(string([]rune{first, second})); ok {
+				codes = append(codes, string([]rune{first, second}))
+			}
+		}
+	}
+	return codes
+}
@@ -0,0 +1,109 @@
+package bankdata
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// columnAliases maps the column names used by the published BIC directory files
+// (and by our own CSV responses) to Record fields.
+var columnAliases = map[string]string{
+	"swift code":        "swiftCode",
+	"swiftcode":         "swiftCode",
+	"bic":               "swiftCode",
+	"name":              "bankName",
+	"bankname":          "bankName",
+	"address":           "address",
+	"country iso2 code": "countryISO2",
+	"countryiso2":       "countryISO2",
+	"country name":      "countryName",
+	"countryname":       "countryName",
+	"isheadquarter":     "isHeadquarter",
+}
+
+var requiredColumns = []string{"swiftCode", "bankName", "address", "countryISO2", "countryName"}
+
+// ReadSnapshot parses a full directory snapshot in CSV format. The header row is matched
+// case-insensitively and unknown columns (e.g. TIME ZONE, CODE TYPE) are ignored. When the
+// file has no isHeadquarter column the flag is derived from the "XXX" branch code suffix.
+func ReadSnapshot(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("snapshot is empty")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := columnAliases[name]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range requiredColumns {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("snapshot is missing required column %q", field)
+		}
+	}
+
+	var records []Record
+	seen := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		swiftCode := strings.ToUpper(value("swiftCode"))
+		if swiftCode == "" {
+			return nil, fmt.Errorf("line %d: swift code is empty", line)
+		}
+		if previous, ok := seen[swiftCode]; ok {
+			return nil, fmt.Errorf("line %d: swift code %s duplicates line %d", line, swiftCode, previous)
+		}
+		seen[swiftCode] = line
+
+		isHeadquarter := strings.HasSuffix(swiftCode, "XXX")
+		if raw := value("isHeadquarter"); raw != "" {
+			isHeadquarter, err = strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid isHeadquarter value %q", line, raw)
+			}
+		}
+
+		address := value("address")
+		bankName := value("bankName")
+		countryISO2 := strings.ToUpper(value("countryISO2"))
+		countryName := strings.ToUpper(value("countryName"))
+
+		records = append(records, Record{
+			Address:       &address,
+			BankName:      &bankName,
+			CountryISO2:   &countryISO2,
+			CountryName:   &countryName,
+			IsHeadquarter: &isHeadquarter,
+			SwiftCode:     &swiftCode,
+		})
+	}
+
+	return records, nil
+}
@@ -0,0 +1,73 @@
+//go:build unit
+
+package bankdata
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	t.Run("directory file format", func(t *testing.T) {
+		input := "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n" +
+			"PL,TESTPL33XXX,BIC11,Test Bank,\"Main Street 1, Warsaw\",WARSZAWA,Poland,Europe/Warsaw\n" +
+			"pl,testpl33abc,BIC11,Test Bank,Side Street 2,KRAKOW,poland,Europe/Warsaw\n"
+
+		banks, err := ReadSnapshot(strings.NewReader(input))
+		require.NoError(t, err)
+		require.Len(t, banks, 2)
+
+		assert.Equal(t, "TESTPL33XXX", *banks[0].SwiftCode)
+		assert.Equal(t, "Main Street 1, Warsaw", *banks[0].Address)
+		assert.Equal(t, "POLAND", *banks[0].CountryName)
+		assert.True(t, *banks[0].IsHeadquarter)
+
+		assert.Equal(t, "TESTPL33ABC", *banks[1].SwiftCode)
+		assert.Equal(t, "PL", *banks[1].CountryISO2)
+		assert.False(t, *banks[1].IsHeadquarter)
+	})
+
+	t.Run("service CSV format", func(t *testing.T) {
+		input := "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n" +
+			"TESTPL33XXX,Test Bank,Main Street,PL,POLAND,true\n"
+
+		banks, err := ReadSnapshot(strings.NewReader(input))
+		require.NoError(t, err)
+		require.Len(t, banks, 1)
+		assert.True(t, *banks[0].IsHeadquarter)
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{name: "empty file", input: "", expectedErr: "snapshot is empty"},
+		{name: "missing column", input: "SWIFT CODE,NAME\nTESTPL33XXX,Bank\n", expectedErr: `missing required column "address"`},
+		{
+			name:        "duplicate swift code",
+			input:       "swiftCode,bankName,address,countryISO2,countryName\nTESTPL33XXX,A,B,PL,POLAND\nTESTPL33XXX,A,B,PL,POLAND\n",
+			expectedErr: "line 3: swift code TESTPL33XXX duplicates line 2",
+		},
+		{
+			name:        "empty swift code",
+			input:       "swiftCode,bankName,address,countryISO2,countryName\n,A,B,PL,POLAND\n",
+			expectedErr: "line 2: swift code is empty",
+		},
+		{
+			name:        "invalid isHeadquarter",
+			input:       "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\nTESTPL33XXX,A,B,PL,POLAND,maybe\n",
+			expectedErr: `line 2: invalid isHeadquarter value "maybe"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSnapshot(strings.NewReader(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package bankdata holds the rules for the data of SWIFT code records, which are shared by the service
+// and bicctl, so that the command-line client does not link the server.
+package bankdata
+
+import (
+	"errors"
+	country "github.com/mikekonan/go-countries"
+	"regexp"
+	"strings"
+)
+
+// Record is the data of a SWIFT code as it is given to the service. Fields are pointers, so that a
+// missing field can be told apart from an empty one.
+type Record struct {
+	Address       *string
+	BankName      *string
+	CountryISO2   *string
+	CountryName   *string
+	IsHeadquarter *bool
+	SwiftCode     *string
+}
+
+var swiftPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// Validate checks a record against the rules applied to POST /v1/swift-codes, with the given handling
+// of country names. The countryName of a valid record is replaced by the canonical name of its country.
+func Validate(r Record, countryNames CountryNames) error {
+	if r.Address == nil {
+		return errors.New("address is required")
+	}
+
+	if r.BankName == nil || strings.TrimSpace(*r.BankName) == "" {
+		return errors.New("bankName is required")
+	}
+
+	if r.CountryISO2 == nil {
+		return errors.New("countryISO2 is required")
+	}
+
+	if r.CountryName == nil {
+		return errors.New("countryName is required")
+	}
+
+	if r.IsHeadquarter == nil {
+		return errors.New("isHeadquarter is required")
+	}
+
+	if r.SwiftCode == nil {
+		return errors.New("swiftCode is required")
+	}
+
+	if !ValidISO2(*r.CountryISO2) {
+		return errors.New("countryISO2 is invalid")
+	}
+
+	canonical, ok := knownCountryNames.resolve(*r.CountryName, *r.CountryISO2, countryNames)
+	if !ok {
+		return errors.New("countryName does not match ISO2 code")
+	}
+	*r.CountryName = canonical
+
+	if !ValidSWIFT(*r.SwiftCode, *r.CountryISO2) {
+		return errors.New("swiftCode is invalid")
+	}
+
+	if strings.HasSuffix(*r.SwiftCode, "XXX") && !*r.IsHeadquarter {
+		return errors.New("swiftCode indicates bank's headquarter")
+	}
+
+	if *r.IsHeadquarter && !strings.HasSuffix(*r.SwiftCode, "XXX") {
+		return errors.New("swiftCode indicates bank's branch")
+	}
+
+	return nil
+}
+
+// ValidISO2 tells whether the code is the upper-case ISO 3166-1 alpha-2 code of a country.
+func ValidISO2(code string) bool {
+	if code != strings.ToUpper(code) {
+		return false
+	}
+	_, ok := country.ByAlpha2CodeStr(code)
+	return ok
+}
+
+// ValidSWIFT tells whether s is a BIC8 or BIC11 code of a bank located in the given country.
+func ValidSWIFT(s, iso2Code string) bool {
+	return swiftPattern.MatchString(s) && s[4:6] == iso2Code
+}
+
+// CountryName returns the canonical name of the country, or an empty string if the code is unknown.
+func CountryName(iso2Code string) string {
+	return knownCountryNames.canonical[iso2Code]
+}
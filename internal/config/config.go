@@ -0,0 +1,496 @@
+// Package config loads the settings of the service. Each setting has a default, which is overridden,
+// in order of increasing precedence, by:
+//
+//  1. the configuration file (YAML or TOML) given with --config or CONFIG_FILE,
+//  2. the environment, where the variables of a .env file in the working directory take precedence,
+//  3. the command-line flags.
+//
+// Every environment variable can instead be given as <NAME>_FILE, the path of a file holding the
+// value, which is how secrets are usually mounted into containers.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Config holds the settings of the service.
+type Config struct {
+	Port           string
+	GRPCPort       string // empty disables the gRPC API
+	Database       storage.PostgresConfig
+	Replicas       []string            // connection strings of read replicas, which share the other settings of Database
+	Cache          storage.CacheConfig // a Size of 0 disables the lookup cache
+	IdempotencyTTL time.Duration
+	EventsLogSize  int
+	Outbox         OutboxConfig
+	CountryNames   string // "strict" or "aliases", see bankdata.CountryNames
+
+	// sources tells where each setting, by key, was read from
+	sources map[string]string
+}
+
+// OutboxConfig selects the publisher of the change data feed: "" (webhook subscriptions only),
+// "stdout", "file" or "webhook".
+type OutboxConfig struct {
+	Publisher  string
+	File       string
+	WebhookURL string
+}
+
+// Default returns the configuration used when nothing else is given. It has no database password,
+// which must always be configured.
+func Default() *Config {
+	return &Config{
+		Port:     "8080",
+		GRPCPort: "9090",
+		Database: storage.PostgresConfig{
+			Host:           "localhost",
+			Port:           "5432",
+			User:           "example_user",
+			Database:       "bicdatabase",
+			ConnectTimeout: time.Minute,
+		},
+		Cache: storage.CacheConfig{
+			Size:        10000,
+			TTL:         5 * time.Minute,
+			NegativeTTL: 30 * time.Second,
+		},
+		IdempotencyTTL: 24 * time.Hour,
+		EventsLogSize:  1000,
+		CountryNames:   "aliases",
+	}
+}
+
+// setting describes how a field of Config is named in the configuration file, the environment and
+// on the command line.
+type setting struct {
+	key    string // in the configuration file, sections separated by dots
+	env    string
+	flag   string // empty if the setting cannot be given on the command line
+	secret bool   // never printed, and not accepted as a flag so that it does not show up in ps
+	usage  string
+	value  flag.Value
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "port", env: "PORT", flag: "port", usage: "HTTP port", value: (*stringValue)(&c.Port)},
+		{key: "grpcPort", env: "GRPC_PORT", flag: "grpc-port", usage: "gRPC port, empty disables the gRPC API",
+			value: (*stringValue)(&c.GRPCPort)},
+		{key: "database.url", env: "DATABASE_URL", secret: true,
+			usage: "PostgreSQL connection URL or key=value string, instead of the host, port, user, password and name, or sqlite:// URL of a SQLite database file",
+			value: (*stringValue)(&c.Database.URL)},
+		{key: "database.host", env: "DB_HOST", flag: "db-host", usage: "PostgreSQL host",
+			value: (*stringValue)(&c.Database.Host)},
+		{key: "database.port", env: "DB_PORT", flag: "db-port", usage: "PostgreSQL port",
+			value: (*stringValue)(&c.Database.Port)},
+		{key: "database.user", env: "DB_USER", flag: "db-user", usage: "PostgreSQL user",
+			value: (*stringValue)(&c.Database.User)},
+		{key: "database.password", env: "DB_PASSWORD", secret: true, usage: "PostgreSQL password",
+			value: (*stringValue)(&c.Database.Password)},
+		{key: "database.name", env: "DB_NAME", flag: "db-name", usage: "PostgreSQL database",
+			value: (*stringValue)(&c.Database.Database)},
+		{key: "database.replicas", env: "DB_REPLICA_URLS", secret: true,
+			usage: "comma-separated connection strings of read replicas", value: (*listValue)(&c.Replicas)},
+		{key: "database.connectTimeout", env: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout",
+			usage: "how long to retry connecting at startup, 0 tries once", value: (*durationValue)(&c.Database.ConnectTimeout)},
+		{key: "database.sslMode", env: "DB_SSLMODE", flag: "db-sslmode",
+			usage: "TLS mode: disable, require, verify-ca or verify-full", value: (*stringValue)(&c.Database.SSLMode)},
+		{key: "database.sslRootCert", env: "DB_SSLROOTCERT", flag: "db-sslrootcert",
+			usage: "CA certificate verifying the server (PEM file)", value: (*stringValue)(&c.Database.SSLRootCert)},
+		{key: "database.sslCert", env: "DB_SSLCERT", flag: "db-sslcert",
+			usage: "client certificate (PEM file)", value: (*stringValue)(&c.Database.SSLCert)},
+		{key: "database.sslKey", env: "DB_SSLKEY", flag: "db-sslkey",
+			usage: "key of the client certificate (PEM file)", value: (*stringValue)(&c.Database.SSLKey)},
+		{key: "database.maxOpenConns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns",
+			usage: "maximum open connections, 0 is unlimited", value: (*intValue)(&c.Database.MaxOpenConns)},
+		{key: "database.maxIdleConns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns",
+			usage: "maximum idle connections, 0 keeps the default of 2", value: (*intValue)(&c.Database.MaxIdleConns)},
+		{key: "database.connMaxLifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime",
+			usage: "maximum lifetime of a connection, 0 is unlimited", value: (*durationValue)(&c.Database.ConnMaxLifetime)},
+		{key: "database.connMaxIdleTime", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time",
+			usage: "maximum idle time of a connection, 0 is unlimited", value: (*durationValue)(&c.Database.ConnMaxIdleTime)},
+		{key: "cache.size", env: "CACHE_SIZE", flag: "cache-size", usage: "entries of the lookup cache, 0 disables it",
+			value: (*intValue)(&c.Cache.Size)},
+		{key: "cache.ttl", env: "CACHE_TTL", flag: "cache-ttl", usage: "how long lookups are cached",
+			value: (*durationValue)(&c.Cache.TTL)},
+		{key: "cache.negativeTTL", env: "CACHE_NEGATIVE_TTL", flag: "cache-negative-ttl",
+			usage: `how long "not found" answers are cached`, value: (*durationValue)(&c.Cache.NegativeTTL)},
+		{key: "idempotency.ttl", env: "IDEMPOTENCY_TTL", flag: "idempotency-ttl",
+			usage: "how long responses to idempotent requests are kept", value: (*durationValue)(&c.IdempotencyTTL)},
+		{key: "events.logSize", env: "EVENTS_LOG_SIZE", flag: "events-log-size",
+			usage: "events kept for clients resuming the change stream", value: (*intValue)(&c.EventsLogSize)},
+		{key: "outbox.publisher", env: "OUTBOX_PUBLISHER", flag: "outbox-publisher",
+			usage: "publisher of the change data feed: stdout, file or webhook", value: (*stringValue)(&c.Outbox.Publisher)},
+		{key: "outbox.file", env: "OUTBOX_FILE", flag: "outbox-file", usage: "file of the file publisher",
+			value: (*stringValue)(&c.Outbox.File)},
+		{key: "outbox.webhookURL", env: "OUTBOX_WEBHOOK_URL", flag: "outbox-webhook-url",
+			usage: "URL of the webhook publisher", value: (*stringValue)(&c.Outbox.WebhookURL)},
+		{key: "validation.countryNames", env: "COUNTRY_NAMES", flag: "country-names",
+			usage: "accepted country names: strict (canonical names only) or aliases", value: (*stringValue)(&c.CountryNames)},
+	}
+}
+
+// Load reads the configuration from the configuration file, the environment and args, the
+// command-line arguments without the program name, and validates it. The arguments following the
+// flags are returned.
+func Load(args []string) (*Config, []string, error) {
+	c := Default()
+	c.sources = make(map[string]string)
+	settings := c.settings()
+
+	// flags are applied last, but parsed first to find the configuration file
+	flags := flag.NewFlagSet(commandName(), flag.ContinueOnError)
+	path := flags.String("config", "", "configuration file (YAML or TOML), or CONFIG_FILE")
+	var given []setting
+	var givenValues []string
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		usage := fmt.Sprintf("%s (default %q, env %s)", s.usage, s.value.String(), s.env)
+		flags.Func(s.flag, usage, func(value string) error {
+			given = append(given, s)
+			givenValues = append(givenValues, value)
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *path == "" {
+		*path = os.Getenv("CONFIG_FILE")
+	}
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := c.apply(settings, values, *path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dotEnv, err := readDotEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.applyEnv(settings, lookupEnv(dotEnv)); err != nil {
+		return nil, nil, err
+	}
+
+	for i, s := range given {
+		if err := c.set(s, givenValues[i], "flag --"+s.flag); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, flags.Args(), nil
+}
+
+// apply sets the settings found in a configuration file. Unknown keys are rejected, so that typos
+// do not go unnoticed.
+func (c *Config) apply(settings []setting, values map[string]string, path string) error {
+	for key, value := range values {
+		i := findSetting(settings, key)
+		if i < 0 {
+			return fmt.Errorf("unknown setting %q in %s", key, path)
+		}
+		if err := c.set(settings[i], value, "file "+path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyEnv(settings []setting, lookup func(string) (string, bool)) error {
+	for _, s := range settings {
+		value, ok := lookup(s.env)
+		path, fromFile := lookup(s.env + "_FILE")
+		if ok && fromFile {
+			return fmt.Errorf("only one of %s and %s_FILE can be set", s.env, s.env)
+		}
+
+		source := "env " + s.env
+		if fromFile {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s_FILE: %w", s.env, err)
+			}
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+			source += "_FILE"
+		}
+		if !ok {
+			continue
+		}
+		if err := c.set(s, value, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) set(s setting, value, source string) error {
+	if err := s.value.Set(value); err != nil {
+		return fmt.Errorf("invalid %s from %s: %w", s.key, source, err)
+	}
+	c.sources[s.key] = source
+	return nil
+}
+
+// Validate checks that the configuration is complete and consistent. All problems are reported at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if err := validatePort(c.Port); err != nil {
+		errs = append(errs, fmt.Errorf("port: %w", err))
+	}
+	if c.GRPCPort != "" {
+		if err := validatePort(c.GRPCPort); err != nil {
+			errs = append(errs, fmt.Errorf("grpcPort: %w", err))
+		}
+	}
+
+	errs = append(errs, validateDatabase(c.Database)...)
+	if storage.IsSQLite(c.Database.URL) {
+		// a SQLite database is used by a single instance, which has no outbox
+		if len(c.Replicas) > 0 {
+			errs = append(errs, errors.New("database.replicas cannot be used with SQLite"))
+		}
+		if c.Outbox.Publisher != "" {
+			errs = append(errs, errors.New("outbox.publisher cannot be used with SQLite"))
+		}
+	}
+	for i, replica := range c.Replicas {
+		if strings.Contains(replica, "://") {
+			if u, err := url.Parse(replica); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+				errs = append(errs, fmt.Errorf("database.replicas: replica %d must be a postgres:// URL or a key=value string", i))
+			}
+		}
+	}
+
+	if c.Cache.Size < 0 {
+		errs = append(errs, errors.New("cache.size cannot be negative"))
+	}
+	if c.Cache.Size > 0 && c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache.ttl must be positive"))
+	}
+	if c.Cache.NegativeTTL < 0 {
+		errs = append(errs, errors.New("cache.negativeTTL cannot be negative"))
+	}
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("idempotency.ttl must be positive"))
+	}
+	if c.EventsLogSize < 0 {
+		errs = append(errs, errors.New("events.logSize cannot be negative"))
+	}
+
+	switch c.Outbox.Publisher {
+	case "", "stdout":
+	case "file":
+		if c.Outbox.File == "" {
+			errs = append(errs, errors.New("outbox.file is required for the file publisher (OUTBOX_FILE)"))
+		}
+	case "webhook":
+		if c.Outbox.WebhookURL == "" {
+			errs = append(errs, errors.New("outbox.webhookURL is required for the webhook publisher (OUTBOX_WEBHOOK_URL)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("outbox.publisher %q is invalid", c.Outbox.Publisher))
+	}
+
+	if c.CountryNames != "strict" && c.CountryNames != "aliases" {
+		errs = append(errs, fmt.Errorf("validation.countryNames %q is invalid, use strict or aliases", c.CountryNames))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validateDatabase(c storage.PostgresConfig) []error {
+	var errs []error
+	if storage.IsSQLite(c.URL) {
+		if strings.Trim(strings.TrimPrefix(c.URL, "sqlite:"), "/") == "" {
+			errs = append(errs, errors.New("database.url must name the SQLite database file, e.g. sqlite:///var/lib/bic/bic.db"))
+		}
+	} else if c.URL != "" {
+		if strings.Contains(c.URL, "://") {
+			if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+				errs = append(errs, errors.New("database.url must be a postgres:// or sqlite:// URL, or a key=value string"))
+			}
+		}
+	} else {
+		if c.Host == "" {
+			errs = append(errs, errors.New("database.host is required (DB_HOST)"))
+		}
+		if err := validatePort(c.Port); err != nil {
+			errs = append(errs, fmt.Errorf("database.port: %w", err))
+		}
+		if c.User == "" {
+			errs = append(errs, errors.New("database.user is required (DB_USER)"))
+		}
+		if c.Password == "" {
+			errs = append(errs, errors.New("database.password is required (DB_PASSWORD or DB_PASSWORD_FILE)"))
+		}
+		if c.Database == "" {
+			errs = append(errs, errors.New("database.name is required (DB_NAME)"))
+		}
+	}
+
+	switch c.SSLMode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("database.sslMode %q is invalid, use disable, require, verify-ca or verify-full", c.SSLMode))
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		errs = append(errs, errors.New("database.sslCert and database.sslKey must be given together"))
+	}
+	for _, file := range []struct{ key, path string }{
+		{"database.sslRootCert", c.SSLRootCert},
+		{"database.sslCert", c.SSLCert},
+		{"database.sslKey", c.SSLKey},
+	} {
+		if _, err := os.Stat(file.path); file.path != "" && err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.key, err))
+		}
+	}
+
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.maxOpenConns and database.maxIdleConns cannot be negative"))
+	}
+	if c.ConnectTimeout < 0 {
+		errs = append(errs, errors.New("database.connectTimeout cannot be negative"))
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database.connMaxLifetime and database.connMaxIdleTime cannot be negative"))
+	}
+	return errs
+}
+
+// Print writes the effective configuration and where each setting comes from. Secrets are redacted.
+func (c *Config) Print(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range c.settings() {
+		value := s.value.String()
+		if s.secret && value != "" {
+			value = "<redacted>"
+		}
+		source, ok := c.sources[s.key]
+		if !ok {
+			source = "default"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t(%s)\n", s.key, value, source)
+	}
+	return writer.Flush()
+}
+
+// readDotEnv reads the variables of the .env file in the working directory, if there is one.
+func readDotEnv() (map[string]string, error) {
+	if _, err := os.Stat(".env"); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	values, err := godotenv.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+	return values, nil
+}
+
+// lookupEnv looks variables up in the .env file first, then in the environment.
+func lookupEnv(dotEnv map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		if value, ok := dotEnv[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}
+}
+
+func findSetting(settings []setting, key string) int {
+	for i, s := range settings {
+		if strings.EqualFold(s.key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%q is not a valid port", port)
+	}
+	return nil
+}
+
+func commandName() string {
+	if len(os.Args) == 0 {
+		return "api"
+	}
+	return os.Args[0]
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readFile reads a YAML (.yaml, .yml) or TOML (.toml) configuration file. Sections are flattened into
+// keys separated by dots, e.g.
+//
+//	database:
+//	  host: db
+//
+// sets database.host.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	document := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("configuration file %s must be YAML (.yaml, .yml) or TOML (.toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", document); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, section map[string]any) error {
+	for key, value := range section {
+		key = prefix + key
+		switch value := value.(type) {
+		case map[string]any:
+			if err := flatten(values, key+".", value); err != nil {
+				return err
+			}
+		case string:
+			values[key] = value
+		case int, int64, uint64, float64, bool:
+			values[key] = fmt.Sprint(value)
+		case []any:
+			// lists are given like in the environment, comma-separated
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			return fmt.Errorf("%s must be a single value", key)
+		}
+	}
+	return nil
+}
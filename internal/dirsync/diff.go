@@ -0,0 +1,159 @@
+package dirsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"sort"
+	"strconv"
+)
+
+type Diff struct {
+	Added    []storage.Bank `json:"added"`
+	Modified []Modification `json:"modified"`
+	Removed  []storage.Bank `json:"removed"`
+	Rejected []Rejection    `json:"rejected"`
+}
+
+type Modification struct {
+	SwiftCode string        `json:"swiftCode"`
+	Before    storage.Bank  `json:"before"`
+	After     storage.Bank  `json:"after"`
+	Fields    []FieldChange `json:"fields"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Rejection is a snapshot record that did not pass validation. Rejected records are
+// neither added nor modified, and their stored counterparts are never removed.
+type Rejection struct {
+	SwiftCode string `json:"swiftCode"`
+	Reason    string `json:"reason"`
+}
+
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0
+}
+
+// Compute compares the stored records with a full snapshot. Records are matched by SWIFT code.
+// validate is optional and is called for every snapshot record before it is compared.
+func Compute(current, snapshot []storage.Bank, validate func(storage.Bank) error) Diff {
+	var diff Diff
+
+	stored := make(map[string]storage.Bank, len(current))
+	for _, b := range current {
+		stored[*b.SwiftCode] = b
+	}
+
+	inSnapshot := make(map[string]bool, len(snapshot))
+	for _, b := range snapshot {
+		swiftCode := *b.SwiftCode
+		inSnapshot[swiftCode] = true
+
+		if validate != nil {
+			if err := validate(b); err != nil {
+				diff.Rejected = append(diff.Rejected, Rejection{SwiftCode: swiftCode, Reason: err.Error()})
+				continue
+			}
+		}
+
+		before, ok := stored[swiftCode]
+		if !ok {
+			diff.Added = append(diff.Added, b)
+			continue
+		}
+
+		if fields := compareBanks(before, b); len(fields) > 0 {
+			diff.Modified = append(diff.Modified, Modification{SwiftCode: swiftCode, Before: before, After: b, Fields: fields})
+		}
+	}
+
+	for _, b := range current {
+		if !inSnapshot[*b.SwiftCode] {
+			diff.Removed = append(diff.Removed, b)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return *diff.Added[i].SwiftCode < *diff.Added[j].SwiftCode })
+	sort.Slice(diff.Modified, func(i, j int) bool { return diff.Modified[i].SwiftCode < diff.Modified[j].SwiftCode })
+	sort.Slice(diff.Removed, func(i, j int) bool { return *diff.Removed[i].SwiftCode < *diff.Removed[j].SwiftCode })
+	sort.Slice(diff.Rejected, func(i, j int) bool { return diff.Rejected[i].SwiftCode < diff.Rejected[j].SwiftCode })
+
+	return diff
+}
+
+func compareBanks(before, after storage.Bank) []FieldChange {
+	var fields []FieldChange
+	compare := func(field, old, new string) {
+		if old != new {
+			fields = append(fields, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	compare("address", *before.Address, *after.Address)
+	compare("bankName", *before.BankName, *after.BankName)
+	compare("countryISO2", *before.CountryISO2, *after.CountryISO2)
+	compare("countryName", *before.CountryName, *after.CountryName)
+	compare("isHeadquarter", strconv.FormatBool(*before.IsHeadquarter), strconv.FormatBool(*after.IsHeadquarter))
+
+	return fields
+}
+
+// Changes converts the diff into storage changes, additions first and removals last.
+func (d Diff) Changes() []storage.Change {
+	changes := make([]storage.Change, 0, len(d.Added)+len(d.Modified)+len(d.Removed))
+	for i := range d.Added {
+		changes = append(changes, storage.Change{Action: storage.ChangeAdd, After: &d.Added[i]})
+	}
+	for i := range d.Modified {
+		changes = append(changes, storage.Change{Action: storage.ChangeModify, Before: &d.Modified[i].Before, After: &d.Modified[i].After})
+	}
+	for i := range d.Removed {
+		changes = append(changes, storage.Change{Action: storage.ChangeRemove, Before: &d.Removed[i]})
+	}
+	return changes
+}
+
+// WriteText prints a human-readable diff, one line per record prefixed with +, ~, - or !.
+func WriteText(w io.Writer, d Diff) error {
+	for _, b := range d.Added {
+		if _, err := fmt.Fprintf(w, "+ %s  %s, %s (%s)\n", *b.SwiftCode, *b.BankName, *b.Address, *b.CountryISO2); err != nil {
+			return err
+		}
+	}
+	for _, m := range d.Modified {
+		if _, err := fmt.Fprintf(w, "~ %s\n", m.SwiftCode); err != nil {
+			return err
+		}
+		for _, f := range m.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %q -> %q\n", f.Field, f.Old, f.New); err != nil {
+				return err
+			}
+		}
+	}
+	for _, b := range d.Removed {
+		if _, err := fmt.Fprintf(w, "- %s  %s, %s (%s)\n", *b.SwiftCode, *b.BankName, *b.Address, *b.CountryISO2); err != nil {
+			return err
+		}
+	}
+	for _, r := range d.Rejected {
+		if _, err := fmt.Fprintf(w, "! %s  rejected: %s\n", r.SwiftCode, r.Reason); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d added, %d modified, %d removed, %d rejected\n",
+		len(d.Added), len(d.Modified), len(d.Removed), len(d.Rejected))
+	return err
+}
+
+func WriteJSON(w io.Writer, d Diff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
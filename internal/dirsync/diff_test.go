@@ -0,0 +1,129 @@
+//go:build unit
+
+package dirsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func bank(swiftCode, bankName, address string) storage.Bank {
+	iso2, country := swiftCode[4:6], "POLAND"
+	isHeadquarter := swiftCode[8:] == "XXX"
+	return storage.Bank{
+		Address:       &address,
+		BankName:      &bankName,
+		CountryISO2:   &iso2,
+		CountryName:   &country,
+		IsHeadquarter: &isHeadquarter,
+		SwiftCode:     &swiftCode,
+	}
+}
+
+type fakeStore struct {
+	banks   []storage.Bank
+	applied []storage.Change
+	source  string
+}
+
+func (f *fakeStore) ListSwiftCodes() ([]storage.Bank, error) {
+	return f.banks, nil
+}
+
+func (f *fakeStore) ApplyChanges(changes []storage.Change, source string) error {
+	f.applied = changes
+	f.source = source
+	return nil
+}
+
+func TestCompute(t *testing.T) {
+	current := []storage.Bank{
+		bank("TESTPL33XXX", "Test Bank", "Main Street"),
+		bank("TESTPL33ABC", "Test Bank", "Old Street"),
+		bank("TESTPL33DEF", "Test Bank", "Closed Street"),
+		bank("TESTPL33GHI", "Test Bank", "Manually Fixed Street"),
+	}
+	snapshot := []storage.Bank{
+		bank("TESTPL33XXX", "Test Bank", "Main Street"),
+		bank("TESTPL33ABC", "Test Bank", "New Street"),
+		bank("TESTPL33GHI", "", "Broken Street"),
+		bank("TESTPL44XXX", "Other Bank", "Other Street"),
+	}
+	validate := func(b storage.Bank) error {
+		if *b.BankName == "" {
+			return errors.New("bankName is required")
+		}
+		return nil
+	}
+
+	diff := Compute(current, snapshot, validate)
+
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "TESTPL44XXX", *diff.Added[0].SwiftCode)
+
+	require.Len(t, diff.Modified, 1)
+	assert.Equal(t, "TESTPL33ABC", diff.Modified[0].SwiftCode)
+	assert.Equal(t, []FieldChange{{Field: "address", Old: "Old Street", New: "New Street"}}, diff.Modified[0].Fields)
+
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "TESTPL33DEF", *diff.Removed[0].SwiftCode)
+
+	// rejected records must not be treated as removed
+	require.Len(t, diff.Rejected, 1)
+	assert.Equal(t, Rejection{SwiftCode: "TESTPL33GHI", Reason: "bankName is required"}, diff.Rejected[0])
+
+	changes := diff.Changes()
+	require.Len(t, changes, 3)
+	assert.Equal(t, storage.ChangeAdd, changes[0].Action)
+	assert.Equal(t, storage.ChangeModify, changes[1].Action)
+	assert.Equal(t, "Old Street", *changes[1].Before.Address)
+	assert.Equal(t, storage.ChangeRemove, changes[2].Action)
+	assert.Equal(t, "TESTPL33DEF", changes[2].SwiftCode())
+}
+
+func TestWriteDiff(t *testing.T) {
+	diff := Compute(
+		[]storage.Bank{bank("TESTPL33ABC", "Test Bank", "Old Street"), bank("TESTPL33DEF", "Test Bank", "Closed Street")},
+		[]storage.Bank{bank("TESTPL33ABC", "Test Bank", "New Street"), bank("TESTPL44XXX", "Other Bank", "Other Street")},
+		nil,
+	)
+
+	var text bytes.Buffer
+	require.NoError(t, WriteText(&text, diff))
+	assert.Equal(t, "+ TESTPL44XXX  Other Bank, Other Street (PL)\n"+
+		"~ TESTPL33ABC\n"+
+		"    address: \"Old Street\" -> \"New Street\"\n"+
+		"- TESTPL33DEF  Test Bank, Closed Street (PL)\n"+
+		"1 added, 1 modified, 1 removed, 0 rejected\n", text.String())
+
+	var encoded bytes.Buffer
+	require.NoError(t, WriteJSON(&encoded, diff))
+	var decoded Diff
+	require.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	assert.Equal(t, diff.Modified[0].Fields, decoded.Modified[0].Fields)
+	assert.Len(t, decoded.Added, 1)
+	assert.Len(t, decoded.Removed, 1)
+}
+
+func TestSyncer(t *testing.T) {
+	store := &fakeStore{banks: []storage.Bank{bank("TESTPL33XXX", "Test Bank", "Main Street")}}
+	syncer := NewSyncer(store, nil)
+
+	diff, err := syncer.Plan([]storage.Bank{bank("TESTPL33XXX", "Test Bank", "Main Street")})
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+	require.NoError(t, syncer.Apply(diff, "sync:test.csv"))
+	assert.Nil(t, store.applied, "empty diff must not open a transaction")
+
+	diff, err = syncer.Plan(nil)
+	require.NoError(t, err)
+	require.NoError(t, syncer.Apply(diff, "sync:test.csv"))
+	require.Len(t, store.applied, 1)
+	assert.Equal(t, storage.ChangeRemove, store.applied[0].Action)
+	assert.Equal(t, "sync:test.csv", store.source)
+}
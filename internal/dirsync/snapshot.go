@@ -0,0 +1,28 @@
+package dirsync
+
+import (
+	"github.com/pkacprzak5/bic-data-service/internal/bankdata"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+)
+
+// ReadSnapshot parses a full directory snapshot in CSV format, see bankdata.ReadSnapshot.
+func ReadSnapshot(r io.Reader) ([]storage.Bank, error) {
+	records, err := bankdata.ReadSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+
+	banks := make([]storage.Bank, len(records))
+	for i, record := range records {
+		banks[i] = storage.Bank{
+			Address:       record.Address,
+			BankName:      record.BankName,
+			CountryISO2:   record.CountryISO2,
+			CountryName:   record.CountryName,
+			IsHeadquarter: record.IsHeadquarter,
+			SwiftCode:     record.SwiftCode,
+		}
+	}
+	return banks, nil
+}
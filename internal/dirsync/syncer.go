@@ -0,0 +1,38 @@
+package dirsync
+
+import (
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+)
+
+// Store is the part of the storage backend needed to synchronise it with a directory snapshot.
+type Store interface {
+	ListSwiftCodes() ([]storage.Bank, error)
+
+	ApplyChanges(changes []storage.Change, source string) error
+}
+
+type Syncer struct {
+	store    Store
+	validate func(storage.Bank) error
+}
+
+func NewSyncer(store Store, validate func(storage.Bank) error) *Syncer {
+	return &Syncer{store: store, validate: validate}
+}
+
+// Plan computes the diff between the stored records and the snapshot without changing anything.
+func (s *Syncer) Plan(snapshot []storage.Bank) (Diff, error) {
+	current, err := s.store.ListSwiftCodes()
+	if err != nil {
+		return Diff{}, err
+	}
+	return Compute(current, snapshot, s.validate), nil
+}
+
+// Apply writes the diff to the store in one transaction. source is recorded in the audit entries.
+func (s *Syncer) Apply(d Diff, source string) error {
+	if d.IsEmpty() {
+		return nil
+	}
+	return s.store.ApplyChanges(d.Changes(), source)
+}
@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Publisher delivers outbox events to downstream systems. An event is delivered at least once,
+// so receivers should use its ID to detect duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, event storage.OutboxEvent) error
+}
+
+// WriterPublisher writes every event as a line of JSON, e.g. to stdout or to a file.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event storage.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
+
+// WebhookPublisher POSTs every event as JSON to a fixed URL. Any response other than 2xx is a failure.
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, client *http.Client) *WebhookPublisher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookPublisher{url: url, client: client}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event storage.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// MultiPublisher publishes every event to all of the publishers. If any of them fails the event is
+// retried, and so delivered again to those which succeeded.
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event storage.OutboxEvent) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+//go:build unit
+
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testEvent = storage.OutboxEvent{
+	ID:          7,
+	Action:      storage.ChangeAdd,
+	SwiftCode:   "TESTPLPWXXX",
+	CountryISO2: "PL",
+	Data:        json.RawMessage(`{"swiftCode":"TESTPLPWXXX"}`),
+	OccurredAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+const testEventJSON = `{"id":7,"action":"add","swiftCode":"TESTPLPWXXX","countryISO2":"PL",` +
+	`"data":{"swiftCode":"TESTPLPWXXX"},"occurredAt":"2026-03-01T12:00:00Z"}`
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewWriterPublisher(&buf)
+
+	require.NoError(t, publisher.Publish(context.Background(), testEvent))
+	require.NoError(t, publisher.Publish(context.Background(), testEvent))
+
+	assert.Equal(t, testEventJSON+"\n"+testEventJSON+"\n", buf.String())
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	t.Run("delivered", func(t *testing.T) {
+		var received []byte
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			received, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := NewWebhookPublisher(server.URL, nil).Publish(context.Background(), testEvent)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, testEventJSON, string(received))
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "7", header.Get("X-Event-ID"))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewWebhookPublisher(server.URL, nil).Publish(context.Background(), testEvent)
+
+		assert.EqualError(t, err, "webhook responded with status 503")
+	})
+}
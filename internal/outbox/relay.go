@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"log"
+	"time"
+)
+
+type Config struct {
+	// BatchSize is the number of events claimed at once.
+	BatchSize int
+
+	// PollInterval is how long the relay waits for new events once the outbox is drained.
+	PollInterval time.Duration
+
+	// Lease is how long a claimed event is reserved for this relay. Events which are not delivered
+	// within the lease (e.g. because the relay crashed) are claimed again.
+	Lease time.Duration
+
+	// MaxAttempts is the number of failed deliveries after which an event is dead-lettered.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the exponential delay between delivery attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:    100,
+		PollInterval: time.Second,
+		Lease:        time.Minute,
+		MaxAttempts:  10,
+		MinBackoff:   time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+// Relay moves events from the outbox to a Publisher. Several relays may share one outbox,
+// every event is claimed by one of them at a time.
+type Relay struct {
+	store     storage.OutboxStore
+	publisher Publisher
+	config    Config
+	now       func() time.Time
+}
+
+func NewRelay(store storage.OutboxStore, publisher Publisher, config Config) *Relay {
+	return &Relay{store: store, publisher: publisher, config: config, now: time.Now}
+}
+
+// Run relays events until ctx is done.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		processed, err := r.ProcessBatch(ctx)
+		if err != nil {
+			log.Printf("Failed to relay outbox events: %v", err)
+		}
+
+		// keep going without waiting while the outbox has a backlog
+		if err == nil && processed == r.config.BatchSize {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+// ProcessBatch claims one batch of events and tries to publish each of them. It returns the number
+// of claimed events.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	events, err := r.store.ClaimOutboxEvents(r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// the remaining events are claimed again once their lease expires
+			return len(events), nil
+		}
+		if err := r.deliver(ctx, event); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+// deliver publishes the event and records the outcome. It only returns errors of the store.
+func (r *Relay) deliver(ctx context.Context, event storage.OutboxEvent) error {
+	publishCtx, cancel := context.WithTimeout(ctx, r.config.Lease/2)
+	err := r.publisher.Publish(publishCtx, event)
+	cancel()
+
+	if err == nil {
+		return r.store.AckOutboxEvent(event.ID)
+	}
+
+	attempts := event.Attempts + 1
+	if attempts >= r.config.MaxAttempts {
+		log.Printf("Giving up on outbox event %d after %d attempts: %v", event.ID, attempts, err)
+		return r.store.DeadLetterOutboxEvent(event.ID, err.Error())
+	}
+
+	return r.store.RetryOutboxEvent(event.ID, r.now().Add(Backoff(attempts, r.config.MinBackoff, r.config.MaxBackoff)), err.Error())
+}
+
+// Backoff returns the exponential delay before the next attempt after the given number of failed
+// attempts, starting at min and capped at max.
+func Backoff(attempts int, min, max time.Duration) time.Duration {
+	delay := min
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,134 @@
+//go:build unit
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory storage.OutboxStore.
+type fakeStore struct {
+	events     []storage.OutboxEvent
+	acked      []int64
+	retried    map[int64]time.Time
+	deadLetter map[int64]string
+}
+
+func newFakeStore(events ...storage.OutboxEvent) *fakeStore {
+	return &fakeStore{events: events, retried: map[int64]time.Time{}, deadLetter: map[int64]string{}}
+}
+
+func (f *fakeStore) ClaimOutboxEvents(limit int, _ time.Duration) ([]storage.OutboxEvent, error) {
+	if len(f.events) < limit {
+		limit = len(f.events)
+	}
+	claimed := f.events[:limit]
+	f.events = f.events[limit:]
+	return claimed, nil
+}
+
+func (f *fakeStore) AckOutboxEvent(id int64) error {
+	f.acked = append(f.acked, id)
+	return nil
+}
+
+func (f *fakeStore) RetryOutboxEvent(id int64, nextAttemptAt time.Time, _ string) error {
+	f.retried[id] = nextAttemptAt
+	return nil
+}
+
+func (f *fakeStore) DeadLetterOutboxEvent(id int64, lastError string) error {
+	f.deadLetter[id] = lastError
+	return nil
+}
+
+// publisherFunc adapts a function to the Publisher interface.
+type publisherFunc func(ctx context.Context, event storage.OutboxEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, event storage.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+func TestRelay_ProcessBatch(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	config := Config{BatchSize: 10, Lease: time.Minute, MaxAttempts: 3, MinBackoff: time.Second, MaxBackoff: time.Minute}
+
+	store := newFakeStore(
+		storage.OutboxEvent{ID: 1, SwiftCode: "TESTPLPWXXX"},
+		storage.OutboxEvent{ID: 2, SwiftCode: "FAILPLPWXXX", Attempts: 0},
+		storage.OutboxEvent{ID: 3, SwiftCode: "FAILPLPWXXX", Attempts: 1},
+		storage.OutboxEvent{ID: 4, SwiftCode: "FAILPLPWXXX", Attempts: 2},
+	)
+
+	var published []int64
+	relay := NewRelay(store, publisherFunc(func(_ context.Context, event storage.OutboxEvent) error {
+		published = append(published, event.ID)
+		if event.SwiftCode == "FAILPLPWXXX" {
+			return errors.New("receiver unavailable")
+		}
+		return nil
+	}), config)
+	relay.now = func() time.Time { return now }
+
+	processed, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, processed)
+	assert.Equal(t, []int64{1, 2, 3, 4}, published)
+	assert.Equal(t, []int64{1}, store.acked)
+	assert.Equal(t, map[int64]time.Time{
+		2: now.Add(time.Second),
+		3: now.Add(2 * time.Second),
+	}, store.retried)
+	assert.Equal(t, map[int64]string{4: "receiver unavailable"}, store.deadLetter)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Backoff(tt.attempts, time.Second, 10*time.Second), "attempts %d", tt.attempts)
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	store := newFakeStore(
+		storage.OutboxEvent{ID: 1},
+		storage.OutboxEvent{ID: 2},
+		storage.OutboxEvent{ID: 3},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	relay := NewRelay(store, publisherFunc(func(_ context.Context, event storage.OutboxEvent) error {
+		if event.ID == 3 {
+			cancel()
+		}
+		return nil
+	}), Config{BatchSize: 2, PollInterval: time.Hour, Lease: time.Minute, MaxAttempts: 1})
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not stop")
+	}
+	assert.Equal(t, []int64{1, 2, 3}, store.acked)
+}
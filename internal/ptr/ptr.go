@@ -0,0 +1,22 @@
+// Package ptr reads the optional fields of records, which are pointers so that a missing field can be
+// told apart from an empty one.
+package ptr
+
+import "strconv"
+
+// Value returns the value p points to, or the zero value if p is nil.
+func Value[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// FormatBool formats the bool p points to, or returns an empty string if p is nil.
+func FormatBool(p *bool) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatBool(*p)
+}
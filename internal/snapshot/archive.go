@@ -0,0 +1,185 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"sort"
+	"time"
+)
+
+// Format identifies snapshot archives in their manifest.
+const Format = "bic-data-service/snapshot"
+
+// Version is the version of the archive layout written by Write. Read accepts archives up to this version.
+const Version = 1
+
+const (
+	manifestFile = "manifest.json"
+	recordsFile  = "banks.jsonl"
+)
+
+var ErrChecksumMismatch = errors.New("snapshot records do not match the checksum of the manifest")
+
+// Manifest describes the records of a snapshot archive.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Backend is the kind of storage the records were taken from, e.g. postgres or sqlite.
+	Backend string `json:"backend,omitempty"`
+	Records int    `json:"records"`
+	// SHA256 is the hex-encoded checksum of the records file.
+	SHA256 string `json:"sha256"`
+}
+
+// record is a bank as stored in the archive, one JSON object per line.
+type record struct {
+	SwiftCode     string `json:"swiftCode"`
+	BankName      string `json:"bankName"`
+	Address       string `json:"address"`
+	CountryISO2   string `json:"countryISO2"`
+	CountryName   string `json:"countryName"`
+	IsHeadquarter bool   `json:"isHeadquarter"`
+}
+
+// Write writes a gzip-compressed tar archive with the manifest followed by the banks ordered by
+// SWIFT code. The format, version, number of records and checksum of the manifest are filled in.
+func Write(w io.Writer, banks []storage.Bank, manifest Manifest) error {
+	banks = append([]storage.Bank(nil), banks...)
+	sort.Slice(banks, func(i, j int) bool { return *banks[i].SwiftCode < *banks[j].SwiftCode })
+
+	var records bytes.Buffer
+	encoder := json.NewEncoder(&records)
+	for _, b := range banks {
+		err := encoder.Encode(record{
+			SwiftCode:     ptr.Value(b.SwiftCode),
+			BankName:      ptr.Value(b.BankName),
+			Address:       ptr.Value(b.Address),
+			CountryISO2:   ptr.Value(b.CountryISO2),
+			CountryName:   ptr.Value(b.CountryName),
+			IsHeadquarter: b.IsHeadquarter != nil && *b.IsHeadquarter,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	checksum := sha256.Sum256(records.Bytes())
+	manifest.Format = Format
+	manifest.Version = Version
+	manifest.Records = len(banks)
+	manifest.SHA256 = hex.EncodeToString(checksum[:])
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	compressed := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressed)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{manifestFile, append(manifestJSON, '\n')},
+		{recordsFile, records.Bytes()},
+	} {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), ModTime: manifest.CreatedAt}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// Read reads an archive written by Write. It fails if the archive is of an unknown format or a newer
+// version, or if the records do not match the manifest.
+func Read(r io.Reader) (Manifest, []storage.Bank, error) {
+	var manifest Manifest
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("snapshot is not a gzip archive: %w", err)
+	}
+	defer compressed.Close()
+
+	archive := tar.NewReader(compressed)
+	header, err := archive.Next()
+	if err != nil {
+		return manifest, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if header.Name != manifestFile {
+		return manifest, nil, fmt.Errorf("snapshot must start with %s, found %s", manifestFile, header.Name)
+	}
+	if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return manifest, nil, fmt.Errorf("invalid %s: %w", manifestFile, err)
+	}
+	if manifest.Format != Format {
+		return manifest, nil, fmt.Errorf("not a snapshot archive, format is %q", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return manifest, nil, fmt.Errorf("unsupported snapshot version %d, at most %d is supported", manifest.Version, Version)
+	}
+
+	header, err = archive.Next()
+	if errors.Is(err, io.EOF) {
+		return manifest, nil, fmt.Errorf("snapshot has no %s", recordsFile)
+	} else if err != nil {
+		return manifest, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if header.Name != recordsFile {
+		return manifest, nil, fmt.Errorf("snapshot must contain %s after %s, found %s", recordsFile, manifestFile, header.Name)
+	}
+
+	hash := sha256.New()
+	decoder := json.NewDecoder(io.TeeReader(archive, hash))
+	decoder.DisallowUnknownFields()
+	var banks []storage.Bank
+	for line := 1; ; line++ {
+		var rec record
+		err := decoder.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return manifest, nil, fmt.Errorf("invalid record %d of %s: %w", line, recordsFile, err)
+		}
+		banks = append(banks, rec.bank())
+	}
+	// the decoder may stop before the end of the file, e.g. at trailing whitespace
+	if _, err := io.Copy(hash, archive); err != nil {
+		return manifest, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	if hex.EncodeToString(hash.Sum(nil)) != manifest.SHA256 {
+		return manifest, nil, ErrChecksumMismatch
+	}
+	if len(banks) != manifest.Records {
+		return manifest, nil, fmt.Errorf("snapshot has %d records, the manifest lists %d", len(banks), manifest.Records)
+	}
+	return manifest, banks, nil
+}
+
+func (r record) bank() storage.Bank {
+	return storage.Bank{
+		SwiftCode:     &r.SwiftCode,
+		BankName:      &r.BankName,
+		Address:       &r.Address,
+		CountryISO2:   &r.CountryISO2,
+		CountryName:   &r.CountryName,
+		IsHeadquarter: &r.IsHeadquarter,
+	}
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/dirsync"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"strings"
+)
+
+// Mode tells what happens to the stored records which are not in the snapshot.
+type Mode string
+
+const (
+	// Replace removes them, the storage holds exactly the records of the snapshot afterwards.
+	Replace Mode = "replace"
+	// Merge keeps them, only the records of the snapshot are added or overwritten.
+	Merge Mode = "merge"
+)
+
+// Store is the part of a storage backend needed to create and restore snapshots. RelationalDB and
+// SQLiteDB implement it.
+type Store interface {
+	storage.Storage
+
+	dirsync.Store
+}
+
+// Plan computes the changes restoring the snapshot makes to the store. Every record is checked with
+// validate, and the restore is refused if any of them is invalid or if a SWIFT code appears twice.
+func Plan(store Store, banks []storage.Bank, mode Mode, validate func(storage.Bank) error) (dirsync.Diff, error) {
+	if mode != Replace && mode != Merge {
+		return dirsync.Diff{}, fmt.Errorf("unknown restore mode %q, use %s or %s", mode, Replace, Merge)
+	}
+
+	seen := make(map[string]bool, len(banks))
+	var invalid []string
+	for _, b := range banks {
+		if seen[*b.SwiftCode] {
+			invalid = append(invalid, fmt.Sprintf("%s: appears more than once", *b.SwiftCode))
+		}
+		seen[*b.SwiftCode] = true
+		if err := validate(b); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", *b.SwiftCode, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return dirsync.Diff{}, fmt.Errorf("snapshot has %d invalid record(s):\n%s", len(invalid), strings.Join(invalid, "\n"))
+	}
+
+	current, err := store.ListSwiftCodes()
+	if err != nil {
+		return dirsync.Diff{}, err
+	}
+	diff := dirsync.Compute(current, banks, nil)
+	if mode == Merge {
+		diff.Removed = nil
+	}
+	return diff, nil
+}
+
+// Restore applies the diff computed by Plan in a single transaction: if any record was modified since
+// it was planned, nothing is applied. source is recorded in the audit entries.
+func Restore(store Store, diff dirsync.Diff, source string) error {
+	if diff.IsEmpty() {
+		return nil
+	}
+	err := store.ApplyChanges(diff.Changes(), source)
+	if err != nil && errors.Is(err, storage.ErrConcurrentModification) {
+		return fmt.Errorf("the stored records changed while restoring, nothing was restored: %w", err)
+	}
+	return err
+}
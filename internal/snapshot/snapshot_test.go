@@ -0,0 +1,217 @@
+//go:build unit
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func bank(swiftCode, address string) storage.Bank {
+	bankName, iso2, country := "TEST BANK", swiftCode[4:6], "POLAND"
+	isHeadquarter := swiftCode[8:] == "XXX"
+	return storage.Bank{
+		Address:       &address,
+		BankName:      &bankName,
+		CountryISO2:   &iso2,
+		CountryName:   &country,
+		IsHeadquarter: &isHeadquarter,
+		SwiftCode:     &swiftCode,
+	}
+}
+
+func newTestStore(t *testing.T, banks ...storage.Bank) Store {
+	s, err := storage.NewSQLiteStorage("sqlite://" + filepath.Join(t.TempDir(), "bic.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Db.Close() })
+
+	db, err := s.Init()
+	require.NoError(t, err)
+	store := storage.NewSQLiteDB(db)
+	for _, b := range banks {
+		require.NoError(t, store.AddSwiftCodeEntry(b))
+	}
+	return store
+}
+
+// writeArchive writes an archive with the given files, to build archives Write would not produce.
+func writeArchive(t *testing.T, files map[string]string, order ...string) *bytes.Buffer {
+	var buf bytes.Buffer
+	compressed := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(compressed)
+	for _, name := range order {
+		require.NoError(t, archive.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}))
+		_, err := archive.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	require.NoError(t, compressed.Close())
+	return &buf
+}
+
+func TestWriteRead(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	banks := []storage.Bank{bank("TESTPL22ABC", "WARSAW"), bank("TESTPL22XXX", "WARSAW")}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []storage.Bank{banks[1], banks[0]}, Manifest{CreatedAt: createdAt, Backend: "sqlite"}))
+
+	manifest, read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, Format, manifest.Format)
+	assert.Equal(t, Version, manifest.Version)
+	assert.Equal(t, createdAt, manifest.CreatedAt)
+	assert.Equal(t, "sqlite", manifest.Backend)
+	assert.Equal(t, 2, manifest.Records)
+	assert.Len(t, manifest.SHA256, 64)
+	assert.Equal(t, banks, read)
+}
+
+func TestWriteRead_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, nil, Manifest{}))
+
+	manifest, read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Zero(t, manifest.Records)
+	assert.Empty(t, read)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	records := `{"swiftCode":"TESTPL22XXX","bankName":"TEST BANK","address":"WARSAW","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true}` + "\n"
+	// the checksum of records
+	var valid bytes.Buffer
+	require.NoError(t, Write(&valid, []storage.Bank{bank("TESTPL22XXX", "WARSAW")}, Manifest{}))
+	manifest, _, err := Read(bytes.NewReader(valid.Bytes()))
+	require.NoError(t, err)
+	checksum := manifest.SHA256
+
+	tests := []struct {
+		name    string
+		archive *bytes.Buffer
+		err     string
+	}{
+		{
+			name:    "not gzip",
+			archive: bytes.NewBufferString("swiftCode,bankName\n"),
+			err:     "not a gzip archive",
+		},
+		{
+			name: "manifest not first",
+			archive: writeArchive(t, map[string]string{recordsFile: records, manifestFile: "{}"},
+				recordsFile, manifestFile),
+			err: "must start with manifest.json",
+		},
+		{
+			name: "other format",
+			archive: writeArchive(t, map[string]string{manifestFile: `{"format":"other","version":1}`},
+				manifestFile),
+			err: "not a snapshot archive",
+		},
+		{
+			name: "newer version",
+			archive: writeArchive(t, map[string]string{manifestFile: `{"format":"bic-data-service/snapshot","version":2}`},
+				manifestFile),
+			err: "unsupported snapshot version 2",
+		},
+		{
+			name: "missing records",
+			archive: writeArchive(t, map[string]string{manifestFile: `{"format":"bic-data-service/snapshot","version":1}`},
+				manifestFile),
+			err: "snapshot has no banks.jsonl",
+		},
+		{
+			name: "checksum mismatch",
+			archive: writeArchive(t, map[string]string{
+				manifestFile: `{"format":"bic-data-service/snapshot","version":1,"records":1,"sha256":"` + checksum + `"}`,
+				recordsFile:  `{"swiftCode":"TESTPL22XXX","bankName":"OTHER BANK","address":"WARSAW","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true}` + "\n",
+			}, manifestFile, recordsFile),
+			err: ErrChecksumMismatch.Error(),
+		},
+		{
+			name: "record count mismatch",
+			archive: writeArchive(t, map[string]string{
+				manifestFile: `{"format":"bic-data-service/snapshot","version":1,"records":2,"sha256":"` + checksum + `"}`,
+				recordsFile:  records,
+			}, manifestFile, recordsFile),
+			err: "snapshot has 1 records, the manifest lists 2",
+		},
+		{
+			name: "unknown field",
+			archive: writeArchive(t, map[string]string{
+				manifestFile: `{"format":"bic-data-service/snapshot","version":1,"records":1}`,
+				recordsFile:  `{"swiftCode":"TESTPL22XXX","town":"WARSAW"}` + "\n",
+			}, manifestFile, recordsFile),
+			err: "invalid record 1 of banks.jsonl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Read(tt.archive)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	stored := []storage.Bank{bank("TESTPL22XXX", "WARSAW"), bank("TESTPL22ABC", "KRAKOW")}
+	snapshot := []storage.Bank{bank("TESTPL22XXX", "GDANSK"), bank("TESTPL33XXX", "POZNAN")}
+
+	t.Run("replace", func(t *testing.T) {
+		store := newTestStore(t, stored...)
+		diff, err := Plan(store, snapshot, Replace, app.ValidateBankData)
+		require.NoError(t, err)
+		assert.Len(t, diff.Added, 1)
+		assert.Len(t, diff.Modified, 1)
+		assert.Len(t, diff.Removed, 1)
+
+		require.NoError(t, Restore(store, diff, "snapshot:test"))
+		banks, err := store.ListSwiftCodes()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, snapshot, banks)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		store := newTestStore(t, stored...)
+		diff, err := Plan(store, snapshot, Merge, app.ValidateBankData)
+		require.NoError(t, err)
+		assert.Len(t, diff.Added, 1)
+		assert.Len(t, diff.Modified, 1)
+		assert.Empty(t, diff.Removed)
+
+		require.NoError(t, Restore(store, diff, "snapshot:test"))
+		banks, err := store.ListSwiftCodes()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, append(snapshot, stored[1]), banks)
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := Plan(newTestStore(t), snapshot, Mode("append"), app.ValidateBankData)
+		assert.ErrorContains(t, err, `unknown restore mode "append"`)
+	})
+
+	t.Run("invalid records", func(t *testing.T) {
+		store := newTestStore(t, stored...)
+		invalid := bank("TESTPL44ABC", "WARSAW")
+		*invalid.IsHeadquarter = true
+		_, err := Plan(store, []storage.Bank{invalid, snapshot[0], snapshot[0]}, Replace, app.ValidateBankData)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "snapshot has 2 invalid record(s)")
+		assert.Contains(t, err.Error(), "TESTPL44ABC: ")
+		assert.Contains(t, err.Error(), "TESTPL22XXX: appears more than once")
+
+		banks, err := store.ListSwiftCodes()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, stored, banks)
+	})
+}
@@ -0,0 +1,396 @@
+//go:build unit
+
+package storage
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// behaviourStorage is the part of a storage backend checked by testStorageBehaviour.
+type behaviourStorage interface {
+	Storage
+
+	ListSwiftCodes() ([]Bank, error)
+
+	ApplyChanges(changes []Change, source string) error
+
+	StatsProvider
+
+	CountryLister
+
+	InstitutionReader
+
+	CountryPager
+
+	SwiftCodePager
+}
+
+func newTestBank(swiftCode, address string) Bank {
+	return Bank{
+		Address:       strPtr(address),
+		BankName:      strPtr("TEST BANK"),
+		CountryISO2:   strPtr(swiftCode[4:6]),
+		CountryName:   strPtr("POLAND"),
+		IsHeadquarter: boolPtr(swiftCode[8:] == "XXX"),
+		SwiftCode:     strPtr(swiftCode),
+	}
+}
+
+// instant returns the current time between two pauses, so that it lies strictly between the changes
+// made before and after it.
+func instant() time.Time {
+	time.Sleep(10 * time.Millisecond)
+	defer time.Sleep(10 * time.Millisecond)
+	return time.Now()
+}
+
+// testStorageBehaviour checks the behaviour expected from every storage backend against an empty database.
+func testStorageBehaviour(t *testing.T, s behaviourStorage) {
+	beforeAdd := instant()
+	for _, b := range []Bank{newTestBank("TESTPL22XXX", "WARSAW"), newTestBank("TESTPL22KRK", "KRAKOW"), newTestBank("TESTDE11XXX", "BERLIN")} {
+		if err := s.AddSwiftCodeEntry(b); err != nil {
+			t.Fatalf("failed to add %s: %v", *b.SwiftCode, err)
+		}
+	}
+
+	t.Run("add existing", func(t *testing.T) {
+		err := s.AddSwiftCodeEntry(newTestBank("TESTPL22XXX", "GDANSK"))
+		if !errors.Is(err, ErrSwiftCodeExists) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeExists, err)
+		}
+	})
+
+	t.Run("headquarter with branches", func(t *testing.T) {
+		bank, err := s.GetSwiftCodeDetails("TESTPL22XXX")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *bank.Address != "WARSAW" || !*bank.IsHeadquarter || bank.Version != 1 {
+			t.Errorf("unexpected headquarter %+v", bank)
+		}
+		if len(bank.Branches) != 1 || bank.Branches[0].SwiftCode != "TESTPL22KRK" || bank.Branches[0].Version != 1 {
+			t.Errorf("expected the KRK branch, got %+v", bank.Branches)
+		}
+
+		branch, err := s.GetSwiftCodeDetails("TESTPL22KRK")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *branch.IsHeadquarter || branch.Branches != nil {
+			t.Errorf("unexpected branch %+v", branch)
+		}
+	})
+
+	t.Run("lookups are case-sensitive", func(t *testing.T) {
+		if _, err := s.GetSwiftCodeDetails("testpl22xxx"); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+	})
+
+	t.Run("country", func(t *testing.T) {
+		country, err := s.GetSwiftCodesForCountry("PL")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if country.CountryISO2 != "PL" || country.CountryName != "POLAND" || len(country.SwiftCodes) != 2 {
+			t.Errorf("unexpected country %+v", country)
+		}
+
+		if _, err := s.GetSwiftCodesForCountry("IT"); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+	})
+
+	t.Run("country pages", func(t *testing.T) {
+		page, err := s.GetSwiftCodesForCountryPage("PL", "", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if page.CountryName != "POLAND" || len(page.SwiftCodes) != 1 || page.SwiftCodes[0].SwiftCode != "TESTPL22KRK" {
+			t.Errorf("unexpected first page %+v", page)
+		}
+
+		page, err = s.GetSwiftCodesForCountryPage("PL", "TESTPL22KRK", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.SwiftCodes) != 1 || page.SwiftCodes[0].SwiftCode != "TESTPL22XXX" {
+			t.Errorf("unexpected second page %+v", page)
+		}
+
+		page, err = s.GetSwiftCodesForCountryPage("PL", "TESTPL22XXX", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if page.CountryName != "POLAND" || len(page.SwiftCodes) != 0 {
+			t.Errorf("expected an empty page of the country, got %+v", page)
+		}
+
+		if _, err := s.GetSwiftCodesForCountryPage("IT", "", 1); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+	})
+
+	t.Run("list pages", func(t *testing.T) {
+		pageCodes := func(iso2Code, after string) []string {
+			banks, err := s.ListSwiftCodesPage(iso2Code, after, 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			swiftCodes := []string{}
+			for _, bank := range banks {
+				swiftCodes = append(swiftCodes, *bank.SwiftCode)
+			}
+			return swiftCodes
+		}
+
+		if codes := pageCodes("", ""); !slices.Equal(codes, []string{"TESTDE11XXX", "TESTPL22KRK"}) {
+			t.Errorf("unexpected first page %v", codes)
+		}
+		if codes := pageCodes("", "TESTPL22KRK"); !slices.Equal(codes, []string{"TESTPL22XXX"}) {
+			t.Errorf("unexpected second page %v", codes)
+		}
+		if codes := pageCodes("", "TESTPL22XXX"); len(codes) != 0 {
+			t.Errorf("expected an empty page, got %v", codes)
+		}
+		if codes := pageCodes("PL", "TESTPL22KRK"); !slices.Equal(codes, []string{"TESTPL22XXX"}) {
+			t.Errorf("unexpected page of the country %v", codes)
+		}
+		if codes := pageCodes("IT", ""); len(codes) != 0 {
+			t.Errorf("expected an empty page of an unknown country, got %v", codes)
+		}
+	})
+
+	beforeUpdate := instant()
+
+	t.Run("update", func(t *testing.T) {
+		updated := newTestBank("TESTPL22XXX", "GDANSK")
+		if err := s.UpdateSwiftCodeEntry(updated, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := s.UpdateSwiftCodeEntry(updated, 1); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("expected %v, got %v", ErrVersionMismatch, err)
+		}
+		if err := s.UpdateSwiftCodeEntry(newTestBank("TESTPL22ABC", "LODZ"), 1); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+
+		bank, err := s.GetSwiftCodeDetails("TESTPL22XXX")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *bank.Address != "GDANSK" || bank.Version != 2 {
+			t.Errorf("expected the update to be stored, got %+v", bank)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := s.DeleteSwiftCodeEntry("TESTPL22KRK", 2); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("expected %v, got %v", ErrVersionMismatch, err)
+		}
+		if err := s.DeleteSwiftCodeEntry("TESTPL22KRK", 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := s.DeleteSwiftCodeEntry("TESTPL22KRK", 1); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+		if _, err := s.GetSwiftCodeDetails("TESTPL22KRK"); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+	})
+
+	t.Run("as of", func(t *testing.T) {
+		bank, err := s.GetSwiftCodeDetailsAsOf("TESTPL22XXX", beforeUpdate)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *bank.Address != "WARSAW" || bank.Version != 1 || bank.ValidFrom == nil || bank.ValidTo == nil {
+			t.Errorf("expected the first version, got %+v", bank)
+		}
+		if len(bank.Branches) != 1 {
+			t.Errorf("expected the branch deleted later, got %+v", bank.Branches)
+		}
+
+		bank, err = s.GetSwiftCodeDetailsAsOf("TESTPL22XXX", time.Now())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *bank.Address != "GDANSK" || bank.ValidTo != nil || len(bank.Branches) != 0 {
+			t.Errorf("expected the current version, got %+v", bank)
+		}
+
+		// times in other zones denote the same instants
+		if _, err := s.GetSwiftCodeDetailsAsOf("TESTPL22XXX", beforeAdd.In(time.FixedZone("UTC+14", 14*60*60))); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+
+		country, err := s.GetSwiftCodesForCountryAsOf("PL", beforeUpdate)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(country.SwiftCodes) != 2 {
+			t.Errorf("expected both records of the country, got %+v", country.SwiftCodes)
+		}
+		if _, err := s.GetSwiftCodesForCountryAsOf("PL", beforeAdd); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+
+		page, err := s.GetSwiftCodesForCountryPageAsOf("PL", "TESTPL22KRK", 10, beforeUpdate)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.SwiftCodes) != 1 || page.SwiftCodes[0].SwiftCode != "TESTPL22XXX" {
+			t.Errorf("expected the page to follow the deleted record, got %+v", page.SwiftCodes)
+		}
+		if _, err := s.GetSwiftCodesForCountryPageAsOf("PL", "", 10, beforeAdd); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+	})
+
+	t.Run("apply changes", func(t *testing.T) {
+		stale := newTestBank("TESTDE11XXX", "MUNICH")
+		added := newTestBank("TESTIT33XXX", "ROME")
+		err := s.ApplyChanges([]Change{
+			{Action: ChangeAdd, After: &added},
+			{Action: ChangeRemove, Before: &stale},
+		}, "test")
+		if !errors.Is(err, ErrConcurrentModification) {
+			t.Errorf("expected %v, got %v", ErrConcurrentModification, err)
+		}
+		if _, err := s.GetSwiftCodeDetails("TESTIT33XXX"); !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected the transaction to be rolled back, got %v", err)
+		}
+
+		current := newTestBank("TESTDE11XXX", "BERLIN")
+		err = s.ApplyChanges([]Change{
+			{Action: ChangeAdd, After: &added},
+			{Action: ChangeModify, Before: &current, After: &stale},
+		}, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		banks, err := s.ListSwiftCodes()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var codes, addresses []string
+		for _, b := range banks {
+			codes = append(codes, *b.SwiftCode)
+			addresses = append(addresses, *b.Address)
+		}
+		if len(banks) != 3 || codes[0] != "TESTDE11XXX" || codes[1] != "TESTIT33XXX" || codes[2] != "TESTPL22XXX" {
+			t.Errorf("expected the records ordered by SWIFT code, got %v", codes)
+		}
+		if len(banks) == 3 && addresses[0] != "MUNICH" {
+			t.Errorf("expected the modification to be applied, got %v", addresses)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		beforeStats := instant()
+		for _, b := range []Bank{newTestBank("TESTPL22WAW", "WARSAW"), newTestBank("TESTPL22GDA", "GDANSK"), newTestBank("TESTDE11MUC", "MUNICH")} {
+			if err := s.AddSwiftCodeEntry(b); err != nil {
+				t.Fatalf("failed to add %s: %v", *b.SwiftCode, err)
+			}
+		}
+		afterStats := instant()
+
+		stats, err := s.Stats()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.SwiftCodes != 6 || stats.Headquarters != 3 || stats.Branches != 3 || stats.Institutions != 3 {
+			t.Errorf("unexpected totals %+v", stats)
+		}
+		expected := []CountryStats{
+			{CountryISO2: "DE", CountryName: "POLAND", SwiftCodes: 2, Headquarters: 1, Branches: 1, Institutions: 1},
+			{CountryISO2: "IT", CountryName: "POLAND", SwiftCodes: 1, Headquarters: 1, Branches: 0, Institutions: 1},
+			{CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1},
+		}
+		if !slices.Equal(stats.Countries, expected) {
+			t.Errorf("expected %+v, got %+v", expected, stats.Countries)
+		}
+		countries, err := s.Countries()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(countries, expected) {
+			t.Errorf("expected %+v, got %+v", expected, countries)
+		}
+		for _, country := range expected {
+			counts, err := s.CountryCounts(country.CountryISO2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *counts != country {
+				t.Errorf("expected %+v, got %+v", country, *counts)
+			}
+		}
+		if _, err := s.CountryCounts("FR"); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+		networks := []BranchNetwork{
+			{Institution: "TESTPL22", BankName: "TEST BANK", CountryISO2: "PL", Branches: 2},
+			{Institution: "TESTDE11", BankName: "TEST BANK", CountryISO2: "DE", Branches: 1},
+		}
+		if !slices.Equal(stats.LargestBranchNetworks, networks) {
+			t.Errorf("expected %+v, got %+v", networks, stats.LargestBranchNetworks)
+		}
+		if stats.LastModified == nil || stats.LastModified.Before(beforeStats) || stats.LastModified.After(afterStats) {
+			t.Errorf("expected the last addition as the last modification, got %v", stats.LastModified)
+		}
+	})
+
+	t.Run("institutions", func(t *testing.T) {
+		if err := s.AddSwiftCodeEntry(newTestBank("TESTPL44WAW", "WARSAW")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		institution, err := s.GetInstitution("TESTPL22")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if institution.Headquarter == nil || institution.Headquarter.SwiftCode != "TESTPL22XXX" || institution.CountryName != "POLAND" {
+			t.Errorf("unexpected institution %+v", institution)
+		}
+		if len(institution.Branches) != 2 || institution.Branches[0].SwiftCode != "TESTPL22GDA" || institution.Branches[1].SwiftCode != "TESTPL22WAW" {
+			t.Errorf("expected the branches ordered by SWIFT code, got %+v", institution.Branches)
+		}
+
+		institution, err = s.GetInstitution("TESTPL44")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if institution.Headquarter != nil || institution.BankName != "TEST BANK" || len(institution.Branches) != 1 {
+			t.Errorf("expected the branch without its headquarter, got %+v", institution)
+		}
+
+		if _, err := s.GetInstitution("TESTFR11"); !errors.Is(err, ErrInstitutionNotFound) {
+			t.Errorf("expected %v, got %v", ErrInstitutionNotFound, err)
+		}
+
+		institutions, err := s.ListInstitutions("PL")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []InstitutionSummary{
+			{BIC8: "TESTPL22", BankName: "TEST BANK", CountryISO2: "PL", HasHeadquarter: true, Branches: 2},
+			{BIC8: "TESTPL44", BankName: "TEST BANK", CountryISO2: "PL", HasHeadquarter: false, Branches: 1},
+		}
+		if !slices.Equal(institutions, expected) {
+			t.Errorf("expected %+v, got %+v", expected, institutions)
+		}
+
+		institutions, err = s.ListInstitutions("")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(institutions) != 4 || institutions[0].BIC8 != "TESTDE11" {
+			t.Errorf("expected the institutions of every country, got %+v", institutions)
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"container/list"
+	"encoding/xml"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheConfig configures CachedStorage. Results are kept for TTL, "not found" answers for NegativeTTL.
+type CacheConfig struct {
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// CacheStats describes the effectiveness of a CachedStorage since it was created.
+type CacheStats struct {
+	XMLName   xml.Name `json:"-" xml:"cacheStats"`
+	Hits      uint64   `json:"hits" xml:"hits"`
+	Misses    uint64   `json:"misses" xml:"misses"`
+	Evictions uint64   `json:"evictions" xml:"evictions"`
+	Entries   int      `json:"entries" xml:"entries"`
+}
+
+func (s CacheStats) MarshalCSV() ([][]string, error) {
+	return [][]string{
+		{"hits", "misses", "evictions", "entries"},
+		{
+			strconv.FormatUint(s.Hits, 10),
+			strconv.FormatUint(s.Misses, 10),
+			strconv.FormatUint(s.Evictions, 10),
+			strconv.Itoa(s.Entries),
+		},
+	}, nil
+}
+
+// CachedStorage is a read-through cache in front of another Storage. Current state lookups are kept in
+// a bounded LRU; point-in-time queries always go to the wrapped storage, as they are rarely repeated.
+// Writes made through the cache invalidate every entry they may affect: the SWIFT code itself, its
+// headquarter group and the list of its country. Misses are read from the primary database of the
+// wrapped storage, as a read replica may still serve the data a write has just invalidated.
+type CachedStorage struct {
+	next    Storage
+	primary Storage
+	config  CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   CacheStats
+
+	// generation changes on every invalidation, so that a lookup which started before a write
+	// does not put the value it read into the cache after the write has invalidated it.
+	generation uint64
+}
+
+type cacheEntry struct {
+	key       string
+	value     any
+	err       error
+	expiresAt time.Time
+}
+
+func NewCachedStorage(next Storage, config CacheConfig) *CachedStorage {
+	return &CachedStorage{
+		next:    next,
+		primary: Primary(next),
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *CachedStorage) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
+	key := swiftCodeKey(swiftCode)
+	value, err, generation, ok := c.get(key)
+	if ok {
+		if err != nil {
+			return nil, err
+		}
+		return copyBank(value.(*Bank)), nil
+	}
+
+	bank, err := c.primary.GetSwiftCodeDetails(swiftCode)
+	if err != nil && errors.Is(err, ErrSwiftCodeNotFound) {
+		c.put(key, generation, nil, err)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.put(key, generation, copyBank(bank), nil)
+	return bank, nil
+}
+
+func (c *CachedStorage) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks, error) {
+	key := countryKey(iso2Code)
+	value, err, generation, ok := c.get(key)
+	if ok {
+		if err != nil {
+			return nil, err
+		}
+		return copyCountryBanks(value.(*CountryBanks)), nil
+	}
+
+	countryBanks, err := c.primary.GetSwiftCodesForCountry(iso2Code)
+	if err != nil && errors.Is(err, ErrISO2CodeNotFound) {
+		c.put(key, generation, nil, err)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.put(key, generation, copyCountryBanks(countryBanks), nil)
+	return countryBanks, nil
+}
+
+func (c *CachedStorage) GetSwiftCodeDetailsAsOf(swiftCode string, asOf time.Time) (*Bank, error) {
+	return c.next.GetSwiftCodeDetailsAsOf(swiftCode, asOf)
+}
+
+func (c *CachedStorage) GetSwiftCodesForCountryAsOf(iso2Code string, asOf time.Time) (*CountryBanks, error) {
+	return c.next.GetSwiftCodesForCountryAsOf(iso2Code, asOf)
+}
+
+func (c *CachedStorage) AddSwiftCodeEntry(b Bank) error {
+	defer c.invalidateBank(b)
+	return c.next.AddSwiftCodeEntry(b)
+}
+
+func (c *CachedStorage) UpdateSwiftCodeEntry(b Bank, version int64) error {
+	defer c.invalidateBank(b)
+	return c.next.UpdateSwiftCodeEntry(b, version)
+}
+
+func (c *CachedStorage) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
+	defer c.Invalidate(swiftCode, "")
+	return c.next.DeleteSwiftCodeEntry(swiftCode, version)
+}
+
+// Invalidate drops the cached entries affected by a change of swiftCode: the code itself, every code
+// of its headquarter group and the list of the country. iso2Code may be empty, the country is then
+// taken from the SWIFT code.
+func (c *CachedStorage) Invalidate(swiftCode, iso2Code string) {
+	swiftCode = strings.ToUpper(swiftCode)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.remove(swiftCodeKey(swiftCode))
+	if len(swiftCode) >= 8 {
+		prefix := swiftCodeKey(swiftCode[:8])
+		for key := range c.entries {
+			if strings.HasPrefix(key, prefix) {
+				c.remove(key)
+			}
+		}
+	}
+
+	if len(swiftCode) >= 6 {
+		c.remove(countryKey(swiftCode[4:6]))
+	}
+	if iso2Code != "" {
+		c.remove(countryKey(iso2Code))
+	}
+}
+
+// Purge drops all cached entries.
+func (c *CachedStorage) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *CachedStorage) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+func (c *CachedStorage) invalidateBank(b Bank) {
+	if b.SwiftCode == nil {
+		return
+	}
+	c.Invalidate(*b.SwiftCode, ptr.Value(b.CountryISO2))
+}
+
+// get returns the cached value or error for key. On a miss it returns the current generation,
+// which has to be passed to put.
+func (c *CachedStorage) get(key string) (any, error, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, nil, c.generation, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(key)
+		c.stats.Misses++
+		return nil, nil, c.generation, false
+	}
+
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+	return entry.value, entry.err, c.generation, true
+}
+
+func (c *CachedStorage) put(key string, generation uint64, value any, err error) {
+	ttl := c.config.TTL
+	if err != nil {
+		ttl = c.config.NegativeTTL
+	}
+	if c.config.Size <= 0 || ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	entry := &cacheEntry{key: key, value: value, err: err, expiresAt: time.Now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.config.Size {
+		oldest := c.lru.Back()
+		c.remove(oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// remove must be called with c.mu held.
+func (c *CachedStorage) remove(key string) {
+	if element, ok := c.entries[key]; ok {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+func swiftCodeKey(swiftCode string) string {
+	return "swift:" + strings.ToUpper(swiftCode)
+}
+
+func countryKey(iso2Code string) string {
+	return "country:" + strings.ToUpper(iso2Code)
+}
+
+// copyBank and copyCountryBanks copy the slices of a cached value, so that callers cannot modify the cache.
+func copyBank(b *Bank) *Bank {
+	copied := *b
+	copied.Branches = copyBranches(b.Branches)
+	return &copied
+}
+
+func copyCountryBanks(cb *CountryBanks) *CountryBanks {
+	copied := *cb
+	copied.SwiftCodes = copyBranches(cb.SwiftCodes)
+	return &copied
+}
+
+func copyBranches(branches []BankBranch) []BankBranch {
+	if branches == nil {
+		return nil
+	}
+	copied := make([]BankBranch, len(branches))
+	copy(copied, branches)
+	return copied
+}
+
+// ListSwiftCodes is not cached, it passes through to the wrapped storage if that can list its records.
+func (c *CachedStorage) ListSwiftCodes() ([]Bank, error) {
+	lister, ok := c.next.(interface{ ListSwiftCodes() ([]Bank, error) })
+	if !ok {
+		return nil, errors.New("Listing SWIFT codes is not supported by the storage")
+	}
+	return lister.ListSwiftCodes()
+}
+
+// ApplyChanges passes through to the wrapped storage if it can apply changes, and invalidates the
+// entries affected by each of them.
+func (c *CachedStorage) ApplyChanges(changes []Change, source string) error {
+	applier, ok := c.next.(ChangeApplier)
+	if !ok {
+		return errors.New("Applying changes is not supported by the storage")
+	}
+	defer func() {
+		for _, change := range changes {
+			c.Invalidate(change.SwiftCode(), change.CountryISO2())
+		}
+	}()
+	return applier.ApplyChanges(changes, source)
+}
+
+// Primary bypasses the cache, which may lag behind the latest writes, and reads from the primary
+// database of the wrapped storage.
+func (c *CachedStorage) Primary() Storage {
+	return Primary(c.next)
+}
@@ -0,0 +1,293 @@
+//go:build unit
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingStorage is a Storage that serves fixed data and counts the lookups reaching it.
+type countingStorage struct {
+	banks        map[string]*Bank
+	countries    map[string]*CountryBanks
+	detailsCalls int
+	countryCalls int
+	writeErr     error
+	lookupErr    error
+	beforeLookup func()
+}
+
+func (s *countingStorage) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
+	s.detailsCalls++
+	if s.beforeLookup != nil {
+		s.beforeLookup()
+	}
+	if s.lookupErr != nil {
+		return nil, s.lookupErr
+	}
+	if bank, ok := s.banks[swiftCode]; ok {
+		return bank, nil
+	}
+	return nil, ErrSwiftCodeNotFound
+}
+
+func (s *countingStorage) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks, error) {
+	s.countryCalls++
+	if countryBanks, ok := s.countries[iso2Code]; ok {
+		return countryBanks, nil
+	}
+	return nil, ErrISO2CodeNotFound
+}
+
+func (s *countingStorage) GetSwiftCodeDetailsAsOf(swiftCode string, _ time.Time) (*Bank, error) {
+	return s.GetSwiftCodeDetails(swiftCode)
+}
+
+func (s *countingStorage) GetSwiftCodesForCountryAsOf(iso2Code string, _ time.Time) (*CountryBanks, error) {
+	return s.GetSwiftCodesForCountry(iso2Code)
+}
+
+func (s *countingStorage) AddSwiftCodeEntry(_ Bank) error { return s.writeErr }
+
+func (s *countingStorage) UpdateSwiftCodeEntry(_ Bank, _ int64) error { return s.writeErr }
+
+func (s *countingStorage) DeleteSwiftCodeEntry(_ string, _ int64) error { return s.writeErr }
+
+func (s *countingStorage) ApplyChanges(_ []Change, _ string) error { return s.writeErr }
+
+func newCountingStorage() *countingStorage {
+	return &countingStorage{
+		banks: map[string]*Bank{
+			"TESTPLPWXXX": {
+				SwiftCode: strPtr("TESTPLPWXXX"),
+				BankName:  strPtr("Test Bank"),
+				Branches:  []BankBranch{{SwiftCode: "TESTPLPWABC", BankName: "Test Bank"}},
+			},
+			"TESTPLPWABC": {SwiftCode: strPtr("TESTPLPWABC"), BankName: strPtr("Test Bank")},
+			"OTHRPLPWXXX": {SwiftCode: strPtr("OTHRPLPWXXX"), BankName: strPtr("Other Bank")},
+		},
+		countries: map[string]*CountryBanks{
+			"PL": {CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: []BankBranch{{SwiftCode: "TESTPLPWXXX"}}},
+		},
+	}
+}
+
+var testCacheConfig = CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute}
+
+func TestCachedStorage_ReadThrough(t *testing.T) {
+	next := newCountingStorage()
+	cache := NewCachedStorage(next, testCacheConfig)
+
+	for i := 0; i < 3; i++ {
+		bank, err := cache.GetSwiftCodeDetails("TESTPLPWXXX")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *bank.BankName != "Test Bank" || len(bank.Branches) != 1 {
+			t.Errorf("unexpected bank %+v", bank)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetSwiftCodesForCountry("PL"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if next.detailsCalls != 1 || next.countryCalls != 1 {
+		t.Errorf("expected one lookup of each kind, got %d and %d", next.detailsCalls, next.countryCalls)
+	}
+
+	stats := cache.CacheStats()
+	if stats.Hits != 3 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCachedStorage_ReturnsCopies(t *testing.T) {
+	cache := NewCachedStorage(newCountingStorage(), testCacheConfig)
+
+	bank, _ := cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	bank.Branches[0].BankName = "Modified"
+
+	bank, _ = cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	if bank.Branches[0].BankName != "Test Bank" {
+		t.Errorf("cached value was modified by the caller")
+	}
+}
+
+func TestCachedStorage_NegativeCaching(t *testing.T) {
+	next := newCountingStorage()
+	cache := NewCachedStorage(next, testCacheConfig)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetSwiftCodeDetails("MISSPLPWXXX")
+		if !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected ErrSwiftCodeNotFound, got %v", err)
+		}
+	}
+	if next.detailsCalls != 1 {
+		t.Errorf("expected not found to be cached, got %d lookups", next.detailsCalls)
+	}
+
+	t.Run("other errors are not cached", func(t *testing.T) {
+		next := newCountingStorage()
+		next.lookupErr = errors.New("connection refused")
+		cache := NewCachedStorage(next, testCacheConfig)
+
+		cache.GetSwiftCodeDetails("TESTPLPWXXX")
+		cache.GetSwiftCodeDetails("TESTPLPWXXX")
+		if next.detailsCalls != 2 {
+			t.Errorf("expected 2 lookups, got %d", next.detailsCalls)
+		}
+	})
+
+	t.Run("negative caching disabled", func(t *testing.T) {
+		next := newCountingStorage()
+		cache := NewCachedStorage(next, CacheConfig{Size: 10, TTL: time.Minute})
+
+		cache.GetSwiftCodeDetails("MISSPLPWXXX")
+		cache.GetSwiftCodeDetails("MISSPLPWXXX")
+		if next.detailsCalls != 2 {
+			t.Errorf("expected 2 lookups, got %d", next.detailsCalls)
+		}
+	})
+}
+
+func TestCachedStorage_Expiry(t *testing.T) {
+	next := newCountingStorage()
+	cache := NewCachedStorage(next, CacheConfig{Size: 10, TTL: time.Millisecond})
+
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	time.Sleep(5 * time.Millisecond)
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+
+	if next.detailsCalls != 2 {
+		t.Errorf("expected expired entry to be reloaded, got %d lookups", next.detailsCalls)
+	}
+}
+
+func TestCachedStorage_Eviction(t *testing.T) {
+	next := newCountingStorage()
+	cache := NewCachedStorage(next, CacheConfig{Size: 2, TTL: time.Minute})
+
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	cache.GetSwiftCodeDetails("TESTPLPWABC")
+	cache.GetSwiftCodeDetails("TESTPLPWXXX") // TESTPLPWABC is now the least recently used
+	cache.GetSwiftCodeDetails("OTHRPLPWXXX")
+
+	next.detailsCalls = 0
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	cache.GetSwiftCodeDetails("TESTPLPWABC")
+
+	if next.detailsCalls != 1 {
+		t.Errorf("expected only the least recently used entry to be evicted, got %d lookups", next.detailsCalls)
+	}
+	if stats := cache.CacheStats(); stats.Evictions != 2 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCachedStorage_Invalidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *CachedStorage) error
+	}{
+		{
+			name: "add branch",
+			write: func(c *CachedStorage) error {
+				return c.AddSwiftCodeEntry(Bank{SwiftCode: strPtr("TESTPLPWDEF"), CountryISO2: strPtr("PL")})
+			},
+		},
+		{
+			name: "update branch",
+			write: func(c *CachedStorage) error {
+				return c.UpdateSwiftCodeEntry(Bank{SwiftCode: strPtr("TESTPLPWABC"), CountryISO2: strPtr("PL")}, 1)
+			},
+		},
+		{
+			name: "delete branch",
+			write: func(c *CachedStorage) error {
+				return c.DeleteSwiftCodeEntry("TESTPLPWABC", 1)
+			},
+		},
+		{
+			name: "apply changes",
+			write: func(c *CachedStorage) error {
+				return c.ApplyChanges([]Change{
+					{Action: ChangeAdd, After: &Bank{SwiftCode: strPtr("TESTPLPWDEF"), CountryISO2: strPtr("PL")}},
+				}, "test")
+			},
+		},
+		{
+			name: "failed write",
+			write: func(c *CachedStorage) error {
+				c.next.(*countingStorage).writeErr = ErrVersionMismatch
+				return c.DeleteSwiftCodeEntry("TESTPLPWABC", 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := newCountingStorage()
+			cache := NewCachedStorage(next, testCacheConfig)
+
+			cache.GetSwiftCodeDetails("TESTPLPWXXX")
+			cache.GetSwiftCodeDetails("TESTPLPWABC")
+			cache.GetSwiftCodeDetails("TESTPLPWDEF")
+			cache.GetSwiftCodeDetails("OTHRPLPWXXX")
+			cache.GetSwiftCodesForCountry("PL")
+
+			tt.write(cache)
+
+			next.detailsCalls, next.countryCalls = 0, 0
+			cache.GetSwiftCodeDetails("TESTPLPWXXX")
+			cache.GetSwiftCodeDetails("TESTPLPWABC")
+			cache.GetSwiftCodeDetails("TESTPLPWDEF")
+			cache.GetSwiftCodeDetails("OTHRPLPWXXX")
+			cache.GetSwiftCodesForCountry("PL")
+
+			if next.detailsCalls != 3 {
+				t.Errorf("expected the headquarter group to be reloaded, got %d lookups", next.detailsCalls)
+			}
+			if next.countryCalls != 1 {
+				t.Errorf("expected the country list to be reloaded, got %d lookups", next.countryCalls)
+			}
+		})
+	}
+}
+
+func TestCachedStorage_StaleLookupIsNotCached(t *testing.T) {
+	next := newCountingStorage()
+	cache := NewCachedStorage(next, testCacheConfig)
+
+	// a write completes while the lookup is in progress
+	next.beforeLookup = func() {
+		next.beforeLookup = nil
+		cache.DeleteSwiftCodeEntry("TESTPLPWXXX", 1)
+	}
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+	cache.GetSwiftCodeDetails("TESTPLPWXXX")
+
+	if next.detailsCalls != 2 {
+		t.Errorf("expected the value read before the write not to be cached, got %d lookups", next.detailsCalls)
+	}
+}
+
+func TestCachedStorage_MissesReadThePrimary(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, _ := newMockDB(t)
+	cache := NewCachedStorage(NewRelationalDB(primary, replica), testCacheConfig)
+
+	// the replica has not yet received the write which invalidated the entry, so no query may reach it
+	expectCountryQuery(primaryMock, "NEW NAME")
+	cache.Invalidate("BREXPLPWXXX", "PL")
+	if name := getBankName(t, cache); name != "NEW NAME" {
+		t.Errorf("expected the primary to fill the cache, got %q", name)
+	}
+	if name := getBankName(t, cache); name != "NEW NAME" {
+		t.Errorf("expected the cached value of the primary, got %q", name)
+	}
+}
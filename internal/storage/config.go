@@ -1,42 +1,114 @@
 package storage
 
 import (
-	"github.com/joho/godotenv"
-	"log"
+	"database/sql"
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
+// PostgresConfig describes how to connect to the PostgreSQL database.
 type PostgresConfig struct {
+	// URL is a full connection string, either a postgres:// URL or key=value pairs. When it is set,
+	// Host, Port, User, Password and Database are ignored.
+	URL string
+
 	Host     string
 	Port     string
-	DB_Port  string
 	User     string
 	Password string
 	Database string
+
+	// SSLMode is one of disable, require, verify-ca and verify-full. The certificates are paths to PEM
+	// files. Empty values are left to the URL or the driver's defaults (e.g. PGSSLMODE).
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// ConnectTimeout bounds how long NewPostgreSQLStorage retries to connect.
+	ConnectTimeout time.Duration
+
+	// Connection pool limits. Zero values keep the defaults of database/sql.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
-var Envs = initConfig()
+// ConnString returns the lib/pq connection string for the database described by the config.
+func (c PostgresConfig) ConnString() string {
+	tls := [][2]string{
+		{"sslmode", c.SSLMode},
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	}
 
-func initConfig() PostgresConfig {
-	err := godotenv.Load()
+	if c.URL == "" {
+		connString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
+			quote(c.Host), quote(c.Port), quote(c.User), quote(c.Password), quote(c.Database))
+		return connString + keywords(tls)
+	}
+
+	if !isURL(c.URL) {
+		// later keywords take precedence over earlier ones
+		return c.URL + keywords(tls)
+	}
+	u, err := url.Parse(c.URL)
 	if err != nil {
-		log.Println("No .env file found. Using system environment variables.")
-	} else {
-		err := godotenv.Overload()
-		if err != nil {
-			log.Println("Failed to load .env file")
+		// left to the driver to report
+		return c.URL
+	}
+	query := u.Query()
+	for _, option := range tls {
+		if option[1] != "" {
+			query.Set(option[0], option[1])
 		}
 	}
-	return PostgresConfig{
-		Port:     GetEnv("PORT", "8080"),
-		DB_Port:  GetEnv("DB_PORT", "5432"),
-		User:     GetEnv("DB_USER", "example_user"),
-		Password: GetEnv("DB_PASSWORD", "Passwd@1234"),
-		Host:     GetEnv("DB_HOST", "localhost"),
-		Database: GetEnv("DB_NAME", "bicdatabase"),
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// configurePool applies the connection pool limits to db.
+func (c PostgresConfig) configurePool(db *sql.DB) {
+	if c.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
 	}
 }
 
+func isURL(connString string) bool {
+	return strings.HasPrefix(connString, "postgres://") || strings.HasPrefix(connString, "postgresql://")
+}
+
+func keywords(options [][2]string) string {
+	var b strings.Builder
+	for _, option := range options {
+		if option[1] != "" {
+			fmt.Fprintf(&b, " %s=%s", option[0], quote(option[1]))
+		}
+	}
+	return b.String()
+}
+
+// quote quotes a value of a key=value connection string, so that it may contain spaces and quotes.
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
 func GetEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
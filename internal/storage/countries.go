@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+// CountryList lists the countries which have stored records.
+type CountryList struct {
+	XMLName   xml.Name       `json:"-" xml:"countries"`
+	Countries []CountryStats `json:"countries" xml:"country"`
+}
+
+// MarshalCSV renders one row per country.
+func (l CountryList) MarshalCSV() ([][]string, error) {
+	return countryStatsCSV(l.Countries), nil
+}
+
+// Country describes a country by its ISO 3166-1 codes, with the counts of its stored records. Name is
+// the ISO 3166 name of the country, CountryName its canonical name, which its records are stored with.
+type Country struct {
+	XMLName      xml.Name `json:"-" xml:"country"`
+	CountryISO2  string   `json:"countryISO2" xml:"countryISO2"`
+	CountryISO3  string   `json:"countryISO3" xml:"countryISO3"`
+	NumericCode  string   `json:"numericCode" xml:"numericCode"`
+	Name         string   `json:"name" xml:"name"`
+	CountryName  string   `json:"countryName" xml:"countryName"`
+	SwiftCodes   int      `json:"swiftCodes" xml:"swiftCodes"`
+	Headquarters int      `json:"headquarters" xml:"headquarters"`
+	Branches     int      `json:"branches" xml:"branches"`
+	Institutions int      `json:"institutions" xml:"institutions"`
+}
+
+func (c Country) MarshalCSV() ([][]string, error) {
+	return [][]string{
+		{"countryISO2", "countryISO3", "numericCode", "name", "countryName", "swiftCodes", "headquarters", "branches", "institutions"},
+		{
+			c.CountryISO2,
+			c.CountryISO3,
+			c.NumericCode,
+			c.Name,
+			c.CountryName,
+			strconv.Itoa(c.SwiftCodes),
+			strconv.Itoa(c.Headquarters),
+			strconv.Itoa(c.Branches),
+			strconv.Itoa(c.Institutions),
+		},
+	}, nil
+}
+
+// CountryLister is implemented by storages which can count the records of each country, such as
+// RelationalDB and SQLiteDB.
+type CountryLister interface {
+	Countries() ([]CountryStats, error)
+
+	// CountryCounts counts the records of one country, ErrISO2CodeNotFound is returned if it has none.
+	CountryCounts(iso2Code string) (*CountryStats, error)
+}
+
+// Countries counts the records of every country which has any, ordered by ISO2 code.
+func (r *RelationalDB) Countries() ([]CountryStats, error) {
+	var countries []CountryStats
+	err := r.read(func(db *sql.DB) (err error) {
+		countries, err = queryCountryStats(db)
+		return err
+	})
+	return countries, err
+}
+
+// Countries counts the records of every country which has any, ordered by ISO2 code.
+func (s *SQLiteDB) Countries() ([]CountryStats, error) {
+	countries, err := queryCountryStats(s.db)
+	return countries, translateError(err)
+}
+
+// Countries is not cached, it passes through to the wrapped storage if that can count its records.
+func (c *CachedStorage) Countries() ([]CountryStats, error) {
+	lister, ok := c.next.(CountryLister)
+	if !ok {
+		return nil, errors.New("Listing countries is not supported by the storage")
+	}
+	return lister.Countries()
+}
+
+// CountryCounts counts the records of one country.
+func (r *RelationalDB) CountryCounts(iso2Code string) (*CountryStats, error) {
+	var counts *CountryStats
+	err := r.read(func(db *sql.DB) (err error) {
+		counts, err = queryCountryCounts(db, iso2Code)
+		return err
+	})
+	return counts, err
+}
+
+// CountryCounts counts the records of one country.
+func (s *SQLiteDB) CountryCounts(iso2Code string) (*CountryStats, error) {
+	counts, err := queryCountryCounts(s.db, iso2Code)
+	return counts, translateError(err)
+}
+
+// CountryCounts is not cached, it passes through to the wrapped storage if that can count its records.
+func (c *CachedStorage) CountryCounts(iso2Code string) (*CountryStats, error) {
+	lister, ok := c.next.(CountryLister)
+	if !ok {
+		return nil, errors.New("Listing countries is not supported by the storage")
+	}
+	return lister.CountryCounts(iso2Code)
+}
+
+func queryCountryCounts(db *sql.DB, iso2Code string) (*CountryStats, error) {
+	var c CountryStats
+	err := db.QueryRow(`SELECT `+countryStatsColumns+`
+		FROM BanksData
+		WHERE countryISO2 = $1
+		GROUP BY countryISO2`, iso2Code).Scan(&c.CountryISO2, &c.CountryName, &c.SwiftCodes, &c.Headquarters, &c.Institutions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrISO2CodeNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	c.Branches = c.SwiftCodes - c.Headquarters
+	return &c, nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"net"
+	"strconv"
+)
+
+// Classes of database errors, which DBError matches with errors.Is.
+var ErrUniqueViolation = errors.New("Value already exists in database")
+var ErrCheckViolation = errors.New("Value is not allowed by database constraints")
+var ErrForeignKeyViolation = errors.New("Referenced record does not exist in database")
+var ErrSerializationFailure = errors.New("Transaction conflicted with a concurrent one")
+var ErrUnavailable = errors.New("Database is unavailable")
+
+// DBError is an error of the database driver classified by its code: the SQLSTATE code in PostgreSQL,
+// the extended result code in SQLite. errors.Is matches it with its Class, errors.As with the error of
+// the driver.
+type DBError struct {
+	Class error
+	Code  string
+	// Constraint is the name of the violated constraint, if the database reports it.
+	Constraint string
+	Err        error
+}
+
+func (e *DBError) Error() string {
+	if e.Constraint != "" {
+		return fmt.Sprintf("%v (%s): %v", e.Class, e.Constraint, e.Err)
+	}
+	return fmt.Sprintf("%v: %v", e.Class, e.Err)
+}
+
+func (e *DBError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// translateError wraps the errors of the database drivers in a DBError if they belong to one of
+// the classes above, and returns any other error unchanged.
+func translateError(err error) error {
+	var dbErr *DBError
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(err, &pqErr):
+		if class := pqErrorClass(pqErr.Code); class != nil {
+			return &DBError{Class: class, Code: string(pqErr.Code), Constraint: pqErr.Constraint, Err: err}
+		}
+	case errors.As(err, &sqliteErr):
+		if class := sqliteErrorClass(sqliteErr.Code()); class != nil {
+			return &DBError{Class: class, Code: strconv.Itoa(sqliteErr.Code()), Err: err}
+		}
+	}
+
+	if isConnectionError(err) {
+		return &DBError{Class: ErrUnavailable, Err: err}
+	}
+	return err
+}
+
+// pqErrorClass returns the class of a PostgreSQL error code, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+func pqErrorClass(code pq.ErrorCode) error {
+	switch code {
+	case "23505":
+		return ErrUniqueViolation
+	case "23514", "23502":
+		return ErrCheckViolation
+	case "23503":
+		return ErrForeignKeyViolation
+	case "40001", "40P01":
+		// a deadlock is resolved by aborting one of the transactions, which can be retried alike
+		return ErrSerializationFailure
+	case "57P01", "57P02", "57P03":
+		// a server which is shutting down or starting up
+		return ErrUnavailable
+	}
+	if code.Class() == "08" {
+		// connection exceptions
+		return ErrUnavailable
+	}
+	return nil
+}
+
+func sqliteErrorClass(code int) error {
+	switch code {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return ErrUniqueViolation
+	case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return ErrCheckViolation
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return ErrForeignKeyViolation
+	}
+
+	// the primary result code is the lowest byte of an extended one
+	switch code & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return ErrSerializationFailure
+	case sqlite3.SQLITE_CANTOPEN:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// isConnectionError tells whether err means that the database could not be reached, rather than
+// that the query failed.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &netErr):
+		return true
+	case errors.As(err, &pqErr):
+		return pqErrorClass(pqErr.Code) == ErrUnavailable
+	default:
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package storage
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		class      error
+		constraint string
+	}{
+		{name: "unique violation", err: &pq.Error{Code: "23505", Constraint: "banksdata_pkey", Message: "doppelter Schlüsselwert"},
+			class: ErrUniqueViolation, constraint: "banksdata_pkey"},
+		{name: "check violation", err: &pq.Error{Code: "23514"}, class: ErrCheckViolation},
+		{name: "not null violation", err: &pq.Error{Code: "23502"}, class: ErrCheckViolation},
+		{name: "foreign key violation", err: &pq.Error{Code: "23503"}, class: ErrForeignKeyViolation},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, class: ErrSerializationFailure},
+		{name: "deadlock", err: &pq.Error{Code: "40P01"}, class: ErrSerializationFailure},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, class: ErrUnavailable},
+		{name: "shutdown", err: &pq.Error{Code: "57P01"}, class: ErrUnavailable},
+		{name: "bad connection", err: driver.ErrBadConn, class: ErrUnavailable},
+		{name: "wrapped", err: fmt.Errorf("add TESTPL22XXX: %w", &pq.Error{Code: "23505"}), class: ErrUniqueViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err)
+			if !errors.Is(err, tt.class) {
+				t.Errorf("expected %v, got %v", tt.class, err)
+			}
+
+			var dbErr *DBError
+			if !errors.As(err, &dbErr) {
+				t.Fatalf("expected a DBError, got %T", err)
+			}
+			if dbErr.Constraint != tt.constraint {
+				t.Errorf("expected constraint %q, got %q", tt.constraint, dbErr.Constraint)
+			}
+			var pqErr *pq.Error
+			if errors.As(tt.err, &pqErr) && (!errors.As(err, &pqErr) || string(pqErr.Code) != dbErr.Code) {
+				t.Errorf("expected the driver error to be kept, got %v", err)
+			}
+			if translateError(err) != err {
+				t.Error("expected translated errors to be kept")
+			}
+		})
+	}
+
+	t.Run("other errors", func(t *testing.T) {
+		for _, err := range []error{nil, ErrSwiftCodeNotFound, &pq.Error{Code: "42P01"}} {
+			if translated := translateError(err); translated != err {
+				t.Errorf("expected %v to be kept, got %v", err, translated)
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IdempotencyRecord is the outcome of a request sent with an Idempotency-Key header.
+// StatusCode is 0 while the first request with the key is still being processed. Headers holds the
+// other response headers which are replayed with the body, such as ETag and Location.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Headers     map[string][]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey claims the key for a request with the given hash. It returns nil if the
+	// key was free (or expired) and the caller should process the request, or the existing record otherwise.
+	ReserveIdempotencyKey(key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error)
+
+	// CompleteIdempotencyKey stores the response of the request that reserved the key.
+	CompleteIdempotencyKey(key string, statusCode int, contentType string, headers map[string][]string, body []byte) error
+
+	// ReleaseIdempotencyKey forgets the key, so that the request can be retried.
+	ReleaseIdempotencyKey(key string) error
+}
+
+func (r *RelationalDB) ReserveIdempotencyKey(key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error) {
+	_, err := r.db.Exec(`DELETE FROM IdempotencyKeys WHERE expiresAt < now()`)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.Exec(`INSERT INTO IdempotencyKeys (key, requestHash, expiresAt)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`,
+		key, requestHash, time.Now().Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 1 {
+		return nil, nil
+	}
+
+	var record IdempotencyRecord
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	var headers []byte
+	err = r.db.QueryRow(`SELECT key, requestHash, statusCode, contentType, headers, body, expiresAt
+		FROM IdempotencyKeys
+		WHERE key = $1`, key).
+		Scan(&record.Key, &record.RequestHash, &statusCode, &contentType, &headers, &record.Body, &record.ExpiresAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		// released in the meantime, try to claim it again
+		return r.ReserveIdempotencyKey(key, requestHash, ttl)
+	} else if err != nil {
+		return nil, err
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+	if headers != nil {
+		if err := json.Unmarshal(headers, &record.Headers); err != nil {
+			return nil, err
+		}
+	}
+	return &record, nil
+}
+
+func (r *RelationalDB) CompleteIdempotencyKey(key string, statusCode int, contentType string, headers map[string][]string, body []byte) error {
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`UPDATE IdempotencyKeys
+		SET statusCode = $2, contentType = $3, headers = $4, body = $5
+		WHERE key = $1`,
+		key, statusCode, contentType, encodedHeaders, body)
+	return err
+}
+
+func (r *RelationalDB) ReleaseIdempotencyKey(key string) error {
+	_, err := r.db.Exec(`DELETE FROM IdempotencyKeys WHERE key = $1`, key)
+	return err
+}
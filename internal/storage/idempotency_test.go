@@ -0,0 +1,111 @@
+//go:build unit
+
+package storage
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectExec(`DELETE FROM IdempotencyKeys WHERE expiresAt < now\(\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO IdempotencyKeys \(key, requestHash, expiresAt\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(key\) DO NOTHING`).
+			WithArgs("key-1", "hash", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		record, err := storage.ReserveIdempotencyKey("key-1", "hash", time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record != nil {
+			t.Errorf("expected key to be reserved, got existing record %v", record)
+		}
+	})
+
+	t.Run("ExistingKey", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+		expiresAt := time.Now().Add(time.Hour)
+
+		mock.ExpectExec(`DELETE FROM IdempotencyKeys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO IdempotencyKeys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT key, requestHash, statusCode, contentType, headers, body, expiresAt FROM IdempotencyKeys WHERE key = \$1`).
+			WithArgs("key-1").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "requestHash", "statusCode", "contentType", "headers", "body", "expiresAt"}).
+				AddRow("key-1", "hash", 200, "application/json", []byte(`{"Etag":["\"1\""]}`), []byte(`{"message":"ok"}`), expiresAt))
+
+		record, err := storage.ReserveIdempotencyKey("key-1", "hash", time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record == nil || record.StatusCode != 200 || string(record.Body) != `{"message":"ok"}` {
+			t.Errorf("unexpected record %v", record)
+		}
+		if etag := record.Headers["Etag"]; len(etag) != 1 || etag[0] != `"1"` {
+			t.Errorf("expected the stored ETag, got %v", record.Headers)
+		}
+	})
+
+	t.Run("KeyInProgress", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectExec(`DELETE FROM IdempotencyKeys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO IdempotencyKeys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT key, requestHash, statusCode, contentType, headers, body, expiresAt FROM IdempotencyKeys`).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "requestHash", "statusCode", "contentType", "headers", "body", "expiresAt"}).
+				AddRow("key-1", "hash", nil, nil, nil, nil, time.Now()))
+
+		record, err := storage.ReserveIdempotencyKey("key-1", "hash", time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record == nil || record.StatusCode != 0 {
+			t.Errorf("expected pending record, got %v", record)
+		}
+	})
+}
+
+func TestCompleteIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+
+	mock.ExpectExec(`UPDATE IdempotencyKeys SET statusCode = \$2, contentType = \$3, headers = \$4, body = \$5 WHERE key = \$1`).
+		WithArgs("key-1", 200, "application/json", []byte(`{"Etag":["\"1\""]}`), []byte("{}")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := storage.CompleteIdempotencyKey("key-1", 200, "application/json",
+		map[string][]string{"Etag": {`"1"`}}, []byte("{}")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+var ErrInstitutionNotFound = errors.New("Institution with given BIC8 not found")
+
+// Institution is a headquarter and its branches, the SWIFT codes sharing their first eight characters
+// (the BIC8). Headquarter is nil if only branches of the institution are stored. BankName is the name
+// of the headquarter, or of one of the branches without it.
+type Institution struct {
+	XMLName     xml.Name     `json:"-" xml:"institution"`
+	BIC8        string       `json:"bic8" xml:"bic8"`
+	BankName    string       `json:"bankName" xml:"bankName"`
+	CountryISO2 string       `json:"countryISO2" xml:"countryISO2"`
+	CountryName string       `json:"countryName" xml:"countryName"`
+	Headquarter *BankBranch  `json:"headquarter" xml:"headquarter,omitempty"`
+	Branches    []BankBranch `json:"branches" xml:"branches>branch"`
+}
+
+// MarshalCSV renders one row per SWIFT code, the headquarter first.
+func (i Institution) MarshalCSV() ([][]string, error) {
+	records := [][]string{csvHeader}
+	if i.Headquarter != nil {
+		records = append(records, i.Headquarter.csvRecord(i.CountryName))
+	}
+	for _, branch := range i.Branches {
+		records = append(records, branch.csvRecord(i.CountryName))
+	}
+	return records, nil
+}
+
+// InstitutionSummary describes an institution by the number of its SWIFT codes.
+type InstitutionSummary struct {
+	BIC8           string `json:"bic8" xml:"bic8"`
+	BankName       string `json:"bankName" xml:"bankName"`
+	CountryISO2    string `json:"countryISO2" xml:"countryISO2"`
+	HasHeadquarter bool   `json:"hasHeadquarter" xml:"hasHeadquarter"`
+	Branches       int    `json:"branches" xml:"branches"`
+}
+
+type InstitutionList struct {
+	XMLName      xml.Name             `json:"-" xml:"institutions"`
+	Institutions []InstitutionSummary `json:"institutions" xml:"institution"`
+}
+
+func (l InstitutionList) MarshalCSV() ([][]string, error) {
+	records := [][]string{{"bic8", "bankName", "countryISO2", "hasHeadquarter", "branches"}}
+	for _, i := range l.Institutions {
+		records = append(records, []string{
+			i.BIC8,
+			i.BankName,
+			i.CountryISO2,
+			strconv.FormatBool(i.HasHeadquarter),
+			strconv.Itoa(i.Branches),
+		})
+	}
+	return records, nil
+}
+
+// InstitutionReader is implemented by storages which can group their records by BIC8, such as
+// RelationalDB and SQLiteDB.
+type InstitutionReader interface {
+	GetInstitution(bic8 string) (*Institution, error)
+
+	// ListInstitutions lists the institutions of a country ordered by BIC8, or of all countries if
+	// iso2Code is empty.
+	ListInstitutions(iso2Code string) ([]InstitutionSummary, error)
+}
+
+func (r *RelationalDB) GetInstitution(bic8 string) (*Institution, error) {
+	var institution *Institution
+	err := r.read(func(db *sql.DB) (err error) {
+		institution, err = getInstitution(db, bic8)
+		return err
+	})
+	return institution, err
+}
+
+func (r *RelationalDB) ListInstitutions(iso2Code string) ([]InstitutionSummary, error) {
+	var institutions []InstitutionSummary
+	err := r.read(func(db *sql.DB) (err error) {
+		institutions, err = listInstitutions(db, iso2Code)
+		return err
+	})
+	return institutions, err
+}
+
+func (s *SQLiteDB) GetInstitution(bic8 string) (*Institution, error) {
+	institution, err := getInstitution(s.db, bic8)
+	return institution, translateError(err)
+}
+
+func (s *SQLiteDB) ListInstitutions(iso2Code string) ([]InstitutionSummary, error) {
+	institutions, err := listInstitutions(s.db, iso2Code)
+	return institutions, translateError(err)
+}
+
+// GetInstitution is not cached, it passes through to the wrapped storage if that can group its records.
+func (c *CachedStorage) GetInstitution(bic8 string) (*Institution, error) {
+	reader, ok := c.next.(InstitutionReader)
+	if !ok {
+		return nil, errors.New("Institutions are not supported by the storage")
+	}
+	return reader.GetInstitution(bic8)
+}
+
+// ListInstitutions is not cached, it passes through to the wrapped storage if that can group its records.
+func (c *CachedStorage) ListInstitutions(iso2Code string) ([]InstitutionSummary, error) {
+	reader, ok := c.next.(InstitutionReader)
+	if !ok {
+		return nil, errors.New("Institutions are not supported by the storage")
+	}
+	return reader.ListInstitutions(iso2Code)
+}
+
+// getInstitution finds the SWIFT codes of the institution by prefix, which the trigram index of
+// swiftCode serves in PostgreSQL and its primary key in SQLite.
+func getInstitution(db *sql.DB, bic8 string) (*Institution, error) {
+	query := `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version
+		FROM BanksData
+		WHERE swiftCode LIKE $1
+		ORDER BY swiftCode`
+
+	rows, err := db.Query(query, bic8+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	institution := Institution{BIC8: bic8, Branches: []BankBranch{}}
+	found := false
+	for rows.Next() {
+		var b BankBranch
+		err := rows.Scan(&b.Address, &b.BankName, &b.CountryISO2, &institution.CountryName, &b.IsHeadquarter, &b.SwiftCode,
+			&b.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+		institution.CountryISO2 = b.CountryISO2
+		if b.IsHeadquarter {
+			institution.Headquarter = &b
+			institution.BankName = b.BankName
+			continue
+		}
+		if institution.Headquarter == nil && institution.BankName == "" {
+			institution.BankName = b.BankName
+		}
+		institution.Branches = append(institution.Branches, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrInstitutionNotFound
+	}
+	return &institution, nil
+}
+
+func listInstitutions(db *sql.DB, iso2Code string) ([]InstitutionSummary, error) {
+	where, args := "", []any{}
+	if iso2Code != "" {
+		where, args = "WHERE countryISO2 = $1", append(args, iso2Code)
+	}
+
+	rows, err := db.Query(`SELECT SUBSTR(swiftCode, 1, 8) AS bic8,
+			COALESCE(MAX(CASE WHEN isHeadquarter THEN bankName END), MAX(bankName)), MAX(countryISO2),
+			SUM(CASE WHEN isHeadquarter THEN 1 ELSE 0 END), SUM(CASE WHEN isHeadquarter THEN 0 ELSE 1 END)
+		FROM BanksData
+		`+where+`
+		GROUP BY SUBSTR(swiftCode, 1, 8)
+		ORDER BY bic8`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	institutions := []InstitutionSummary{}
+	for rows.Next() {
+		var i InstitutionSummary
+		var headquarters int
+		if err := rows.Scan(&i.BIC8, &i.BankName, &i.CountryISO2, &headquarters, &i.Branches); err != nil {
+			return nil, err
+		}
+		i.HasHeadquarter = headquarters > 0
+		institutions = append(institutions, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return institutions, nil
+}
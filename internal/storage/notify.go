@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	"time"
+)
+
+// ChangesChannel is the Postgres notification channel on which every write to BanksData is announced,
+// so that other replicas can drop their cached copies of the record.
+const ChangesChannel = "banks_changed"
+
+const (
+	listenerMinReconnectInterval = time.Second
+	listenerMaxReconnectInterval = time.Minute
+	listenerPingInterval         = 90 * time.Second
+)
+
+// ChangeNotification is the payload of a notification sent on ChangesChannel. EventID is the id of
+// the change in the outbox.
+type ChangeNotification struct {
+	EventID     int64        `json:"eventId,omitempty"`
+	Action      ChangeAction `json:"action,omitempty"`
+	SwiftCode   string       `json:"swiftCode"`
+	CountryISO2 string       `json:"countryISO2,omitempty"`
+	OccurredAt  time.Time    `json:"occurredAt"`
+}
+
+// ChangeHandler reacts to the changes announced on ChangesChannel.
+type ChangeHandler interface {
+	HandleChange(change ChangeNotification)
+
+	// HandleReconnect is called after the listener has reconnected. Changes made while it was
+	// disconnected are not announced.
+	HandleReconnect()
+}
+
+// CacheInvalidator is implemented by caches which can be kept consistent with the notifications,
+// such as CachedStorage.
+type CacheInvalidator interface {
+	Invalidate(swiftCode, iso2Code string)
+
+	Purge()
+}
+
+// InvalidateOnChange returns a ChangeHandler evicting the changed records from the cache. As changes
+// may be lost while reconnecting, the whole cache is purged after a reconnect.
+func InvalidateOnChange(cache CacheInvalidator) ChangeHandler {
+	return cacheChangeHandler{cache: cache}
+}
+
+type cacheChangeHandler struct {
+	cache CacheInvalidator
+}
+
+func (h cacheChangeHandler) HandleChange(change ChangeNotification) {
+	h.cache.Invalidate(change.SwiftCode, change.CountryISO2)
+}
+
+func (h cacheChangeHandler) HandleReconnect() {
+	h.cache.Purge()
+}
+
+// notifyChange announces a change on ChangesChannel. The notification is delivered only when the
+// transaction commits.
+func notifyChange(tx *sql.Tx, change ChangeNotification) error {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`SELECT pg_notify($1, $2)`, ChangesChannel, string(payload))
+	return err
+}
+
+// ListenForChanges passes the changes made by any replica to the handlers until ctx is done.
+// Lost connections are re-established with exponential backoff.
+func ListenForChanges(ctx context.Context, connStr string, handlers ...ChangeHandler) error {
+	listener := pq.NewListener(connStr, listenerMinReconnectInterval, listenerMaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventConnectionAttemptFailed:
+				log.Printf("Failed to connect the %s listener: %v", ChangesChannel, err)
+			case pq.ListenerEventDisconnected:
+				log.Printf("Lost the %s listener connection: %v", ChangesChannel, err)
+			case pq.ListenerEventReconnected:
+				log.Printf("Reconnected the %s listener", ChangesChannel)
+			}
+		})
+	defer listener.Close()
+
+	if err := listener.Listen(ChangesChannel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			handleChangeNotification(n, handlers)
+		case <-ticker.C:
+			// detects connections which were dropped without the listener noticing
+			go func() {
+				if err := listener.Ping(); err != nil {
+					log.Printf("Failed to ping the %s listener: %v", ChangesChannel, err)
+				}
+			}()
+		}
+	}
+}
+
+// handleChangeNotification passes the notification to the handlers. A nil notification is sent by the
+// listener after a reconnect. Invalid notifications are treated like a reconnect, as a change was missed.
+func handleChangeNotification(n *pq.Notification, handlers []ChangeHandler) {
+	var change ChangeNotification
+	if n == nil {
+		for _, h := range handlers {
+			h.HandleReconnect()
+		}
+		return
+	}
+
+	if err := json.Unmarshal([]byte(n.Extra), &change); err != nil || change.SwiftCode == "" {
+		log.Printf("Invalid %s notification %q", ChangesChannel, n.Extra)
+		for _, h := range handlers {
+			h.HandleReconnect()
+		}
+		return
+	}
+
+	for _, h := range handlers {
+		h.HandleChange(change)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build unit
+
+package storage
+
+import (
+	"github.com/lib/pq"
+	"testing"
+)
+
+type recordingInvalidator struct {
+	invalidated [][2]string
+	purged      int
+}
+
+func (r *recordingInvalidator) Invalidate(swiftCode, iso2Code string) {
+	r.invalidated = append(r.invalidated, [2]string{swiftCode, iso2Code})
+}
+
+func (r *recordingInvalidator) Purge() {
+	r.purged++
+}
+
+func TestHandleChangeNotification(t *testing.T) {
+	tests := []struct {
+		name                string
+		notification        *pq.Notification
+		expectedInvalidated [][2]string
+		expectedPurged      int
+	}{
+		{
+			name:                "change with country",
+			notification:        &pq.Notification{Channel: ChangesChannel, Extra: `{"swiftCode":"TESTPLPWXXX","countryISO2":"PL"}`},
+			expectedInvalidated: [][2]string{{"TESTPLPWXXX", "PL"}},
+		},
+		{
+			name:                "change without country",
+			notification:        &pq.Notification{Channel: ChangesChannel, Extra: `{"swiftCode":"TESTPLPWXXX"}`},
+			expectedInvalidated: [][2]string{{"TESTPLPWXXX", ""}},
+		},
+		{
+			name:           "reconnected",
+			notification:   nil,
+			expectedPurged: 1,
+		},
+		{
+			name:           "invalid payload",
+			notification:   &pq.Notification{Channel: ChangesChannel, Extra: `TESTPLPWXXX`},
+			expectedPurged: 1,
+		},
+		{
+			name:           "missing swift code",
+			notification:   &pq.Notification{Channel: ChangesChannel, Extra: `{}`},
+			expectedPurged: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &recordingInvalidator{}
+			handleChangeNotification(tt.notification, []ChangeHandler{InvalidateOnChange(cache)})
+
+			if len(cache.invalidated) != len(tt.expectedInvalidated) {
+				t.Fatalf("expected invalidations %v, got %v", tt.expectedInvalidated, cache.invalidated)
+			}
+			for i := range cache.invalidated {
+				if cache.invalidated[i] != tt.expectedInvalidated[i] {
+					t.Errorf("expected invalidations %v, got %v", tt.expectedInvalidated, cache.invalidated)
+				}
+			}
+			if cache.purged != tt.expectedPurged {
+				t.Errorf("expected %d purges, got %d", tt.expectedPurged, cache.purged)
+			}
+		})
+	}
+}
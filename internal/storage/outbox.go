@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// OutboxEvent is a change of a record waiting in the outbox to be published to downstream systems.
+// Data holds the record after the change, or the removed record if it is known; it is null otherwise.
+type OutboxEvent struct {
+	ID          int64           `json:"id"`
+	Action      ChangeAction    `json:"action"`
+	SwiftCode   string          `json:"swiftCode"`
+	CountryISO2 string          `json:"countryISO2"`
+	Data        json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	Attempts    int             `json:"-"`
+}
+
+// OutboxStore is the part of the storage backend used by the outbox relay. Events are claimed for
+// a lease; an event which is neither acknowledged nor rescheduled within the lease is claimed again,
+// so every event is delivered at least once.
+type OutboxStore interface {
+	ClaimOutboxEvents(limit int, lease time.Duration) ([]OutboxEvent, error)
+
+	// AckOutboxEvent removes a delivered event from the outbox.
+	AckOutboxEvent(id int64) error
+
+	// RetryOutboxEvent records a failed delivery and schedules the next attempt.
+	RetryOutboxEvent(id int64, nextAttemptAt time.Time, lastError string) error
+
+	// DeadLetterOutboxEvent moves an event which could not be delivered to the dead-letter table.
+	DeadLetterOutboxEvent(id int64, lastError string) error
+}
+
+// recordChange writes the change to the outbox and announces it on ChangesChannel, within the
+// transaction making it.
+func recordChange(tx *sql.Tx, action ChangeAction, swiftCode, iso2Code string, data *Bank) error {
+	payload, err := marshalNullable(data)
+	if err != nil {
+		return err
+	}
+
+	change := ChangeNotification{Action: action, SwiftCode: swiftCode, CountryISO2: iso2Code}
+	err = tx.QueryRow(`INSERT INTO Outbox (action, swiftCode, countryISO2, data)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, createdAt`,
+		string(action), swiftCode, iso2Code, payload).Scan(&change.EventID, &change.OccurredAt)
+	if err != nil {
+		return err
+	}
+
+	return notifyChange(tx, change)
+}
+
+func (r *RelationalDB) ClaimOutboxEvents(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	rows, err := r.db.Query(`UPDATE Outbox
+		SET nextAttemptAt = now() + $2 * interval '1 millisecond'
+		WHERE id IN (
+		    SELECT id FROM Outbox
+		    WHERE nextAttemptAt <= now()
+		    ORDER BY id
+		    LIMIT $1
+		    FOR UPDATE SKIP LOCKED)
+		RETURNING id, action, swiftCode, countryISO2, data, createdAt, attempts`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		var action string
+		var data []byte
+		err := rows.Scan(&event.ID, &action, &event.SwiftCode, &event.CountryISO2, &data, &event.OccurredAt, &event.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		event.Action = ChangeAction(action)
+		event.Data = nullableJSON(data)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (r *RelationalDB) AckOutboxEvent(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM Outbox WHERE id = $1`, id)
+	return err
+}
+
+func (r *RelationalDB) RetryOutboxEvent(id int64, nextAttemptAt time.Time, lastError string) error {
+	_, err := r.db.Exec(`UPDATE Outbox
+		SET attempts = attempts + 1, nextAttemptAt = $2, lastError = $3
+		WHERE id = $1`,
+		id, nextAttemptAt, lastError)
+	return err
+}
+
+func (r *RelationalDB) DeadLetterOutboxEvent(id int64, lastError string) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO OutboxDeadLetter (id, action, swiftCode, countryISO2, data, createdAt, attempts, lastError)
+			SELECT id, action, swiftCode, countryISO2, data, createdAt, attempts + 1, $2
+			FROM Outbox
+			WHERE id = $1`,
+			id, lastError)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM Outbox WHERE id = $1`, id)
+		return err
+	})
+}
+
+func nullableJSON(data []byte) json.RawMessage {
+	if data == nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
@@ -0,0 +1,70 @@
+//go:build unit
+
+package storage
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+func TestClaimOutboxEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE Outbox SET nextAttemptAt = now\(\) \+ \$2 \* interval '1 millisecond' WHERE id IN \(.+ FOR UPDATE SKIP LOCKED\) RETURNING`).
+		WithArgs(10, int64(60000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "swiftCode", "countryISO2", "data", "createdAt", "attempts"}).
+			AddRow(2, "remove", "TESTPL33ABC", "PL", nil, createdAt, 1).
+			AddRow(1, "add", "TESTPL33XXX", "PL", []byte(`{"swiftCode":"TESTPL33XXX"}`), createdAt, 0))
+
+	events, err := storage.ClaimOutboxEvents(10, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].ID != 1 || events[0].Action != ChangeAdd || string(events[0].Data) != `{"swiftCode":"TESTPL33XXX"}` {
+		t.Errorf("unexpected first event %+v", events[0])
+	}
+	if events[1].ID != 2 || events[1].Attempts != 1 || string(events[1].Data) != "null" {
+		t.Errorf("unexpected second event %+v", events[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeadLetterOutboxEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO OutboxDeadLetter .+ SELECT .+ FROM Outbox WHERE id = \$1`).
+		WithArgs(int64(3), "receiver unavailable").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM Outbox WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := storage.DeadLetterOutboxEvent(3, "receiver unavailable"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
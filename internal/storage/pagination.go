@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// CountryPager is implemented by storages which can read the SWIFT codes of a country page by page,
+// such as RelationalDB and SQLiteDB.
+type CountryPager interface {
+	// GetSwiftCodesForCountryPage returns at most limit SWIFT codes of a country which follow after
+	// in the order of SWIFT codes. The page is empty if after is the last one, ErrISO2CodeNotFound is
+	// returned if the country has no SWIFT codes at all.
+	GetSwiftCodesForCountryPage(iso2Code, after string, limit int) (*CountryBanks, error)
+
+	// GetSwiftCodesForCountryPageAsOf pages through the SWIFT codes which were valid at the given instant.
+	GetSwiftCodesForCountryPageAsOf(iso2Code, after string, limit int, asOf time.Time) (*CountryBanks, error)
+}
+
+// SwiftCodePager is implemented by storages which can read all their records page by page, such as
+// RelationalDB and SQLiteDB.
+type SwiftCodePager interface {
+	// ListSwiftCodesPage returns at most limit records which follow after in the order of SWIFT codes,
+	// without branch aggregation. Only the records of the country iso2Code are returned, unless it is
+	// empty. The page is empty once after is the last record.
+	ListSwiftCodesPage(iso2Code, after string, limit int) ([]Bank, error)
+}
+
+const (
+	listPageQuery = `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode
+		FROM BanksData
+		WHERE swiftCode > $1
+		ORDER BY swiftCode
+		LIMIT $2`
+	listCountryPageQuery = `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode
+		FROM BanksData
+		WHERE countryISO2 = $1 AND swiftCode > $2
+		ORDER BY swiftCode
+		LIMIT $3`
+)
+
+const (
+	countryPageQuery = `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode
+		FROM BanksData
+		WHERE countryISO2 = $1 AND swiftCode > $2
+		ORDER BY swiftCode
+		LIMIT $3`
+	countryNameQuery = `SELECT countryName FROM BanksData WHERE countryISO2 = $1 LIMIT 1`
+
+	countryPageAsOfQuery = `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode
+		FROM BanksDataHistory
+		WHERE countryISO2 = $1 AND swiftCode > $2 AND validFrom <= $4 AND (validTo IS NULL OR validTo > $4)
+		ORDER BY swiftCode
+		LIMIT $3`
+	countryNameAsOfQuery = `SELECT countryName FROM BanksDataHistory
+		WHERE countryISO2 = $1 AND validFrom <= $2 AND (validTo IS NULL OR validTo > $2)
+		LIMIT 1`
+)
+
+func (r *RelationalDB) GetSwiftCodesForCountryPage(iso2Code, after string, limit int) (*CountryBanks, error) {
+	var countryBanks *CountryBanks
+	err := r.read(func(db *sql.DB) (err error) {
+		countryBanks, err = queryCountryPage(db, countryPageQuery, countryNameQuery, iso2Code, after, limit)
+		return err
+	})
+	return countryBanks, err
+}
+
+func (r *RelationalDB) GetSwiftCodesForCountryPageAsOf(iso2Code, after string, limit int, asOf time.Time) (*CountryBanks, error) {
+	var countryBanks *CountryBanks
+	err := r.read(func(db *sql.DB) (err error) {
+		countryBanks, err = queryCountryPage(db, countryPageAsOfQuery, countryNameAsOfQuery, iso2Code, after, limit, asOf)
+		return err
+	})
+	return countryBanks, err
+}
+
+func (s *SQLiteDB) GetSwiftCodesForCountryPage(iso2Code, after string, limit int) (*CountryBanks, error) {
+	countryBanks, err := queryCountryPage(s.db, countryPageQuery, countryNameQuery, iso2Code, after, limit)
+	return countryBanks, translateError(err)
+}
+
+func (s *SQLiteDB) GetSwiftCodesForCountryPageAsOf(iso2Code, after string, limit int, asOf time.Time) (*CountryBanks, error) {
+	countryBanks, err := queryCountryPage(s.db, countryPageAsOfQuery, countryNameAsOfQuery, iso2Code, after, limit, asOf.UTC())
+	return countryBanks, translateError(err)
+}
+
+func (r *RelationalDB) ListSwiftCodesPage(iso2Code, after string, limit int) ([]Bank, error) {
+	var banks []Bank
+	err := r.read(func(db *sql.DB) (err error) {
+		banks, err = listSwiftCodesPage(db, iso2Code, after, limit)
+		return err
+	})
+	return banks, err
+}
+
+func (s *SQLiteDB) ListSwiftCodesPage(iso2Code, after string, limit int) ([]Bank, error) {
+	banks, err := listSwiftCodesPage(s.db, iso2Code, after, limit)
+	return banks, translateError(err)
+}
+
+func listSwiftCodesPage(db *sql.DB, iso2Code, after string, limit int) ([]Bank, error) {
+	if iso2Code == "" {
+		return queryBanks(db, listPageQuery, after, limit)
+	}
+	return queryBanks(db, listCountryPageQuery, iso2Code, after, limit)
+}
+
+// queryCountryPage reads a page with pageQuery. An empty page is told apart from an unknown country
+// with nameQuery, which also gives the name of the country.
+func queryCountryPage(db *sql.DB, pageQuery, nameQuery, iso2Code, after string, limit int, asOf ...any) (*CountryBanks, error) {
+	countryBanks, err := queryCountryBanks(db, pageQuery, append([]any{iso2Code, after, limit}, asOf...)...)
+	if !errors.Is(err, ErrISO2CodeNotFound) {
+		return countryBanks, err
+	}
+
+	countryBanks = &CountryBanks{CountryISO2: iso2Code, SwiftCodes: []BankBranch{}}
+	err = db.QueryRow(nameQuery, append([]any{iso2Code}, asOf...)...).Scan(&countryBanks.CountryName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrISO2CodeNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return countryBanks, nil
+}
+
+// GetSwiftCodesForCountryPage is not cached, it passes through to the wrapped storage if that can read pages.
+func (c *CachedStorage) GetSwiftCodesForCountryPage(iso2Code, after string, limit int) (*CountryBanks, error) {
+	pager, ok := c.next.(CountryPager)
+	if !ok {
+		return nil, errors.New("Paging through SWIFT codes is not supported by the storage")
+	}
+	return pager.GetSwiftCodesForCountryPage(iso2Code, after, limit)
+}
+
+func (c *CachedStorage) GetSwiftCodesForCountryPageAsOf(iso2Code, after string, limit int, asOf time.Time) (*CountryBanks, error) {
+	pager, ok := c.next.(CountryPager)
+	if !ok {
+		return nil, errors.New("Paging through SWIFT codes is not supported by the storage")
+	}
+	return pager.GetSwiftCodesForCountryPageAsOf(iso2Code, after, limit, asOf)
+}
+
+// ListSwiftCodesPage is not cached, it passes through to the wrapped storage if that can read pages.
+func (c *CachedStorage) ListSwiftCodesPage(iso2Code, after string, limit int) ([]Bank, error) {
+	pager, ok := c.next.(SwiftCodePager)
+	if !ok {
+		return nil, errors.New("Paging through SWIFT codes is not supported by the storage")
+	}
+	return pager.ListSwiftCodesPage(iso2Code, after, limit)
+}
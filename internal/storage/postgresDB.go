@@ -1,56 +1,91 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/lib/pq"
 	"log"
+	mathrand "math/rand/v2"
+	"time"
 )
 
 type PostgreSQLStorage struct {
 	Db *sql.DB
 }
 
-// Helper function that returns errors (for testing)
-func newPostgreSQLStorage(config PostgresConfig) (*PostgreSQLStorage, error) {
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s",
-		config.Host, config.DB_Port, config.User, config.Password, config.Database,
-	)
+// Bounds of the delay between attempts to connect to the database.
+const (
+	connectMinBackoff = 250 * time.Millisecond
+	connectMaxBackoff = 5 * time.Second
+)
 
-	db, err := sql.Open("postgres", connStr)
+// NewPostgreSQLStorage connects to the database described by config. While the database cannot be
+// reached, e.g. because it is still starting, connecting is retried with exponential backoff and
+// jitter for up to config.ConnectTimeout (a single attempt if it is 0). It gives up as soon as ctx is
+// done.
+func NewPostgreSQLStorage(ctx context.Context, config PostgresConfig) (*PostgreSQLStorage, error) {
+	db, err := OpenPostgreSQL(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
+		return nil, err
 	}
 
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+	pingCtx := ctx
+	if config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, config.ConnectTimeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(pingCtx)
+		if err == nil {
+			break
+		}
+
+		if ctx.Err() != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("connecting to database interrupted: %w", ctx.Err())
+		}
+		wait := connectBackoff(attempt)
+		if deadline, ok := pingCtx.Deadline(); config.ConnectTimeout <= 0 || !ok || time.Now().Add(wait).After(deadline) {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to ping database after %d attempt(s): %w", attempt, err)
+		}
+
+		log.Printf("Failed to ping database (attempt %d), retrying in %v: %v", attempt, wait.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
 	}
 
 	log.Println("Successfully connected to database")
 	return &PostgreSQLStorage{Db: db}, nil
 }
 
-// Public function that exits on error (for production)
-func NewPostgreSQLStorage(config PostgresConfig) (*PostgreSQLStorage, error) {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
-		config.Host, config.DB_Port, config.User, config.Password, config.Database)
-
-	db, err := sql.Open("postgres", connStr)
+// OpenPostgreSQL opens the database described by config without connecting to it, the connections are
+// made by the first queries. A read replica opened this way does not hold up startup while it cannot be
+// reached; RelationalDB ejects it on its first read instead.
+func OpenPostgreSQL(config PostgresConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", config.ConnString())
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("Failed to connect to database: %v", err))
-		return nil, err
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	config.configurePool(db)
+	return db, nil
+}
 
-	err = db.Ping()
-	if err != nil {
-		log.Fatalln(fmt.Sprintf("Failed to ping database: %v", err))
-		return nil, err
+// connectBackoff returns a random delay before the next attempt to connect, up to an exponentially
+// growing bound.
+func connectBackoff(attempt int) time.Duration {
+	bound := connectMaxBackoff
+	if attempt-1 < 32 {
+		if exp := connectMinBackoff << (attempt - 1); exp > 0 && exp < bound {
+			bound = exp
+		}
 	}
-
-	log.Println("Successfully connected to database")
-
-	return &PostgreSQLStorage{Db: db}, nil
+	return time.Duration(mathrand.Int64N(int64(bound) + 1))
 }
 
 func (s *PostgreSQLStorage) Init() (*sql.DB, error) {
@@ -61,6 +96,21 @@ func (s *PostgreSQLStorage) Init() (*sql.DB, error) {
 	if err := s.createBankTable(); err != nil {
 		return nil, err
 	}
+	if err := s.createAuditTable(); err != nil {
+		return nil, err
+	}
+	if err := s.createHistoryTable(); err != nil {
+		return nil, err
+	}
+	if err := s.createIdempotencyTable(); err != nil {
+		return nil, err
+	}
+	if err := s.createOutboxTables(); err != nil {
+		return nil, err
+	}
+	if err := s.createWebhookTables(); err != nil {
+		return nil, err
+	}
 	return s.Db, nil
 }
 
@@ -75,9 +125,148 @@ func (s *PostgreSQLStorage) createBankTable() error {
 			    swiftCode TEXT NOT NULL UNIQUE,
 			    PRIMARY KEY (swiftCode));
 
-			CREATE INDEX IF NOT EXISTS idx_countryISO2 ON BanksData (countryISO2);
+			ALTER TABLE BanksData ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+			CREATE INDEX IF NOT EXISTS idx_countryISO2_swiftCode ON BanksData (countryISO2, swiftCode);
+
+			DROP INDEX IF EXISTS idx_countryISO2;
 
 			CREATE INDEX IF NOT EXISTS idx_swiftCode_pattern ON BanksData USING gin (swiftCode gin_trgm_ops);
 `)
 	return err
 }
+
+func (s *PostgreSQLStorage) createAuditTable() error {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS BanksAudit (
+			    id BIGSERIAL PRIMARY KEY,
+			    swiftCode TEXT NOT NULL,
+			    action TEXT NOT NULL,
+			    oldData JSONB,
+			    newData JSONB,
+			    source TEXT NOT NULL,
+			    changedAt TIMESTAMPTZ NOT NULL DEFAULT now());
+
+			CREATE INDEX IF NOT EXISTS idx_audit_swiftCode ON BanksAudit (swiftCode);
+`)
+	return err
+}
+
+// createHistoryTable creates the table holding every version of every record. The current version
+// has no validTo. Records which were stored before the table existed get their first version here.
+func (s *PostgreSQLStorage) createHistoryTable() error {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS BanksDataHistory (
+			    address TEXT NOT NULL,
+			    bankName TEXT NOT NULL,
+			    isHeadquarter BOOLEAN NOT NULL,
+			    countryName TEXT NOT NULL,
+			    countryISO2 CHAR(2) NOT NULL,
+			    swiftCode TEXT NOT NULL,
+			    validFrom TIMESTAMPTZ NOT NULL,
+			    validTo TIMESTAMPTZ,
+			    PRIMARY KEY (swiftCode, validFrom));
+
+			ALTER TABLE BanksDataHistory ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+			CREATE INDEX IF NOT EXISTS idx_history_countryISO2 ON BanksDataHistory (countryISO2, validFrom);
+
+			CREATE INDEX IF NOT EXISTS idx_history_validFrom ON BanksDataHistory (validFrom);
+
+			CREATE INDEX IF NOT EXISTS idx_history_validTo ON BanksDataHistory (validTo) WHERE validTo IS NOT NULL;
+
+			CREATE INDEX IF NOT EXISTS idx_history_swiftCode_pattern ON BanksDataHistory USING gin (swiftCode gin_trgm_ops);
+
+			INSERT INTO BanksDataHistory (address, bankName, isHeadquarter, countryName, countryISO2, swiftCode, version, validFrom)
+			SELECT b.address, b.bankName, b.isHeadquarter, b.countryName, b.countryISO2, b.swiftCode, b.version, now()
+			FROM BanksData b
+			WHERE NOT EXISTS (
+			    SELECT 1 FROM BanksDataHistory h WHERE h.swiftCode = b.swiftCode AND h.validTo IS NULL);
+`)
+	return err
+}
+
+func (s *PostgreSQLStorage) createIdempotencyTable() error {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS IdempotencyKeys (
+			    key TEXT PRIMARY KEY,
+			    requestHash TEXT NOT NULL,
+			    statusCode INT,
+			    contentType TEXT,
+			    headers JSONB,
+			    body BYTEA,
+			    createdAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+			    expiresAt TIMESTAMPTZ NOT NULL);
+
+			ALTER TABLE IdempotencyKeys ADD COLUMN IF NOT EXISTS headers JSONB;
+
+			CREATE INDEX IF NOT EXISTS idx_idempotency_expiresAt ON IdempotencyKeys (expiresAt);
+`)
+	return err
+}
+
+// createOutboxTables creates the outbox of changes waiting to be published and the table of changes
+// which could not be published.
+func (s *PostgreSQLStorage) createOutboxTables() error {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS Outbox (
+			    id BIGSERIAL PRIMARY KEY,
+			    action TEXT NOT NULL,
+			    swiftCode TEXT NOT NULL,
+			    countryISO2 CHAR(2) NOT NULL,
+			    data JSONB,
+			    createdAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+			    attempts INT NOT NULL DEFAULT 0,
+			    nextAttemptAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+			    lastError TEXT);
+
+			CREATE INDEX IF NOT EXISTS idx_outbox_nextAttemptAt ON Outbox (nextAttemptAt);
+
+			CREATE TABLE IF NOT EXISTS OutboxDeadLetter (
+			    id BIGINT PRIMARY KEY,
+			    action TEXT NOT NULL,
+			    swiftCode TEXT NOT NULL,
+			    countryISO2 CHAR(2) NOT NULL,
+			    data JSONB,
+			    createdAt TIMESTAMPTZ NOT NULL,
+			    attempts INT NOT NULL,
+			    lastError TEXT,
+			    failedAt TIMESTAMPTZ NOT NULL DEFAULT now());
+`)
+	return err
+}
+
+// createWebhookTables creates the webhook subscriptions and the log of deliveries to them. Every event
+// is delivered to a subscription once, unless it is replayed.
+func (s *PostgreSQLStorage) createWebhookTables() error {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS WebhookSubscriptions (
+			    id BIGSERIAL PRIMARY KEY,
+			    url TEXT NOT NULL,
+			    countryISO2 CHAR(2),
+			    bicPrefix TEXT,
+			    secret TEXT NOT NULL,
+			    createdAt TIMESTAMPTZ NOT NULL DEFAULT now());
+
+			CREATE TABLE IF NOT EXISTS WebhookDeliveries (
+			    id BIGSERIAL PRIMARY KEY,
+			    subscriptionId BIGINT NOT NULL REFERENCES WebhookSubscriptions (id) ON DELETE CASCADE,
+			    eventId BIGINT NOT NULL,
+			    event JSONB NOT NULL,
+			    status TEXT NOT NULL DEFAULT 'pending',
+			    attempts INT NOT NULL DEFAULT 0,
+			    responseStatus INT,
+			    lastError TEXT,
+			    replayOf BIGINT,
+			    createdAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+			    nextAttemptAt TIMESTAMPTZ DEFAULT now(),
+			    deliveredAt TIMESTAMPTZ);
+
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+			    ON WebhookDeliveries (subscriptionId, eventId) WHERE replayOf IS NULL;
+
+			CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+			    ON WebhookDeliveries (nextAttemptAt) WHERE status = 'pending';
+`)
+	return err
+}
@@ -3,16 +3,18 @@
 package storage
 
 import (
+	"context"
 	_ "database/sql"
+	"errors"
 	_ "github.com/lib/pq"
-	"os"
-	"os/exec"
+	"strings"
 	"testing"
+	"time"
 )
 
 func getValidTestConfig() PostgresConfig {
 	return PostgresConfig{
-		DB_Port:  GetEnv("DB_PORT", "5432"),
+		Port:     GetEnv("DB_PORT", "5432"),
 		User:     GetEnv("DB_USER", "test_user"),
 		Password: GetEnv("DB_PASSWORD", "Test@1234"),
 		Host:     GetEnv("DB_HOST", "localhost"),
@@ -23,17 +25,26 @@ func getValidTestConfig() PostgresConfig {
 func getInvalidTestConfig() PostgresConfig {
 	return PostgresConfig{
 		Host:     "invalid-host",
-		DB_Port:  "1234",
+		Port:     "1234",
 		User:     "invalid-user",
 		Password: "wrong-password",
 		Database: "invalid-db",
 	}
 }
 
+// getUnreachableTestConfig describes a database which refuses connections.
+func getUnreachableTestConfig() PostgresConfig {
+	config := getInvalidTestConfig()
+	config.Host = "127.0.0.1"
+	config.Port = "1"
+	config.SSLMode = "disable"
+	return config
+}
+
 // TestNewPostgreSQLStorage_Success tests successful connection
 func TestNewPostgreSQLStorage_Success(t *testing.T) {
 	config := getValidTestConfig()
-	storage, err := newPostgreSQLStorage(config)
+	storage, err := NewPostgreSQLStorage(context.Background(), config)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -47,34 +58,53 @@ func TestNewPostgreSQLStorage_Success(t *testing.T) {
 // TestNewPostgreSQLStorage_InvalidConfig tests connection failure
 func TestNewPostgreSQLStorage_InvalidConfig(t *testing.T) {
 	config := getInvalidTestConfig()
-	_, err := newPostgreSQLStorage(config)
+	_, err := NewPostgreSQLStorage(context.Background(), config)
 	if err == nil {
 		t.Error("Expected error for invalid config, got nil")
 	}
 }
 
-// TestNewPostgreSQLStorage_FatalExit tests exit behavior (using a subprocess)
-func TestNewPostgreSQLStorage_FatalExit(t *testing.T) {
-	if os.Getenv("TEST_FATAL_EXIT") == "1" {
-		config := getInvalidTestConfig()
-		NewPostgreSQLStorage(config) // Should call log.Fatalf
-		return
+// TestNewPostgreSQLStorage_Retries tests that connecting is retried until the timeout
+func TestNewPostgreSQLStorage_Retries(t *testing.T) {
+	config := getUnreachableTestConfig()
+	config.ConnectTimeout = 1500 * time.Millisecond
+
+	start := time.Now()
+	_, err := NewPostgreSQLStorage(context.Background(), config)
+	if err == nil {
+		t.Fatal("Expected error for unreachable database, got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to ping database after") || strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Errorf("Expected several attempts, got: %v", err)
 	}
+	if elapsed := time.Since(start); elapsed > config.ConnectTimeout+time.Second {
+		t.Errorf("Retried for %v, longer than the timeout", elapsed)
+	}
+}
 
-	// Execute the test in a subprocess to avoid terminating the test runner
-	cmd := exec.Command(os.Args[0], "-test.run=TestNewPostgreSQLStorage_FatalExit")
-	cmd.Env = append(os.Environ(), "TEST_FATAL_EXIT=1")
-	err := cmd.Run()
+// TestNewPostgreSQLStorage_Canceled tests that retrying stops when the context is canceled
+func TestNewPostgreSQLStorage_Canceled(t *testing.T) {
+	config := getUnreachableTestConfig()
+	config.ConnectTimeout = time.Minute
 
-	if e, ok := err.(*exec.ExitError); !ok || e.Success() {
-		t.Fatal("Expected process to exit with error, got success")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(300*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := NewPostgreSQLStorage(ctx, config)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the context's error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Returned after %v, expected to stop with the context", elapsed)
 	}
 }
 
 // TestInit_CreatesSchema tests schema initialization (tables, indexes, extensions).
 func TestInit_CreatesSchema(t *testing.T) {
 	config := getValidTestConfig()
-	storage, err := NewPostgreSQLStorage(config)
+	storage, err := NewPostgreSQLStorage(context.Background(), config)
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -119,7 +149,7 @@ func TestInit_CreatesSchema(t *testing.T) {
 // TestInit_IsIdempotent tests that multiple Init calls don't cause errors.
 func TestInit_IsIdempotent(t *testing.T) {
 	config := getValidTestConfig()
-	storage, err := NewPostgreSQLStorage(config)
+	storage, err := NewPostgreSQLStorage(context.Background(), config)
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -144,7 +174,7 @@ func TestInit_IsIdempotent(t *testing.T) {
 // TestBanksData_UniqueSwiftCode tests the primary key/unique constraint on swiftCode.
 func TestBanksData_UniqueSwiftCode(t *testing.T) {
 	config := getValidTestConfig()
-	storage, err := NewPostgreSQLStorage(config)
+	storage, err := NewPostgreSQLStorage(context.Background(), config)
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
@@ -183,3 +213,27 @@ func TestBanksData_UniqueSwiftCode(t *testing.T) {
 		t.Error("Expected error for duplicate swiftCode, got nil")
 	}
 }
+
+// TestRelationalDB_Behaviour checks RelationalDB against the behaviour expected from every storage backend.
+func TestRelationalDB_Behaviour(t *testing.T) {
+	config := getValidTestConfig()
+	storage, err := NewPostgreSQLStorage(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer storage.Db.Close()
+
+	defer func() {
+		_, err := storage.Db.Exec("DROP TABLE IF EXISTS BanksData, BanksDataHistory, BanksAudit, Outbox CASCADE")
+		if err != nil {
+			t.Errorf("Failed to clean up tables: %v", err)
+		}
+	}()
+
+	db, err := storage.Init()
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	testStorageBehaviour(t, NewRelationalDB(db))
+}
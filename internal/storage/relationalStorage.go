@@ -2,27 +2,51 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"time"
 )
 
+// RelationalDB stores the data in PostgreSQL. Writes, and everything but the lookups of banks, use the
+// primary database db. Lookups are spread over the read replicas, if there are any.
 type RelationalDB struct {
-	db *sql.DB
+	db           *sql.DB
+	replicas     *replicaPool
+	primaryReads bool
 }
 
-func NewRelationalDB(db *sql.DB) *RelationalDB {
-	return &RelationalDB{db: db}
+func NewRelationalDB(db *sql.DB, replicas ...*sql.DB) *RelationalDB {
+	return &RelationalDB{db: db, replicas: newReplicaPool(replicas)}
+}
+
+// Primary returns a view of the storage which reads from the primary database.
+func (r *RelationalDB) Primary() Storage {
+	if r.replicas == nil || r.primaryReads {
+		return r
+	}
+	return &RelationalDB{db: r.db, replicas: r.replicas, primaryReads: true}
 }
 
 func (r *RelationalDB) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
-	query := `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode
+	var bank *Bank
+	err := r.read(func(db *sql.DB) (err error) {
+		bank, err = getSwiftCodeDetails(db, swiftCode)
+		return err
+	})
+	return bank, err
+}
+
+func getSwiftCodeDetails(db *sql.DB, swiftCode string) (*Bank, error) {
+	query := `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version
 		FROM BanksData
 		WHERE swiftCode = $1`
 
 	var bank Bank
-	err := r.db.QueryRow(query, swiftCode).
-		Scan(&bank.Address, &bank.BankName, &bank.CountryISO2, &bank.CountryName, &bank.IsHeadquarter, &bank.SwiftCode)
+	err := db.QueryRow(query, swiftCode).
+		Scan(&bank.Address, &bank.BankName, &bank.CountryISO2, &bank.CountryName, &bank.IsHeadquarter, &bank.SwiftCode,
+			&bank.Version)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrSwiftCodeNotFound
@@ -34,20 +58,80 @@ func (r *RelationalDB) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
 		return &bank, nil
 	}
 
-	query = `SELECT address, bankName, countryISO2, isHeadquarter, swiftCode
+	query = `SELECT address, bankName, countryISO2, isHeadquarter, swiftCode, version
 		FROM BanksData
 		WHERE swiftCode LIKE $1`
 
-	rows, err := r.db.Query(query, fmt.Sprintf("%s%%", swiftCode[:8]))
+	bank.Branches, err = queryBranches(db, query, fmt.Sprintf("%s%%", swiftCode[:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	return &bank, nil
+}
+
+// GetSwiftCodeDetailsAsOf returns the version of the record that was valid at the given instant.
+// Branches of a headquarter are also the ones that existed at that instant.
+func (r *RelationalDB) GetSwiftCodeDetailsAsOf(swiftCode string, asOf time.Time) (*Bank, error) {
+	var bank *Bank
+	err := r.read(func(db *sql.DB) (err error) {
+		bank, err = getSwiftCodeDetailsAsOf(db, swiftCode, asOf)
+		return err
+	})
+	return bank, err
+}
+
+func getSwiftCodeDetailsAsOf(db *sql.DB, swiftCode string, asOf time.Time) (*Bank, error) {
+	query := `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, validFrom, validTo
+		FROM BanksDataHistory
+		WHERE swiftCode = $1 AND validFrom <= $2 AND (validTo IS NULL OR validTo > $2)`
+
+	var bank Bank
+	var validFrom time.Time
+	var validTo sql.NullTime
+	err := db.QueryRow(query, swiftCode, asOf).
+		Scan(&bank.Address, &bank.BankName, &bank.CountryISO2, &bank.CountryName, &bank.IsHeadquarter, &bank.SwiftCode,
+			&bank.Version, &validFrom, &validTo)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSwiftCodeNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	bank.ValidFrom = &validFrom
+	if validTo.Valid {
+		bank.ValidTo = &validTo.Time
+	}
+
+	if !*bank.IsHeadquarter {
+		return &bank, nil
+	}
+
+	query = `SELECT address, bankName, countryISO2, isHeadquarter, swiftCode, version
+		FROM BanksDataHistory
+		WHERE swiftCode LIKE $1 AND validFrom <= $2 AND (validTo IS NULL OR validTo > $2)`
+
+	bank.Branches, err = queryBranches(db, query, fmt.Sprintf("%s%%", swiftCode[:8]), asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bank, nil
+}
+
+func queryBranches(db *sql.DB, query string, args ...any) ([]BankBranch, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var branches []BankBranch
 	for rows.Next() {
 		var b BankBranch
 
-		err := rows.Scan(&b.Address, &b.BankName, &b.CountryISO2, &b.IsHeadquarter, &b.SwiftCode)
+		err := rows.Scan(&b.Address, &b.BankName, &b.CountryISO2, &b.IsHeadquarter, &b.SwiftCode, &b.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -55,10 +139,10 @@ func (r *RelationalDB) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
 		if b.IsHeadquarter {
 			continue
 		}
-		bank.Branches = append(bank.Branches, b)
+		branches = append(branches, b)
 	}
 
-	return &bank, nil
+	return branches, rows.Err()
 }
 
 func (r *RelationalDB) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks, error) {
@@ -66,7 +150,29 @@ func (r *RelationalDB) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks,
 		FROM BanksData
 		WHERE countryISO2 = $1`
 
-	rows, err := r.db.Query(query, iso2Code)
+	return r.queryCountryBanks(query, iso2Code)
+}
+
+// GetSwiftCodesForCountryAsOf returns the SWIFT codes of a country as they were at the given instant.
+func (r *RelationalDB) GetSwiftCodesForCountryAsOf(iso2Code string, asOf time.Time) (*CountryBanks, error) {
+	query := `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode
+		FROM BanksDataHistory
+		WHERE countryISO2 = $1 AND validFrom <= $2 AND (validTo IS NULL OR validTo > $2)`
+
+	return r.queryCountryBanks(query, iso2Code, asOf)
+}
+
+func (r *RelationalDB) queryCountryBanks(query string, args ...any) (*CountryBanks, error) {
+	var countryBanks *CountryBanks
+	err := r.read(func(db *sql.DB) (err error) {
+		countryBanks, err = queryCountryBanks(db, query, args...)
+		return err
+	})
+	return countryBanks, err
+}
+
+func queryCountryBanks(db *sql.DB, query string, args ...any) (*CountryBanks, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -111,30 +217,264 @@ func (r *RelationalDB) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks,
 }
 
 func (r *RelationalDB) AddSwiftCodeEntry(b Bank) error {
-	query := `INSERT INTO BanksData (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+	return r.inTx(func(tx *sql.Tx) error {
+		return writeChange(tx, Change{Action: ChangeAdd, After: &b})
+	})
+}
 
-	_, err := r.db.Exec(query, b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter, b.SwiftCode)
+func (r *RelationalDB) UpdateSwiftCodeEntry(b Bank, version int64) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`UPDATE BanksData
+		SET address = $1, bankName = $2, countryISO2 = $3, countryName = $4, isHeadquarter = $5, version = version + 1
+		WHERE swiftCode = $6 AND version = $7`,
+			b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter, b.SwiftCode, version)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersionedWrite(tx, result, *b.SwiftCode); err != nil {
+			return err
+		}
 
+		if err := closeVersion(tx, *b.SwiftCode); err != nil {
+			return err
+		}
+		if err := openVersion(tx, *b.SwiftCode); err != nil {
+			return err
+		}
+
+		return recordChange(tx, ChangeModify, *b.SwiftCode, ptr.Value(b.CountryISO2), &b)
+	})
+}
+
+func (r *RelationalDB) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		var deleted Bank
+		err := tx.QueryRow(`DELETE FROM BanksData
+			WHERE swiftCode = $1 AND version = $2
+			RETURNING address, bankName, countryISO2, countryName, isHeadquarter, swiftCode`, swiftCode, version).
+			Scan(&deleted.Address, &deleted.BankName, &deleted.CountryISO2, &deleted.CountryName,
+				&deleted.IsHeadquarter, &deleted.SwiftCode)
+		if errors.Is(err, sql.ErrNoRows) {
+			return versionedWriteError(tx, swiftCode)
+		} else if err != nil {
+			return err
+		}
+
+		if err := closeVersion(tx, swiftCode); err != nil {
+			return err
+		}
+		// the removed record is the data of the event, as for the removals of ApplyChanges
+		return recordChange(tx, ChangeRemove, swiftCode, *deleted.CountryISO2, &deleted)
+	})
+}
+
+// checkVersionedWrite tells apart a missing record from a stale version when a write
+// conditioned on the record version did not affect any rows.
+func checkVersionedWrite(tx *sql.Tx, result sql.Result, swiftCode string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	return versionedWriteError(tx, swiftCode)
+}
+
+// versionedWriteError returns the error of a write conditioned on the record version which did not
+// affect any rows: ErrSwiftCodeNotFound if the record is missing, ErrVersionMismatch otherwise.
+func versionedWriteError(tx *sql.Tx, swiftCode string) error {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM BanksData WHERE swiftCode = $1)`, swiftCode).Scan(&exists)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+		return err
+	}
+	if !exists {
+		return ErrSwiftCodeNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// inTx runs fn in a transaction, which is committed only if fn succeeds.
+func (r *RelationalDB) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit())
+}
+
+// ListSwiftCodes returns every stored record ordered by SWIFT code, without branch aggregation.
+func (r *RelationalDB) ListSwiftCodes() ([]Bank, error) {
+	var banks []Bank
+	err := r.read(func(db *sql.DB) (err error) {
+		banks, err = listSwiftCodes(db)
+		return err
+	})
+	return banks, err
+}
+
+func listSwiftCodes(db *sql.DB) ([]Bank, error) {
+	return queryBanks(db, `SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode
+		FROM BanksData
+		ORDER BY swiftCode`)
+}
+
+// queryBanks reads the records selected by query, which has to return the columns of listSwiftCodes.
+func queryBanks(db *sql.DB, query string, args ...any) ([]Bank, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []Bank
+	for rows.Next() {
+		var bank Bank
+		err := rows.Scan(&bank.Address, &bank.BankName, &bank.CountryISO2, &bank.CountryName, &bank.IsHeadquarter, &bank.SwiftCode)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, bank)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return banks, nil
+}
+
+// ApplyChanges applies all changes in a single transaction and records an audit entry for each of them.
+// Modifications and removals only succeed if the stored record still matches Before,
+// otherwise the whole transaction is rolled back with ErrConcurrentModification.
+func (r *RelationalDB) ApplyChanges(changes []Change, source string) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		for _, c := range changes {
+			if err := writeChange(tx, c); err != nil {
+				return fmt.Errorf("%s %s: %w", c.Action, c.SwiftCode(), err)
+			}
+			if err := insertAuditEntry(tx, c, source); err != nil {
+				return fmt.Errorf("audit %s: %w", c.SwiftCode(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// writeChange applies the change to BanksData, keeps BanksDataHistory in step with it, records it
+// in the outbox and announces it.
+func writeChange(tx *sql.Tx, c Change) error {
+	if err := applyChange(tx, c); err != nil {
+		return err
+	}
+
+	if c.Action != ChangeAdd {
+		if err := closeVersion(tx, c.SwiftCode()); err != nil {
+			return err
+		}
+	}
+	if c.Action != ChangeRemove {
+		if err := openVersion(tx, c.SwiftCode()); err != nil {
+			return err
+		}
+	}
+
+	data := c.After
+	if c.Action == ChangeRemove {
+		data = c.Before
+	}
+	return recordChange(tx, c.Action, c.SwiftCode(), c.CountryISO2(), data)
+}
+
+// openVersion copies the current state of the record into BanksDataHistory.
+func openVersion(tx *sql.Tx, swiftCode string) error {
+	_, err := tx.Exec(`INSERT INTO BanksDataHistory (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, validFrom)
+		SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, now()
+		FROM BanksData
+		WHERE swiftCode = $1`, swiftCode)
+	return err
+}
+
+func closeVersion(tx *sql.Tx, swiftCode string) error {
+	_, err := tx.Exec(`UPDATE BanksDataHistory SET validTo = now() WHERE swiftCode = $1 AND validTo IS NULL`, swiftCode)
+	return err
+}
+
+func applyChange(tx *sql.Tx, c Change) error {
+	var result sql.Result
+	var err error
+
+	switch c.Action {
+	case ChangeAdd:
+		b := c.After
+		_, err = tx.Exec(`INSERT INTO BanksData (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+			b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter, b.SwiftCode)
+		if err := translateError(err); errors.Is(err, ErrUniqueViolation) {
 			return ErrSwiftCodeExists
 		}
 		return err
+	case ChangeModify:
+		a, b := c.After, c.Before
+		result, err = tx.Exec(`UPDATE BanksData
+		SET address = $1, bankName = $2, countryISO2 = $3, countryName = $4, isHeadquarter = $5, version = version + 1
+		WHERE swiftCode = $6 AND address = $7 AND bankName = $8 AND countryISO2 = $9 AND countryName = $10 AND isHeadquarter = $11`,
+			a.Address, a.BankName, a.CountryISO2, a.CountryName, a.IsHeadquarter,
+			b.SwiftCode, b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter)
+	case ChangeRemove:
+		b := c.Before
+		result, err = tx.Exec(`DELETE FROM BanksData
+		WHERE swiftCode = $1 AND address = $2 AND bankName = $3 AND countryISO2 = $4 AND countryName = $5 AND isHeadquarter = $6`,
+			b.SwiftCode, b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter)
+	default:
+		return fmt.Errorf("unknown change action %q", c.Action)
+	}
+
+	if err != nil {
+		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrConcurrentModification
+	}
 	return nil
 }
 
-func (r *RelationalDB) DeleteSwiftCodeEntry(swiftCode string) error {
-	query := `SELECT swiftCode FROM BanksData WHERE swiftCode = $1`
-	_, err := r.db.Exec(query, swiftCode)
-	if errors.Is(err, sql.ErrNoRows) {
-		return ErrSwiftCodeNotFound
+func insertAuditEntry(tx *sql.Tx, c Change, source string) error {
+	oldData, err := marshalNullable(c.Before)
+	if err != nil {
+		return err
+	}
+	newData, err := marshalNullable(c.After)
+	if err != nil {
+		return err
 	}
 
-	query = `DELETE FROM BanksData WHERE swiftCode = $1`
-	_, err = r.db.Exec(query, swiftCode)
-
+	_, err = tx.Exec(`INSERT INTO BanksAudit (swiftCode, action, oldData, newData, source)
+		VALUES ($1, $2, $3, $4, $5)`,
+		c.SwiftCode(), string(c.Action), oldData, newData, source)
 	return err
 }
+
+func marshalNullable(b *Bank) (any, error) {
+	if b == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -6,7 +6,9 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"testing"
+	"time"
 )
 
 func strPtr(s string) *string {
@@ -28,7 +30,7 @@ func TestGetSwiftCodeDetails(t *testing.T) {
 		storage := NewRelationalDB(db)
 		swiftCode := "INVALIDCODE"
 
-		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode FROM BanksData WHERE swiftCode = \$1`).
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version FROM BanksData WHERE swiftCode = \$1`).
 			WithArgs(swiftCode).
 			WillReturnError(sql.ErrNoRows)
 
@@ -51,10 +53,10 @@ func TestGetSwiftCodeDetails(t *testing.T) {
 		storage := NewRelationalDB(db)
 		swiftCode := "TESTPL33AAA"
 
-		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode FROM BanksData WHERE swiftCode = \$1`).
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version FROM BanksData WHERE swiftCode = \$1`).
 			WithArgs(swiftCode).
-			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode"}).
-				AddRow("Address", "Bank", "PL", "POLAND", false, swiftCode))
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode", "version"}).
+				AddRow("Address", "Bank", "PL", "POLAND", false, swiftCode, 1))
 
 		result, err := storage.GetSwiftCodeDetails(swiftCode)
 		if err != nil {
@@ -78,17 +80,17 @@ func TestGetSwiftCodeDetails(t *testing.T) {
 		storage := NewRelationalDB(db)
 		swiftCode := "TESTPL33XXX"
 
-		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode FROM BanksData WHERE swiftCode = \$1`).
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version FROM BanksData WHERE swiftCode = \$1`).
 			WithArgs(swiftCode).
-			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode"}).
-				AddRow("HQ Address", "HQ Bank", "PL", "POLAND", true, swiftCode))
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode", "version"}).
+				AddRow("HQ Address", "HQ Bank", "PL", "POLAND", true, swiftCode, 3))
 
 		likeParam := swiftCode[:8] + "%"
-		mock.ExpectQuery(`SELECT address, bankName, countryISO2, isHeadquarter, swiftCode FROM BanksData WHERE swiftCode LIKE \$1`).
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, isHeadquarter, swiftCode, version FROM BanksData WHERE swiftCode LIKE \$1`).
 			WithArgs(likeParam).
-			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "isHeadquarter", "swiftCode"}).
-				AddRow("Branch Address", "Branch Bank", "PL", false, "HEADQCODE123").
-				AddRow("Other Branch", "Other Bank", "PL", false, "HEADQCODE456"))
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "isHeadquarter", "swiftCode", "version"}).
+				AddRow("Branch Address", "Branch Bank", "PL", false, "HEADQCODE123", 1).
+				AddRow("Other Branch", "Other Bank", "PL", false, "HEADQCODE456", 2))
 
 		result, err := storage.GetSwiftCodeDetails(swiftCode)
 		if err != nil {
@@ -100,6 +102,9 @@ func TestGetSwiftCodeDetails(t *testing.T) {
 		if len(result.Branches) != 2 {
 			t.Errorf("expected 2 branches, got %d", len(result.Branches))
 		}
+		if result.Version != 3 || result.Branches[1].Version != 2 {
+			t.Errorf("expected versions to be read, got %d and %d", result.Version, result.Branches[1].Version)
+		}
 	})
 }
 
@@ -156,6 +161,91 @@ func TestGetSwiftCodesForCountry(t *testing.T) {
 	})
 }
 
+func TestGetSwiftCodeDetailsAsOf(t *testing.T) {
+	asOf := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	validFrom := asOf.Add(-24 * time.Hour)
+	validTo := asOf.Add(24 * time.Hour)
+
+	t.Run("NotValidAtInstant", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectQuery(`SELECT .+ FROM BanksDataHistory WHERE swiftCode = \$1 AND validFrom <= \$2 AND \(validTo IS NULL OR validTo > \$2\)`).
+			WithArgs("TESTPL33AAA", asOf).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = storage.GetSwiftCodeDetailsAsOf("TESTPL33AAA", asOf)
+		if !errors.Is(err, ErrSwiftCodeNotFound) {
+			t.Errorf("expected error %v, got %v", ErrSwiftCodeNotFound, err)
+		}
+	})
+
+	t.Run("HeadquarterWithBranchesAtInstant", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+		swiftCode := "TESTPL33XXX"
+
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, validFrom, validTo FROM BanksDataHistory`).
+			WithArgs(swiftCode, asOf).
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode", "version", "validFrom", "validTo"}).
+				AddRow("Old HQ Address", "HQ Bank", "PL", "POLAND", true, swiftCode, 1, validFrom, validTo))
+
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, isHeadquarter, swiftCode, version FROM BanksDataHistory WHERE swiftCode LIKE \$1 AND validFrom <= \$2`).
+			WithArgs("TESTPL33%", asOf).
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "isHeadquarter", "swiftCode", "version"}).
+				AddRow("Old HQ Address", "HQ Bank", "PL", true, swiftCode, 1).
+				AddRow("Closed Branch", "HQ Bank", "PL", false, "TESTPL33AAA", 1))
+
+		result, err := storage.GetSwiftCodeDetailsAsOf(swiftCode, asOf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *result.Address != "Old HQ Address" {
+			t.Errorf("expected historical address, got %s", *result.Address)
+		}
+		if !result.ValidFrom.Equal(validFrom) || result.ValidTo == nil || !result.ValidTo.Equal(validTo) {
+			t.Errorf("unexpected validity range %v - %v", result.ValidFrom, result.ValidTo)
+		}
+		if len(result.Branches) != 1 || result.Branches[0].SwiftCode != "TESTPL33AAA" {
+			t.Errorf("expected the branch existing at the instant, got %v", result.Branches)
+		}
+	})
+}
+
+func TestGetSwiftCodesForCountryAsOf(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+	asOf := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode FROM BanksDataHistory WHERE countryISO2 = \$1 AND validFrom <= \$2`).
+		WithArgs("PL", asOf).
+		WillReturnRows(sqlmock.NewRows([]string{"countryISO2", "countryName", "address", "bankName", "isHeadquarter", "swiftCode"}).
+			AddRow("PL", "POLAND", "Addr1", "Bank1", true, "TESTPL33XXX"))
+
+	result, err := storage.GetSwiftCodesForCountryAsOf("PL", asOf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.SwiftCodes) != 1 {
+		t.Errorf("expected 1 swift code, got %d", len(result.SwiftCodes))
+	}
+}
+
 func TestAddSwiftCodeEntry(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -174,15 +264,28 @@ func TestAddSwiftCodeEntry(t *testing.T) {
 			SwiftCode:     strPtr("TESTPL33XXX"),
 		}
 
+		mock.ExpectBegin()
 		mock.ExpectExec(`INSERT INTO BanksData \(address, bankName, countryISO2, countryName, isHeadquarter, swiftCode\) 
 VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
 			WithArgs(bank.Address, bank.BankName, bank.CountryISO2, bank.CountryName, bank.IsHeadquarter, bank.SwiftCode).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO BanksDataHistory .+ SELECT .+, version, now\(\) FROM BanksData WHERE swiftCode = \$1`).
+			WithArgs("TESTPL33XXX").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("add", "TESTPL33XXX", "PL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
 
 		err = storage.AddSwiftCodeEntry(bank)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
 	})
 
 	t.Run("DuplicateEntry", func(t *testing.T) {
@@ -195,14 +298,106 @@ VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
 		storage := NewRelationalDB(db)
 		bank := Bank{SwiftCode: strPtr("DUPLICATE")}
 
+		mock.ExpectBegin()
 		mock.ExpectExec(`INSERT INTO BanksData .+`).
-			WillReturnError(errors.New("duplicate key value violates unique constraint"))
+			WillReturnError(&pq.Error{Code: "23505", Message: "doppelter Schlüsselwert verletzt Unique-Constraint"})
+		mock.ExpectRollback()
 
 		err = storage.AddSwiftCodeEntry(bank)
 		if !errors.Is(err, ErrSwiftCodeExists) {
 			t.Errorf("expected error %v, got %v", ErrSwiftCodeExists, err)
 		}
 	})
+
+	t.Run("OtherConstraintViolation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+		bank := Bank{SwiftCode: strPtr("TESTPL33XXX")}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO BanksData .+`).
+			WillReturnError(&pq.Error{Code: "23514", Message: "duplicate key value violates unique constraint in a check"})
+		mock.ExpectRollback()
+
+		err = storage.AddSwiftCodeEntry(bank)
+		if errors.Is(err, ErrSwiftCodeExists) || !errors.Is(err, ErrCheckViolation) {
+			t.Errorf("expected error %v, got %v", ErrCheckViolation, err)
+		}
+	})
+}
+
+func TestUpdateSwiftCodeEntry(t *testing.T) {
+	bank := Bank{
+		Address:       strPtr("Updated Address"),
+		BankName:      strPtr("Bank"),
+		CountryISO2:   strPtr("PL"),
+		CountryName:   strPtr("POLAND"),
+		IsHeadquarter: boolPtr(true),
+		SwiftCode:     strPtr("TESTPL33XXX"),
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE BanksData SET .+, version = version \+ 1 WHERE swiftCode = \$6 AND version = \$7`).
+			WithArgs(bank.Address, bank.BankName, bank.CountryISO2, bank.CountryName, bank.IsHeadquarter, bank.SwiftCode, int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE BanksDataHistory SET validTo = now\(\)`).
+			WithArgs("TESTPL33XXX").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO BanksDataHistory`).
+			WithArgs("TESTPL33XXX").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WithArgs("modify", "TESTPL33XXX", "PL", sqlmock.AnyArg()).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("modify", "TESTPL33XXX", "PL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		if err := storage.UpdateSwiftCodeEntry(bank, 4); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE BanksData SET`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM BanksData WHERE swiftCode = \$1\)`).
+			WithArgs("TESTPL33XXX").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err = storage.UpdateSwiftCodeEntry(bank, 3)
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("expected error %v, got %v", ErrVersionMismatch, err)
+		}
+	})
 }
 
 func TestDeleteSwiftCodeEntry(t *testing.T) {
@@ -216,18 +411,42 @@ func TestDeleteSwiftCodeEntry(t *testing.T) {
 		storage := NewRelationalDB(db)
 		swiftCode := "TODELETE"
 
-		mock.ExpectExec(`SELECT swiftCode FROM BanksData WHERE swiftCode = \$1`).
-			WithArgs(swiftCode).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		deleted := Bank{
+			Address:       strPtr("Addr1"),
+			BankName:      strPtr("Bank1"),
+			CountryISO2:   strPtr("LE"),
+			CountryName:   strPtr("LESOTHO"),
+			IsHeadquarter: boolPtr(false),
+			SwiftCode:     strPtr(swiftCode),
+		}
+		data, err := marshalNullable(&deleted)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-		mock.ExpectExec(`DELETE FROM BanksData WHERE swiftCode = \$1`).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM BanksData WHERE swiftCode = \$1 AND version = \$2 RETURNING address, bankName, countryISO2, countryName, isHeadquarter, swiftCode`).
+			WithArgs(swiftCode, int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode"}).
+				AddRow("Addr1", "Bank1", "LE", "LESOTHO", false, swiftCode))
+		mock.ExpectExec(`UPDATE BanksDataHistory SET validTo = now\(\) WHERE swiftCode = \$1 AND validTo IS NULL`).
 			WithArgs(swiftCode).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		err = storage.DeleteSwiftCodeEntry(swiftCode)
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WithArgs("remove", swiftCode, "LE", data).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("remove", "TODELETE", "LE")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err = storage.DeleteSwiftCodeEntry(swiftCode, 2)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
@@ -240,13 +459,246 @@ func TestDeleteSwiftCodeEntry(t *testing.T) {
 		storage := NewRelationalDB(db)
 		swiftCode := "NOTFOUND"
 
-		mock.ExpectExec(`SELECT swiftCode FROM BanksData WHERE swiftCode = \$1`).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM BanksData WHERE swiftCode = \$1 AND version = \$2`).
+			WithArgs(swiftCode, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode"}))
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM BanksData WHERE swiftCode = \$1\)`).
 			WithArgs(swiftCode).
-			WillReturnError(sql.ErrNoRows)
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
 
-		err = storage.DeleteSwiftCodeEntry(swiftCode)
+		err = storage.DeleteSwiftCodeEntry(swiftCode, 1)
 		if !errors.Is(err, ErrSwiftCodeNotFound) {
 			t.Errorf("expected error %v, got %v", ErrSwiftCodeNotFound, err)
 		}
 	})
 }
+
+func TestListSwiftCodes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+
+	mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode FROM BanksData ORDER BY swiftCode`).
+		WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode"}).
+			AddRow("Addr1", "Bank1", "PL", "POLAND", true, "TESTPL33XXX").
+			AddRow("Addr2", "Bank1", "PL", "POLAND", false, "TESTPL33ABC"))
+
+	banks, err := storage.ListSwiftCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(banks) != 2 {
+		t.Errorf("expected 2 banks, got %d", len(banks))
+	}
+}
+
+func TestGetInstitution(t *testing.T) {
+	t.Run("BranchesWithoutHeadquarter", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectQuery(`SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version FROM BanksData WHERE swiftCode LIKE \$1`).
+			WithArgs("TESTPL33%").
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode", "version"}).
+				AddRow("Addr1", "Bank1", "PL", "POLAND", false, "TESTPL33AAA", 1).
+				AddRow("Addr2", "Bank1", "PL", "POLAND", false, "TESTPL33BBB", 2))
+
+		institution, err := storage.GetInstitution("TESTPL33")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if institution.Headquarter != nil || len(institution.Branches) != 2 || institution.BankName != "Bank1" {
+			t.Errorf("unexpected institution %+v", institution)
+		}
+	})
+
+	t.Run("InstitutionNotFound", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectQuery(`SELECT .+ FROM BanksData WHERE swiftCode LIKE \$1`).
+			WithArgs("TESTPL33%").
+			WillReturnRows(sqlmock.NewRows([]string{"address", "bankName", "countryISO2", "countryName", "isHeadquarter", "swiftCode", "version"}))
+
+		if _, err := storage.GetInstitution("TESTPL33"); !errors.Is(err, ErrInstitutionNotFound) {
+			t.Errorf("expected error %v, got %v", ErrInstitutionNotFound, err)
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+	lastModified := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT countryISO2, MAX\(countryName\), COUNT\(\*\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"countryISO2", "countryName", "count", "headquarters", "institutions"}).
+			AddRow("DE", "GERMANY", 1, 1, 1).
+			AddRow("PL", "POLAND", 4, 2, 2))
+	mock.ExpectQuery(`SELECT SUBSTR\(swiftCode, 1, 8\) AS institution`).
+		WithArgs(largestNetworks).
+		WillReturnRows(sqlmock.NewRows([]string{"institution", "bankName", "countryISO2", "branches"}).
+			AddRow("TESTPL33", "Bank1", "PL", 2))
+	mock.ExpectQuery(`SELECT validFrom FROM BanksDataHistory`).
+		WillReturnRows(sqlmock.NewRows([]string{"validFrom"}).AddRow(lastModified.Add(-time.Hour)))
+	mock.ExpectQuery(`SELECT validTo FROM BanksDataHistory`).
+		WillReturnRows(sqlmock.NewRows([]string{"validTo"}).AddRow(lastModified))
+
+	stats, err := storage.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.SwiftCodes != 5 || stats.Headquarters != 3 || stats.Branches != 2 || stats.Institutions != 3 {
+		t.Errorf("unexpected totals %+v", stats)
+	}
+	if len(stats.Countries) != 2 || stats.Countries[1].Branches != 2 {
+		t.Errorf("unexpected countries %+v", stats.Countries)
+	}
+	if len(stats.LargestBranchNetworks) != 1 || stats.LargestBranchNetworks[0].Institution != "TESTPL33" {
+		t.Errorf("unexpected branch networks %+v", stats.LargestBranchNetworks)
+	}
+	if stats.LastModified == nil || !stats.LastModified.Equal(lastModified) {
+		t.Errorf("expected %v, got %v", lastModified, stats.LastModified)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	before := Bank{
+		Address:       strPtr("Old Address"),
+		BankName:      strPtr("Bank"),
+		CountryISO2:   strPtr("PL"),
+		CountryName:   strPtr("POLAND"),
+		IsHeadquarter: boolPtr(false),
+		SwiftCode:     strPtr("TESTPL33ABC"),
+	}
+	after := before
+	after.Address = strPtr("New Address")
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO BanksData `).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO BanksDataHistory`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("add", "TESTPL33XXX", "PL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO BanksAudit`).
+			WithArgs("TESTPL33XXX", "add", nil, sqlmock.AnyArg(), "sync:test.csv").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE BanksData SET address = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE BanksDataHistory SET validTo = now\(\)`).
+			WithArgs("TESTPL33ABC").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO BanksDataHistory`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("modify", "TESTPL33ABC", "PL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO BanksAudit`).
+			WithArgs("TESTPL33ABC", "modify", sqlmock.AnyArg(), sqlmock.AnyArg(), "sync:test.csv").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`DELETE FROM BanksData WHERE swiftCode = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE BanksDataHistory SET validTo = now\(\)`).
+			WithArgs("TESTPL33DEF").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO Outbox \(action, swiftCode, countryISO2, data\)`).
+			WillReturnRows(outboxRow())
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+			WithArgs(ChangesChannel, changeNotification("remove", "TESTPL33DEF", "PL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO BanksAudit`).
+			WithArgs("TESTPL33DEF", "remove", sqlmock.AnyArg(), nil, "sync:test.csv").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		removed := before
+		removed.SwiftCode = strPtr("TESTPL33DEF")
+		added := before
+		added.SwiftCode = strPtr("TESTPL33XXX")
+
+		err = storage.ApplyChanges([]Change{
+			{Action: ChangeAdd, After: &added},
+			{Action: ChangeModify, Before: &before, After: &after},
+			{Action: ChangeRemove, Before: &removed},
+		}, "sync:test.csv")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("ConcurrentModificationRollsBack", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create mock: %v", err)
+		}
+		defer db.Close()
+
+		storage := NewRelationalDB(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE BanksData`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = storage.ApplyChanges([]Change{{Action: ChangeModify, Before: &before, After: &after}}, "sync:test.csv")
+		if !errors.Is(err, ErrConcurrentModification) {
+			t.Errorf("expected error %v, got %v", ErrConcurrentModification, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// outboxRow is the row returned when an outbox entry is inserted.
+func outboxRow() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "createdAt"}).AddRow(1, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+}
+
+// changeNotification is the payload announcing the change recorded as outboxRow.
+func changeNotification(action, swiftCode, iso2Code string) string {
+	return `{"eventId":1,"action":"` + action + `","swiftCode":"` + swiftCode + `","countryISO2":"` + iso2Code +
+		`","occurredAt":"2026-03-01T12:00:00Z"}`
+}
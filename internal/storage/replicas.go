@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// replicaEjection is how long a read replica which failed with a connection error receives no reads.
+const replicaEjection = 30 * time.Second
+
+// PrimaryReader is implemented by storages which may serve reads from replicas of the database, such
+// as RelationalDB. Primary returns a view of the storage which reads from the primary database, for
+// reads which must see the latest writes.
+type PrimaryReader interface {
+	Primary() Storage
+}
+
+// Primary returns s reading from the primary database, or s itself if it does not use replicas.
+func Primary(s Storage) Storage {
+	if reader, ok := s.(PrimaryReader); ok {
+		return reader.Primary()
+	}
+	return s
+}
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads marks ctx so that ForContext sends the reads made on its behalf to the primary database.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads reports whether ctx was marked with WithPrimaryReads.
+func PrimaryReads(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return forced
+}
+
+// ForContext returns the storage serving a request with the given context: Primary(s) if the
+// context asks for primary reads, otherwise s.
+func ForContext(ctx context.Context, s Storage) Storage {
+	if PrimaryReads(ctx) {
+		return Primary(s)
+	}
+	return s
+}
+
+// replicaPool spreads reads over read replicas round-robin. A replica which fails with a connection
+// error is ejected for replicaEjection, after which it is given reads again.
+type replicaPool struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	index        int
+	db           *sql.DB
+	ejectedUntil atomic.Int64 // Unix nanoseconds
+}
+
+func newReplicaPool(dbs []*sql.DB) *replicaPool {
+	if len(dbs) == 0 {
+		return nil
+	}
+	pool := &replicaPool{}
+	for i, db := range dbs {
+		pool.replicas = append(pool.replicas, &replica{index: i, db: db})
+	}
+	return pool
+}
+
+// pick returns the next healthy replica, or nil if all of them are ejected.
+func (p *replicaPool) pick() *replica {
+	now := time.Now().UnixNano()
+	for range p.replicas {
+		r := p.replicas[p.next.Add(1)%uint64(len(p.replicas))]
+		if r.ejectedUntil.Load() <= now {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p *replicaPool) eject(r *replica, err error) {
+	r.ejectedUntil.Store(time.Now().Add(replicaEjection).UnixNano())
+	log.Printf("Read replica %d ejected for %v: %v", r.index, replicaEjection, err)
+}
+
+// read runs query on a healthy replica. When a replica fails with a connection error, it is ejected
+// and the query is retried on another one, and eventually on the primary.
+func (r *RelationalDB) read(query func(db *sql.DB) error) error {
+	if r.replicas != nil && !r.primaryReads {
+		for range r.replicas.replicas {
+			replica := r.replicas.pick()
+			if replica == nil {
+				break
+			}
+			err := translateError(query(replica.db))
+			if !errors.Is(err, ErrUnavailable) {
+				return err
+			}
+			r.replicas.eject(replica, err)
+		}
+	}
+	return translateError(query(r.db))
+}
@@ -0,0 +1,161 @@
+//go:build unit
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net"
+	"testing"
+	"time"
+)
+
+const countryQuery = `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode FROM BanksData WHERE countryISO2 = \$1`
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled expectations: %v", err)
+		}
+		db.Close()
+	})
+	return db, mock
+}
+
+func expectCountryQuery(mock sqlmock.Sqlmock, bankName string) {
+	mock.ExpectQuery(countryQuery).
+		WithArgs("PL").
+		WillReturnRows(sqlmock.NewRows([]string{"countryISO2", "countryName", "address", "bankName", "isHeadquarter", "swiftCode"}).
+			AddRow("PL", "POLAND", "WARSAW", bankName, true, "BREXPLPWXXX"))
+}
+
+func getBankName(t *testing.T, s Storage) string {
+	countryBanks, err := s.GetSwiftCodesForCountry("PL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return countryBanks.SwiftCodes[0].BankName
+}
+
+func TestRelationalDB_Replicas(t *testing.T) {
+	t.Run("reads are spread over replicas", func(t *testing.T) {
+		primary, _ := newMockDB(t)
+		replica1, mock1 := newMockDB(t)
+		replica2, mock2 := newMockDB(t)
+		storage := NewRelationalDB(primary, replica1, replica2)
+
+		expectCountryQuery(mock1, "REPLICA 1")
+		expectCountryQuery(mock1, "REPLICA 1")
+		expectCountryQuery(mock2, "REPLICA 2")
+		expectCountryQuery(mock2, "REPLICA 2")
+
+		seen := make(map[string]int)
+		for range 4 {
+			seen[getBankName(t, storage)]++
+		}
+		if seen["REPLICA 1"] != 2 || seen["REPLICA 2"] != 2 {
+			t.Errorf("expected reads to alternate between replicas, got %v", seen)
+		}
+	})
+
+	t.Run("writes and forced reads go to the primary", func(t *testing.T) {
+		primary, primaryMock := newMockDB(t)
+		replica, _ := newMockDB(t)
+		storage := NewRelationalDB(primary, replica)
+
+		primaryMock.ExpectBegin()
+		primaryMock.ExpectQuery(`DELETE FROM BanksData`).WillReturnError(errors.New("write failed"))
+		primaryMock.ExpectRollback()
+		if err := storage.DeleteSwiftCodeEntry("BREXPLPWXXX", 1); err == nil {
+			t.Error("expected the error of the primary")
+		}
+
+		expectCountryQuery(primaryMock, "PRIMARY")
+		if name := getBankName(t, Primary(storage)); name != "PRIMARY" {
+			t.Errorf("expected Primary to read from the primary, got %s", name)
+		}
+
+		expectCountryQuery(primaryMock, "PRIMARY")
+		if name := getBankName(t, ForContext(WithPrimaryReads(context.Background()), storage)); name != "PRIMARY" {
+			t.Errorf("expected WithPrimaryReads to read from the primary, got %s", name)
+		}
+	})
+
+	t.Run("unreachable replica is ejected", func(t *testing.T) {
+		primary, primaryMock := newMockDB(t)
+		replica1, mock1 := newMockDB(t)
+		replica2, mock2 := newMockDB(t)
+		storage := NewRelationalDB(primary, replica1, replica2)
+
+		// the round-robin starts with the second replica
+		mock2.ExpectQuery(countryQuery).WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")})
+		expectCountryQuery(mock1, "REPLICA 1")
+		expectCountryQuery(mock1, "REPLICA 1")
+		for range 2 {
+			if name := getBankName(t, storage); name != "REPLICA 1" {
+				t.Errorf("expected the healthy replica to serve reads, got %s", name)
+			}
+		}
+
+		// with all replicas ejected, the primary serves reads
+		mock1.ExpectQuery(countryQuery).WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")})
+		expectCountryQuery(primaryMock, "PRIMARY")
+		if name := getBankName(t, storage); name != "PRIMARY" {
+			t.Errorf("expected the primary to serve reads, got %s", name)
+		}
+
+		// ejected replicas are given another chance later
+		for _, replica := range storage.replicas.replicas {
+			replica.ejectedUntil.Store(time.Now().Add(-time.Second).UnixNano())
+		}
+		expectCountryQuery(mock2, "REPLICA 2")
+		if name := getBankName(t, storage); name != "REPLICA 2" {
+			t.Errorf("expected the replica to be reinstated, got %s", name)
+		}
+	})
+
+	t.Run("replica unreachable from the start", func(t *testing.T) {
+		primary, primaryMock := newMockDB(t)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		address := listener.Addr().String()
+		_ = listener.Close()
+
+		replica, err := OpenPostgreSQL(PostgresConfig{URL: "postgres://user@" + address + "/bic?sslmode=disable"})
+		if err != nil {
+			t.Fatalf("expected the replica to be opened without connecting, got %v", err)
+		}
+		t.Cleanup(func() { _ = replica.Close() })
+		storage := NewRelationalDB(primary, replica)
+
+		expectCountryQuery(primaryMock, "PRIMARY")
+		if name := getBankName(t, storage); name != "PRIMARY" {
+			t.Errorf("expected the primary to serve reads, got %s", name)
+		}
+		if until := storage.replicas.replicas[0].ejectedUntil.Load(); until == 0 {
+			t.Error("expected the replica to be ejected")
+		}
+	})
+
+	t.Run("query errors are not retried", func(t *testing.T) {
+		primary, _ := newMockDB(t)
+		replica, mock := newMockDB(t)
+		storage := NewRelationalDB(primary, replica)
+
+		mock.ExpectQuery(countryQuery).WillReturnRows(sqlmock.NewRows([]string{}))
+		if _, err := storage.GetSwiftCodesForCountry("PL"); !errors.Is(err, ErrISO2CodeNotFound) {
+			t.Errorf("expected %v, got %v", ErrISO2CodeNotFound, err)
+		}
+		if until := storage.replicas.replicas[0].ejectedUntil.Load(); until != 0 {
+			t.Error("expected the replica not to be ejected")
+		}
+	})
+}
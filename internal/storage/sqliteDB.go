@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// sqliteScheme is the scheme of the database URLs selecting SQLite, e.g. sqlite:///var/lib/bic/bic.db
+// or sqlite:bic.db for a path relative to the working directory.
+const sqliteScheme = "sqlite:"
+
+// sqliteOptions are applied to every connection. Times are stored as text which sorts chronologically,
+// and LIKE is case-sensitive as in PostgreSQL, which lets prefix searches use the primary key.
+const sqliteOptions = "_time_format=sqlite&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=case_sensitive_like(1)"
+
+// IsSQLite tells whether the database URL selects the SQLite backend.
+func IsSQLite(url string) bool {
+	return strings.HasPrefix(url, sqliteScheme)
+}
+
+type SQLiteStorage struct {
+	Db *sql.DB
+}
+
+// NewSQLiteStorage opens the SQLite database file named by the sqlite: URL, creating it if needed.
+func NewSQLiteStorage(url string) (*SQLiteStorage, error) {
+	if !IsSQLite(url) {
+		return nil, fmt.Errorf("%q is not a SQLite database URL", url)
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(url, sqliteScheme), "//")
+	if path == "" || strings.HasPrefix(path, "?") {
+		return nil, errors.New("the SQLite database URL does not name a file")
+	}
+
+	dsn := "file:" + path
+	if strings.Contains(path, "?") {
+		dsn += "&" + sqliteOptions
+	} else {
+		dsn += "?" + sqliteOptions
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite allows a single writer, a single connection serialises the transactions instead of
+	// failing them as busy
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	log.Printf("Successfully opened SQLite database %s", path)
+	return &SQLiteStorage{Db: db}, nil
+}
+
+// Init creates the same tables and indexes as PostgreSQLStorage.Init, apart from the ones of the
+// outbox, webhooks and idempotency keys, which SQLiteDB does not support.
+func (s *SQLiteStorage) Init() (*sql.DB, error) {
+	_, err := s.Db.Exec(`
+			CREATE TABLE IF NOT EXISTS BanksData (
+			    address TEXT NOT NULL,
+			    bankName TEXT NOT NULL,
+			    isHeadquarter BOOLEAN NOT NULL,
+			    countryName TEXT NOT NULL,
+			    countryISO2 CHAR(2) NOT NULL,
+			    swiftCode TEXT NOT NULL UNIQUE,
+			    version INTEGER NOT NULL DEFAULT 1,
+			    PRIMARY KEY (swiftCode));
+
+			CREATE INDEX IF NOT EXISTS idx_countryISO2_swiftCode ON BanksData (countryISO2, swiftCode);
+
+			DROP INDEX IF EXISTS idx_countryISO2;
+
+			CREATE TABLE IF NOT EXISTS BanksAudit (
+			    id INTEGER PRIMARY KEY AUTOINCREMENT,
+			    swiftCode TEXT NOT NULL,
+			    action TEXT NOT NULL,
+			    oldData TEXT,
+			    newData TEXT,
+			    source TEXT NOT NULL,
+			    changedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+
+			CREATE INDEX IF NOT EXISTS idx_audit_swiftCode ON BanksAudit (swiftCode);
+
+			CREATE TABLE IF NOT EXISTS BanksDataHistory (
+			    address TEXT NOT NULL,
+			    bankName TEXT NOT NULL,
+			    isHeadquarter BOOLEAN NOT NULL,
+			    countryName TEXT NOT NULL,
+			    countryISO2 CHAR(2) NOT NULL,
+			    swiftCode TEXT NOT NULL,
+			    version INTEGER NOT NULL DEFAULT 1,
+			    validFrom TIMESTAMP NOT NULL,
+			    validTo TIMESTAMP,
+			    PRIMARY KEY (swiftCode, validFrom));
+
+			CREATE INDEX IF NOT EXISTS idx_history_countryISO2 ON BanksDataHistory (countryISO2, validFrom);
+
+			CREATE INDEX IF NOT EXISTS idx_history_validFrom ON BanksDataHistory (validFrom);
+
+			CREATE INDEX IF NOT EXISTS idx_history_validTo ON BanksDataHistory (validTo) WHERE validTo IS NOT NULL;
+`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+	return s.Db, nil
+}
+
+// SQLiteDB stores the data in a SQLite database, for deployments without PostgreSQL. It keeps the
+// history and the audit log of the records, but does not announce the changes: there is no outbox,
+// and no notifications for other replicas.
+type SQLiteDB struct {
+	db *sql.DB
+}
+
+func NewSQLiteDB(db *sql.DB) *SQLiteDB {
+	return &SQLiteDB{db: db}
+}
+
+func (s *SQLiteDB) GetSwiftCodeDetails(swiftCode string) (*Bank, error) {
+	return getSwiftCodeDetails(s.db, swiftCode)
+}
+
+func (s *SQLiteDB) GetSwiftCodeDetailsAsOf(swiftCode string, asOf time.Time) (*Bank, error) {
+	// times are compared as text, which only sorts chronologically in the same time zone
+	return getSwiftCodeDetailsAsOf(s.db, swiftCode, asOf.UTC())
+}
+
+func (s *SQLiteDB) GetSwiftCodesForCountry(iso2Code string) (*CountryBanks, error) {
+	return queryCountryBanks(s.db, `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode
+		FROM BanksData
+		WHERE countryISO2 = $1`, iso2Code)
+}
+
+func (s *SQLiteDB) GetSwiftCodesForCountryAsOf(iso2Code string, asOf time.Time) (*CountryBanks, error) {
+	return queryCountryBanks(s.db, `SELECT countryISO2, countryName, address, bankName, isHeadquarter, swiftCode
+		FROM BanksDataHistory
+		WHERE countryISO2 = $1 AND validFrom <= $2 AND (validTo IS NULL OR validTo > $2)`, iso2Code, asOf.UTC())
+}
+
+// ListSwiftCodes returns every stored record ordered by SWIFT code, without branch aggregation.
+func (s *SQLiteDB) ListSwiftCodes() ([]Bank, error) {
+	return listSwiftCodes(s.db)
+}
+
+func (s *SQLiteDB) AddSwiftCodeEntry(b Bank) error {
+	return s.inTx(func(tx *sql.Tx, now time.Time) error {
+		return sqliteWriteChange(tx, Change{Action: ChangeAdd, After: &b}, now)
+	})
+}
+
+func (s *SQLiteDB) UpdateSwiftCodeEntry(b Bank, version int64) error {
+	return s.inTx(func(tx *sql.Tx, now time.Time) error {
+		result, err := tx.Exec(`UPDATE BanksData
+		SET address = $1, bankName = $2, countryISO2 = $3, countryName = $4, isHeadquarter = $5, version = version + 1
+		WHERE swiftCode = $6 AND version = $7`,
+			b.Address, b.BankName, b.CountryISO2, b.CountryName, b.IsHeadquarter, b.SwiftCode, version)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersionedWrite(tx, result, *b.SwiftCode); err != nil {
+			return err
+		}
+
+		if err := sqliteCloseVersion(tx, *b.SwiftCode, now); err != nil {
+			return err
+		}
+		return sqliteOpenVersion(tx, *b.SwiftCode, now)
+	})
+}
+
+func (s *SQLiteDB) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
+	return s.inTx(func(tx *sql.Tx, now time.Time) error {
+		result, err := tx.Exec(`DELETE FROM BanksData WHERE swiftCode = $1 AND version = $2`, swiftCode, version)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersionedWrite(tx, result, swiftCode); err != nil {
+			return err
+		}
+
+		return sqliteCloseVersion(tx, swiftCode, now)
+	})
+}
+
+// ApplyChanges applies all changes in a single transaction and records an audit entry for each of them,
+// like RelationalDB.ApplyChanges.
+func (s *SQLiteDB) ApplyChanges(changes []Change, source string) error {
+	return s.inTx(func(tx *sql.Tx, now time.Time) error {
+		for _, c := range changes {
+			if err := sqliteWriteChange(tx, c, now); err != nil {
+				return fmt.Errorf("%s %s: %w", c.Action, c.SwiftCode(), err)
+			}
+			if err := insertAuditEntry(tx, c, source); err != nil {
+				return fmt.Errorf("audit %s: %w", c.SwiftCode(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction, which is committed only if fn succeeds. now is the time of the
+// transaction, as now() in PostgreSQL.
+func (s *SQLiteDB) inTx(fn func(tx *sql.Tx, now time.Time) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if err := fn(tx, time.Now().UTC()); err != nil {
+		_ = tx.Rollback()
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit())
+}
+
+// sqliteWriteChange applies the change to BanksData and keeps BanksDataHistory in step with it.
+func sqliteWriteChange(tx *sql.Tx, c Change, now time.Time) error {
+	if err := applyChange(tx, c); err != nil {
+		return err
+	}
+
+	if c.Action != ChangeAdd {
+		if err := sqliteCloseVersion(tx, c.SwiftCode(), now); err != nil {
+			return err
+		}
+	}
+	if c.Action != ChangeRemove {
+		return sqliteOpenVersion(tx, c.SwiftCode(), now)
+	}
+	return nil
+}
+
+func sqliteOpenVersion(tx *sql.Tx, swiftCode string, now time.Time) error {
+	_, err := tx.Exec(`INSERT INTO BanksDataHistory (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, validFrom)
+		SELECT address, bankName, countryISO2, countryName, isHeadquarter, swiftCode, version, $2
+		FROM BanksData
+		WHERE swiftCode = $1`, swiftCode, now)
+	return err
+}
+
+func sqliteCloseVersion(tx *sql.Tx, swiftCode string, now time.Time) error {
+	_, err := tx.Exec(`UPDATE BanksDataHistory SET validTo = $2 WHERE swiftCode = $1 AND validTo IS NULL`, swiftCode, now)
+	return err
+}
@@ -0,0 +1,95 @@
+//go:build unit
+
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestSQLiteDB(t *testing.T) *SQLiteDB {
+	s, err := NewSQLiteStorage("sqlite://" + filepath.Join(t.TempDir(), "bic.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Db.Close() })
+
+	db, err := s.Init()
+	if err != nil {
+		t.Fatalf("failed to init database: %v", err)
+	}
+	return NewSQLiteDB(db)
+}
+
+func TestSQLiteDB_Behaviour(t *testing.T) {
+	testStorageBehaviour(t, newTestSQLiteDB(t))
+}
+
+func TestSQLiteStorage_Init(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bic.db")
+	s, err := NewSQLiteStorage("sqlite:" + path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer s.Db.Close()
+
+	if _, err := s.Init(); err != nil {
+		t.Fatalf("failed to init database: %v", err)
+	}
+	if err := NewSQLiteDB(s.Db).AddSwiftCodeEntry(newTestBank("TESTPL22XXX", "WARSAW")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Init(); err != nil {
+		t.Errorf("expected Init to be idempotent, got %v", err)
+	}
+
+	var plan string
+	err = s.Db.QueryRow(`EXPLAIN QUERY PLAN SELECT swiftCode FROM BanksData WHERE swiftCode LIKE 'TESTPL22%'`).
+		Scan(new(int), new(int), new(int), &plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan == "SCAN BanksData" {
+		t.Error("expected the branch lookup to use an index")
+	}
+}
+
+func TestSQLiteDB_LastModifiedUsesIndexes(t *testing.T) {
+	s := newTestSQLiteDB(t)
+	for i, index := range []string{"idx_history_validFrom", "idx_history_validTo"} {
+		var id, parent, notUsed int
+		var detail string
+		err := s.db.QueryRow("EXPLAIN QUERY PLAN "+lastModifiedQueries[i]).Scan(&id, &parent, &notUsed, &detail)
+		if err != nil {
+			t.Fatalf("failed to explain %q: %v", lastModifiedQueries[i], err)
+		}
+		if !strings.Contains(detail, index) {
+			t.Errorf("expected %q to use %s, got %q", lastModifiedQueries[i], index, detail)
+		}
+	}
+}
+
+func TestNewSQLiteStorage_InvalidURL(t *testing.T) {
+	for _, url := range []string{"postgres://db/bic", "sqlite:", "sqlite://"} {
+		if _, err := NewSQLiteStorage(url); err == nil {
+			t.Errorf("expected an error for %q", url)
+		}
+	}
+}
+
+func TestTranslateError_SQLite(t *testing.T) {
+	s := newTestSQLiteDB(t)
+	_, err := s.db.Exec(`INSERT INTO BanksData (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode)
+		VALUES ('a', 'b', 'PL', 'POLAND', true, 'TESTPL22XXX'), ('a', 'b', 'PL', 'POLAND', true, 'TESTPL22XXX')`)
+	if err := translateError(err); !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("expected %v, got %v", ErrUniqueViolation, err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO BanksData (address, bankName, countryISO2, countryName, isHeadquarter, swiftCode)
+		VALUES (NULL, 'b', 'PL', 'POLAND', true, 'TESTPL22XXX')`)
+	if err := translateError(err); !errors.Is(err, ErrCheckViolation) {
+		t.Errorf("expected %v, got %v", ErrCheckViolation, err)
+	}
+}
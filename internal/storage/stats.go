@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// largestNetworks is the number of institutions listed in Stats.LargestBranchNetworks.
+const largestNetworks = 10
+
+// Stats summarises the stored records. An institution is a group of SWIFT codes sharing their first
+// eight characters (the BIC8), that is a headquarter and its branches.
+type Stats struct {
+	XMLName      xml.Name `json:"-" xml:"stats"`
+	SwiftCodes   int      `json:"swiftCodes" xml:"swiftCodes"`
+	Headquarters int      `json:"headquarters" xml:"headquarters"`
+	Branches     int      `json:"branches" xml:"branches"`
+	Institutions int      `json:"institutions" xml:"institutions"`
+	// LastModified is when a record was last added, modified or removed, nil if there never was one.
+	LastModified          *time.Time      `json:"lastModified" xml:"lastModified,omitempty"`
+	Countries             []CountryStats  `json:"countries" xml:"countries>country"`
+	LargestBranchNetworks []BranchNetwork `json:"largestBranchNetworks" xml:"largestBranchNetworks>institution"`
+}
+
+type CountryStats struct {
+	CountryISO2  string `json:"countryISO2" xml:"countryISO2"`
+	CountryName  string `json:"countryName" xml:"countryName"`
+	SwiftCodes   int    `json:"swiftCodes" xml:"swiftCodes"`
+	Headquarters int    `json:"headquarters" xml:"headquarters"`
+	Branches     int    `json:"branches" xml:"branches"`
+	Institutions int    `json:"institutions" xml:"institutions"`
+}
+
+// BranchNetwork is an institution with the number of its branches. BankName is the name of its
+// headquarter, or of one of its branches if it has none.
+type BranchNetwork struct {
+	Institution string `json:"institution" xml:"institution"`
+	BankName    string `json:"bankName" xml:"bankName"`
+	CountryISO2 string `json:"countryISO2" xml:"countryISO2"`
+	Branches    int    `json:"branches" xml:"branches"`
+}
+
+// MarshalCSV renders one row per country.
+func (s Stats) MarshalCSV() ([][]string, error) {
+	return countryStatsCSV(s.Countries), nil
+}
+
+func countryStatsCSV(countries []CountryStats) [][]string {
+	records := [][]string{{"countryISO2", "countryName", "swiftCodes", "headquarters", "branches", "institutions"}}
+	for _, c := range countries {
+		records = append(records, []string{
+			c.CountryISO2,
+			c.CountryName,
+			strconv.Itoa(c.SwiftCodes),
+			strconv.Itoa(c.Headquarters),
+			strconv.Itoa(c.Branches),
+			strconv.Itoa(c.Institutions),
+		})
+	}
+	return records
+}
+
+// StatsProvider is implemented by storages which can summarise their records, such as RelationalDB
+// and SQLiteDB.
+type StatsProvider interface {
+	Stats() (*Stats, error)
+}
+
+// Stats summarises the stored records with aggregate queries.
+func (r *RelationalDB) Stats() (*Stats, error) {
+	var stats *Stats
+	err := r.read(func(db *sql.DB) (err error) {
+		stats, err = queryStats(db)
+		return err
+	})
+	return stats, err
+}
+
+// Stats summarises the stored records with aggregate queries.
+func (s *SQLiteDB) Stats() (*Stats, error) {
+	stats, err := queryStats(s.db)
+	return stats, translateError(err)
+}
+
+// Stats is not cached, it passes through to the wrapped storage if that can summarise its records.
+func (c *CachedStorage) Stats() (*Stats, error) {
+	provider, ok := c.next.(StatsProvider)
+	if !ok {
+		return nil, errors.New("Statistics are not supported by the storage")
+	}
+	return provider.Stats()
+}
+
+// queryStats runs the aggregate queries of Stats. They are written to run unchanged on PostgreSQL
+// and SQLite.
+func queryStats(db *sql.DB) (*Stats, error) {
+	countries, err := queryCountryStats(db)
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{Countries: countries, LargestBranchNetworks: []BranchNetwork{}}
+	for _, c := range countries {
+		// the country is part of the BIC8, so an institution is never counted in two countries
+		stats.SwiftCodes += c.SwiftCodes
+		stats.Headquarters += c.Headquarters
+		stats.Branches += c.Branches
+		stats.Institutions += c.Institutions
+	}
+
+	rows, err := db.Query(`SELECT SUBSTR(swiftCode, 1, 8) AS institution,
+			COALESCE(MAX(CASE WHEN isHeadquarter THEN bankName END), MAX(bankName)), MAX(countryISO2),
+			SUM(CASE WHEN isHeadquarter THEN 0 ELSE 1 END) AS branches
+		FROM BanksData
+		GROUP BY SUBSTR(swiftCode, 1, 8)
+		HAVING SUM(CASE WHEN isHeadquarter THEN 0 ELSE 1 END) > 0
+		ORDER BY branches DESC, institution
+		LIMIT $1`, largestNetworks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n BranchNetwork
+		if err := rows.Scan(&n.Institution, &n.BankName, &n.CountryISO2, &n.Branches); err != nil {
+			return nil, err
+		}
+		stats.LargestBranchNetworks = append(stats.LargestBranchNetworks, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, query := range lastModifiedQueries {
+		var t time.Time
+		err := db.QueryRow(query).Scan(&t)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if stats.LastModified == nil || t.After(*stats.LastModified) {
+			stats.LastModified = &t
+		}
+	}
+
+	return stats, nil
+}
+
+// lastModifiedQueries read the latest start and end of a version. A version is opened by every change
+// and closed by every modification or removal, so the later of both times is the last change. They are
+// served by the idx_history_validFrom and idx_history_validTo indexes; aggregates of times would lose
+// their type in SQLite.
+var lastModifiedQueries = []string{
+	`SELECT validFrom FROM BanksDataHistory ORDER BY validFrom DESC LIMIT 1`,
+	`SELECT validTo FROM BanksDataHistory WHERE validTo IS NOT NULL ORDER BY validTo DESC LIMIT 1`,
+}
+
+// countryStatsColumns are the counts of CountryStats, for records grouped by country.
+const countryStatsColumns = `countryISO2, MAX(countryName), COUNT(*),
+			SUM(CASE WHEN isHeadquarter THEN 1 ELSE 0 END), COUNT(DISTINCT SUBSTR(swiftCode, 1, 8))`
+
+// queryCountryStats counts the records of every country which has any, ordered by ISO2 code.
+func queryCountryStats(db *sql.DB) ([]CountryStats, error) {
+	rows, err := db.Query(`SELECT ` + countryStatsColumns + `
+		FROM BanksData
+		GROUP BY countryISO2
+		ORDER BY countryISO2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	countries := []CountryStats{}
+	for rows.Next() {
+		var c CountryStats
+		if err := rows.Scan(&c.CountryISO2, &c.CountryName, &c.SwiftCodes, &c.Headquarters, &c.Institutions); err != nil {
+			return nil, err
+		}
+		c.Branches = c.SwiftCodes - c.Headquarters
+		countries = append(countries, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return countries, nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"time"
 )
 
 type Storage interface {
@@ -9,11 +10,23 @@ type Storage interface {
 
 	GetSwiftCodesForCountry(iso2Code string) (*CountryBanks, error)
 
+	// GetSwiftCodeDetailsAsOf and GetSwiftCodesForCountryAsOf answer the same questions
+	// as their counterparts above, but for the data that was valid at the given instant.
+	GetSwiftCodeDetailsAsOf(swiftCode string, asOf time.Time) (*Bank, error)
+
+	GetSwiftCodesForCountryAsOf(iso2Code string, asOf time.Time) (*CountryBanks, error)
+
 	AddSwiftCodeEntry(b Bank) error
 
-	DeleteSwiftCodeEntry(swiftCode string) error
+	// UpdateSwiftCodeEntry and DeleteSwiftCodeEntry only succeed if the stored record still has
+	// the given version, otherwise ErrVersionMismatch is returned.
+	UpdateSwiftCodeEntry(b Bank, version int64) error
+
+	DeleteSwiftCodeEntry(swiftCode string, version int64) error
 }
 
 var ErrSwiftCodeNotFound = errors.New("Given Swift Code not found")
 var ErrISO2CodeNotFound = errors.New("Country with given ISO2 Code does not have any swift codes")
 var ErrSwiftCodeExists = errors.New("Given Swift Code already exists in database")
+var ErrVersionMismatch = errors.New("Record has been modified by someone else")
+var ErrConcurrentModification = errors.New("Record was modified since the changes were computed")
@@ -1,29 +1,140 @@
 package storage
 
+import (
+	"encoding/xml"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"strconv"
+	"time"
+)
+
 type Response struct {
-	Message string `json:"message"`
+	XMLName xml.Name `json:"-" xml:"response"`
+	Message string   `json:"message" xml:"message"`
+	// CountryName is the canonical country name a record has been stored with, set when it is added or
+	// updated.
+	CountryName string `json:"countryName,omitempty" xml:"countryName,omitempty"`
+	// Code identifies the error of an error response, so that clients need not match its message.
+	Code string `json:"code,omitempty" xml:"code,omitempty"`
 }
 
 type Bank struct {
-	Address       *string      `json:"address"`
-	BankName      *string      `json:"bankName"`
-	CountryISO2   *string      `json:"countryISO2"`
-	CountryName   *string      `json:"countryName"`
-	IsHeadquarter *bool        `json:"isHeadquarter"`
-	SwiftCode     *string      `json:"swiftCode"`
-	Branches      []BankBranch `json:"branches"`
+	XMLName       xml.Name     `json:"-" xml:"bank"`
+	Address       *string      `json:"address" xml:"address"`
+	BankName      *string      `json:"bankName" xml:"bankName"`
+	CountryISO2   *string      `json:"countryISO2" xml:"countryISO2"`
+	CountryName   *string      `json:"countryName" xml:"countryName"`
+	IsHeadquarter *bool        `json:"isHeadquarter" xml:"isHeadquarter"`
+	SwiftCode     *string      `json:"swiftCode" xml:"swiftCode"`
+	Branches      []BankBranch `json:"branches" xml:"branches>branch"`
+	ValidFrom     *time.Time   `json:"validFrom,omitempty" xml:"validFrom,omitempty"`
+	ValidTo       *time.Time   `json:"validTo,omitempty" xml:"validTo,omitempty"`
+	Version       int64        `json:"-" xml:"-"`
 }
 
 type BankBranch struct {
-	Address       string `json:"address"`
-	BankName      string `json:"bankName"`
-	CountryISO2   string `json:"countryISO2"`
-	IsHeadquarter bool   `json:"isHeadquarter"`
-	SwiftCode     string `json:"swiftCode"`
+	Address       string `json:"address" xml:"address"`
+	BankName      string `json:"bankName" xml:"bankName"`
+	CountryISO2   string `json:"countryISO2" xml:"countryISO2"`
+	IsHeadquarter bool   `json:"isHeadquarter" xml:"isHeadquarter"`
+	SwiftCode     string `json:"swiftCode" xml:"swiftCode"`
+	Version       int64  `json:"-" xml:"-"`
 }
 
 type CountryBanks struct {
-	CountryISO2 string       `json:"countryISO2"`
-	CountryName string       `json:"countryName"`
-	SwiftCodes  []BankBranch `json:"swiftCode"`
+	XMLName     xml.Name     `json:"-" xml:"country"`
+	CountryISO2 string       `json:"countryISO2" xml:"countryISO2"`
+	CountryName string       `json:"countryName" xml:"countryName"`
+	SwiftCodes  []BankBranch `json:"swiftCode" xml:"swiftCodes>swiftCode"`
+}
+
+// csvHeader is shared by the CSV representation of Bank and CountryBanks, so that
+// both responses can be loaded into the same spreadsheet.
+var csvHeader = []string{"swiftCode", "bankName", "address", "countryISO2", "countryName", "isHeadquarter"}
+
+func (r Response) MarshalCSV() ([][]string, error) {
+	if r.Code != "" {
+		return [][]string{{"message", "code"}, {r.Message, r.Code}}, nil
+	}
+	if r.CountryName != "" {
+		return [][]string{{"message", "countryName"}, {r.Message, r.CountryName}}, nil
+	}
+	return [][]string{{"message"}, {r.Message}}, nil
+}
+
+// MarshalCSV renders the bank as the first row followed by one row per branch.
+func (b Bank) MarshalCSV() ([][]string, error) {
+	countryName := ptr.Value(b.CountryName)
+	records := [][]string{csvHeader, {
+		ptr.Value(b.SwiftCode),
+		ptr.Value(b.BankName),
+		ptr.Value(b.Address),
+		ptr.Value(b.CountryISO2),
+		countryName,
+		ptr.FormatBool(b.IsHeadquarter),
+	}}
+
+	for _, branch := range b.Branches {
+		records = append(records, branch.csvRecord(countryName))
+	}
+	return records, nil
+}
+
+func (c CountryBanks) MarshalCSV() ([][]string, error) {
+	records := [][]string{csvHeader}
+	for _, branch := range c.SwiftCodes {
+		records = append(records, branch.csvRecord(c.CountryName))
+	}
+	return records, nil
+}
+
+func (b BankBranch) csvRecord(countryName string) []string {
+	return []string{
+		b.SwiftCode,
+		b.BankName,
+		b.Address,
+		b.CountryISO2,
+		countryName,
+		strconv.FormatBool(b.IsHeadquarter),
+	}
+}
+
+type ChangeAction string
+
+const (
+	ChangeAdd    ChangeAction = "add"
+	ChangeModify ChangeAction = "modify"
+	ChangeRemove ChangeAction = "remove"
+)
+
+// Change describes a single record mutation. Before is nil for additions and After is nil for removals.
+type Change struct {
+	Action ChangeAction `json:"action"`
+	Before *Bank        `json:"before,omitempty"`
+	After  *Bank        `json:"after,omitempty"`
+}
+
+// ChangeApplier is implemented by storages which can apply several changes atomically.
+type ChangeApplier interface {
+	ApplyChanges(changes []Change, source string) error
+}
+
+// SwiftCode returns the SWIFT code of the record affected by the change.
+func (c Change) SwiftCode() string {
+	if c.After != nil {
+		return ptr.Value(c.After.SwiftCode)
+	}
+	if c.Before != nil {
+		return ptr.Value(c.Before.SwiftCode)
+	}
+	return ""
+}
+
+func (c Change) CountryISO2() string {
+	if c.After != nil {
+		return ptr.Value(c.After.CountryISO2)
+	}
+	if c.Before != nil {
+		return ptr.Value(c.Before.CountryISO2)
+	}
+	return ""
 }
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"strconv"
+	"time"
+)
+
+// WebhookSubscription is a receiver of change events. Events are delivered only if they match
+// all of the filters which are set.
+type WebhookSubscription struct {
+	XMLName     xml.Name  `json:"-" xml:"webhook"`
+	ID          int64     `json:"id" xml:"id"`
+	URL         string    `json:"url" xml:"url"`
+	CountryISO2 *string   `json:"countryISO2,omitempty" xml:"countryISO2,omitempty"`
+	BICPrefix   *string   `json:"bicPrefix,omitempty" xml:"bicPrefix,omitempty"`
+	Secret      string    `json:"secret,omitempty" xml:"secret,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" xml:"createdAt"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an attempt to deliver one event to one subscription. ReplayOf is set for
+// deliveries created by replaying an earlier one.
+type WebhookDelivery struct {
+	XMLName        xml.Name              `json:"-" xml:"delivery"`
+	ID             int64                 `json:"id" xml:"id"`
+	SubscriptionID int64                 `json:"subscriptionId" xml:"subscriptionId"`
+	EventID        int64                 `json:"eventId" xml:"eventId"`
+	Event          json.RawMessage       `json:"event" xml:"event"`
+	Status         WebhookDeliveryStatus `json:"status" xml:"status"`
+	Attempts       int                   `json:"attempts" xml:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus,omitempty" xml:"responseStatus,omitempty"`
+	LastError      *string               `json:"lastError,omitempty" xml:"lastError,omitempty"`
+	ReplayOf       *int64                `json:"replayOf,omitempty" xml:"replayOf,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt" xml:"createdAt"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt,omitempty" xml:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty" xml:"deliveredAt,omitempty"`
+
+	// URL and Secret of the subscription, set only for claimed deliveries.
+	URL    string `json:"-" xml:"-"`
+	Secret string `json:"-" xml:"-"`
+}
+
+type WebhookStore interface {
+	CreateWebhook(s WebhookSubscription) (*WebhookSubscription, error)
+
+	GetWebhook(id int64) (*WebhookSubscription, error)
+
+	ListWebhooks() ([]WebhookSubscription, error)
+
+	UpdateWebhook(s WebhookSubscription) error
+
+	DeleteWebhook(id int64) error
+
+	// EnqueueWebhookDeliveries creates a pending delivery of the event for every matching subscription.
+	// Enqueueing the same event again does not create duplicates.
+	EnqueueWebhookDeliveries(event OutboxEvent) error
+
+	// ClaimWebhookDeliveries reserves pending deliveries which are due for the given lease.
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error)
+
+	// RecordWebhookAttempt stores the outcome of a delivery attempt. A nil nextAttemptAt
+	// finishes the delivery with the given status.
+	RecordWebhookAttempt(id int64, status WebhookDeliveryStatus, responseStatus *int, lastError *string, nextAttemptAt *time.Time) error
+
+	// ListWebhookDeliveries returns the most recent deliveries of the subscription, newest first.
+	ListWebhookDeliveries(subscriptionID int64, limit int) ([]WebhookDelivery, error)
+
+	GetWebhookDelivery(subscriptionID, deliveryID int64) (*WebhookDelivery, error)
+
+	// ReplayWebhookDelivery creates a new pending delivery of the same event.
+	ReplayWebhookDelivery(subscriptionID, deliveryID int64) (*WebhookDelivery, error)
+}
+
+var ErrWebhookNotFound = errors.New("Webhook with given id not found")
+var ErrWebhookDeliveryNotFound = errors.New("Webhook delivery with given id not found")
+
+func (r *RelationalDB) CreateWebhook(s WebhookSubscription) (*WebhookSubscription, error) {
+	err := r.db.QueryRow(`INSERT INTO WebhookSubscriptions (url, countryISO2, bicPrefix, secret)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, createdAt`,
+		s.URL, s.CountryISO2, s.BICPrefix, s.Secret).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *RelationalDB) GetWebhook(id int64) (*WebhookSubscription, error) {
+	var s WebhookSubscription
+	err := r.db.QueryRow(`SELECT id, url, countryISO2, bicPrefix, secret, createdAt
+		FROM WebhookSubscriptions
+		WHERE id = $1`, id).
+		Scan(&s.ID, &s.URL, &s.CountryISO2, &s.BICPrefix, &s.Secret, &s.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *RelationalDB) ListWebhooks() ([]WebhookSubscription, error) {
+	rows, err := r.db.Query(`SELECT id, url, countryISO2, bicPrefix, secret, createdAt
+		FROM WebhookSubscriptions
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []WebhookSubscription{}
+	for rows.Next() {
+		var s WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.URL, &s.CountryISO2, &s.BICPrefix, &s.Secret, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *RelationalDB) UpdateWebhook(s WebhookSubscription) error {
+	result, err := r.db.Exec(`UPDATE WebhookSubscriptions
+		SET url = $2, countryISO2 = $3, bicPrefix = $4, secret = $5
+		WHERE id = $1`,
+		s.ID, s.URL, s.CountryISO2, s.BICPrefix, s.Secret)
+	if err != nil {
+		return err
+	}
+	return checkWebhookAffected(result)
+}
+
+func (r *RelationalDB) DeleteWebhook(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM WebhookSubscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkWebhookAffected(result)
+}
+
+func checkWebhookAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *RelationalDB) EnqueueWebhookDeliveries(event OutboxEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO WebhookDeliveries (subscriptionId, eventId, event)
+		SELECT id, $1, $2
+		FROM WebhookSubscriptions
+		WHERE (countryISO2 IS NULL OR countryISO2 = $3)
+		  AND (bicPrefix IS NULL OR starts_with($4, bicPrefix))
+		ON CONFLICT (subscriptionId, eventId) WHERE replayOf IS NULL DO NOTHING`,
+		event.ID, string(payload), event.CountryISO2, event.SwiftCode)
+	return err
+}
+
+func (r *RelationalDB) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := r.db.Query(`UPDATE WebhookDeliveries d
+		SET nextAttemptAt = now() + $2 * interval '1 millisecond'
+		FROM WebhookSubscriptions s
+		WHERE s.id = d.subscriptionId
+		  AND d.id IN (
+		    SELECT id FROM WebhookDeliveries
+		    WHERE status = 'pending' AND nextAttemptAt <= now()
+		    ORDER BY id
+		    LIMIT $1
+		    FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.subscriptionId, d.eventId, d.event, d.attempts, d.createdAt, s.url, s.secret`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var event []byte
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &event, &d.Attempts, &d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Event = event
+		d.Status = DeliveryPending
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *RelationalDB) RecordWebhookAttempt(id int64, status WebhookDeliveryStatus, responseStatus *int, lastError *string, nextAttemptAt *time.Time) error {
+	_, err := r.db.Exec(`UPDATE WebhookDeliveries
+		SET status = $2, attempts = attempts + 1, responseStatus = $3, lastError = $4,
+		    nextAttemptAt = $5, deliveredAt = CASE WHEN $2 = 'delivered' THEN now() END
+		WHERE id = $1`,
+		id, string(status), responseStatus, lastError, nextAttemptAt)
+	return err
+}
+
+const webhookDeliveryColumns = `id, subscriptionId, eventId, event, status, attempts, responseStatus, lastError,
+		replayOf, createdAt, nextAttemptAt, deliveredAt`
+
+func (r *RelationalDB) ListWebhookDeliveries(subscriptionID int64, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.db.Query(`SELECT `+webhookDeliveryColumns+`
+		FROM WebhookDeliveries
+		WHERE subscriptionId = $1
+		ORDER BY id DESC
+		LIMIT $2`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *RelationalDB) GetWebhookDelivery(subscriptionID, deliveryID int64) (*WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRow(`SELECT `+webhookDeliveryColumns+`
+		FROM WebhookDeliveries
+		WHERE subscriptionId = $1 AND id = $2`, subscriptionID, deliveryID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *RelationalDB) ReplayWebhookDelivery(subscriptionID, deliveryID int64) (*WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRow(`INSERT INTO WebhookDeliveries (subscriptionId, eventId, event, replayOf)
+		SELECT subscriptionId, eventId, event, id
+		FROM WebhookDeliveries
+		WHERE subscriptionId = $1 AND id = $2
+		RETURNING `+webhookDeliveryColumns, subscriptionID, deliveryID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhookDelivery(row rowScanner) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	var event []byte
+	var status string
+	var responseStatus sql.NullInt64
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &event, &status, &d.Attempts, &responseStatus, &d.LastError,
+		&d.ReplayOf, &d.CreatedAt, &d.NextAttemptAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Event = event
+	d.Status = WebhookDeliveryStatus(status)
+	if responseStatus.Valid {
+		code := int(responseStatus.Int64)
+		d.ResponseStatus = &code
+	}
+	return &d, nil
+}
+
+type WebhookList struct {
+	XMLName  xml.Name              `json:"-" xml:"webhooks"`
+	Webhooks []WebhookSubscription `json:"webhooks" xml:"webhook"`
+}
+
+// MarshalCSV renders one row per subscription.
+func (l WebhookList) MarshalCSV() ([][]string, error) {
+	return webhookCSV(l.Webhooks...), nil
+}
+
+type WebhookDeliveryList struct {
+	XMLName    xml.Name          `json:"-" xml:"deliveries"`
+	Deliveries []WebhookDelivery `json:"deliveries" xml:"delivery"`
+}
+
+// MarshalCSV renders one row per delivery.
+func (l WebhookDeliveryList) MarshalCSV() ([][]string, error) {
+	return webhookDeliveryCSV(l.Deliveries...), nil
+}
+
+// MarshalCSV renders the subscription with its secret, which is set only when it is created.
+func (s WebhookSubscription) MarshalCSV() ([][]string, error) {
+	return webhookCSV(s), nil
+}
+
+// MarshalCSV renders the delivery, with its event as a JSON column.
+func (d WebhookDelivery) MarshalCSV() ([][]string, error) {
+	return webhookDeliveryCSV(d), nil
+}
+
+func webhookCSV(subscriptions ...WebhookSubscription) [][]string {
+	records := [][]string{{"id", "url", "countryISO2", "bicPrefix", "secret", "createdAt"}}
+	for _, s := range subscriptions {
+		records = append(records, []string{
+			strconv.FormatInt(s.ID, 10),
+			s.URL,
+			ptr.Value(s.CountryISO2),
+			ptr.Value(s.BICPrefix),
+			s.Secret,
+			s.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return records
+}
+
+func webhookDeliveryCSV(deliveries ...WebhookDelivery) [][]string {
+	records := [][]string{{"id", "subscriptionId", "eventId", "status", "attempts", "responseStatus", "lastError",
+		"replayOf", "createdAt", "nextAttemptAt", "deliveredAt", "event"}}
+	for _, d := range deliveries {
+		records = append(records, []string{
+			strconv.FormatInt(d.ID, 10),
+			strconv.FormatInt(d.SubscriptionID, 10),
+			strconv.FormatInt(d.EventID, 10),
+			string(d.Status),
+			strconv.Itoa(d.Attempts),
+			formatOptional(d.ResponseStatus, strconv.Itoa),
+			ptr.Value(d.LastError),
+			formatOptional(d.ReplayOf, func(id int64) string { return strconv.FormatInt(id, 10) }),
+			d.CreatedAt.Format(time.RFC3339Nano),
+			formatOptional(d.NextAttemptAt, func(t time.Time) string { return t.Format(time.RFC3339Nano) }),
+			formatOptional(d.DeliveredAt, func(t time.Time) string { return t.Format(time.RFC3339Nano) }),
+			string(d.Event),
+		})
+	}
+	return records
+}
+
+// formatOptional formats the value p points to, or returns an empty string if p is nil.
+func formatOptional[T any](p *T, format func(T) string) string {
+	if p == nil {
+		return ""
+	}
+	return format(*p)
+}
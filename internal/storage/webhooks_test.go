@@ -0,0 +1,70 @@
+//go:build unit
+
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+)
+
+func TestEnqueueWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+	event := OutboxEvent{ID: 7, Action: ChangeAdd, SwiftCode: "TESTPL33XXX", CountryISO2: "PL"}
+
+	mock.ExpectExec(`INSERT INTO WebhookDeliveries \(subscriptionId, eventId, event\) SELECT id, \$1, \$2 FROM WebhookSubscriptions `+
+		`WHERE \(countryISO2 IS NULL OR countryISO2 = \$3\) AND \(bicPrefix IS NULL OR starts_with\(\$4, bicPrefix\)\) `+
+		`ON CONFLICT \(subscriptionId, eventId\) WHERE replayOf IS NULL DO NOTHING`).
+		WithArgs(int64(7), sqlmock.AnyArg(), "PL", "TESTPL33XXX").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := storage.EnqueueWebhookDeliveries(event); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetWebhook_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+
+	mock.ExpectQuery(`SELECT id, url, countryISO2, bicPrefix, secret, createdAt FROM WebhookSubscriptions WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err := storage.GetWebhook(3); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+func TestDeleteWebhook_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	storage := NewRelationalDB(db)
+
+	mock.ExpectExec(`DELETE FROM WebhookSubscriptions WHERE id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := storage.DeleteWebhook(3); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pkacprzak5/bic-data-service/internal/outbox"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Dispatcher is the outbox.Publisher which turns change events into deliveries to the matching
+// subscriptions. The deliveries themselves are made by a Deliverer.
+type Dispatcher struct {
+	store storage.WebhookStore
+}
+
+func NewDispatcher(store storage.WebhookStore) *Dispatcher {
+	return &Dispatcher{store: store}
+}
+
+func (d *Dispatcher) Publish(_ context.Context, event storage.OutboxEvent) error {
+	return d.store.EnqueueWebhookDeliveries(event)
+}
+
+type Config struct {
+	BatchSize    int
+	PollInterval time.Duration
+
+	// Timeout of a single delivery; deliveries are claimed for twice as long.
+	Timeout time.Duration
+
+	// MaxAttempts is the number of failed attempts after which a delivery is marked as failed.
+	MaxAttempts int
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:    50,
+		PollInterval: time.Second,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  8,
+		MinBackoff:   5 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// Deliverer sends pending deliveries to the subscribed URLs and records the outcome of every attempt.
+type Deliverer struct {
+	store  storage.WebhookStore
+	client *http.Client
+	config Config
+	now    func() time.Time
+}
+
+func NewDeliverer(store storage.WebhookStore, client *http.Client, config Config) *Deliverer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Deliverer{store: store, client: client, config: config, now: time.Now}
+}
+
+// Run delivers webhooks until ctx is done.
+func (d *Deliverer) Run(ctx context.Context) error {
+	for {
+		processed, err := d.ProcessBatch(ctx)
+		if err != nil {
+			log.Printf("Failed to deliver webhooks: %v", err)
+		}
+
+		if err == nil && processed == d.config.BatchSize {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d.config.PollInterval):
+		}
+	}
+}
+
+// ProcessBatch claims one batch of due deliveries and attempts each of them. It returns the number
+// of claimed deliveries.
+func (d *Deliverer) ProcessBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimWebhookDeliveries(d.config.BatchSize, 2*d.config.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return len(deliveries), nil
+		}
+		if err := d.attempt(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery and records the outcome. It only returns errors of the store.
+func (d *Deliverer) attempt(ctx context.Context, delivery storage.WebhookDelivery) error {
+	responseStatus, err := d.send(ctx, delivery)
+	if err == nil {
+		return d.store.RecordWebhookAttempt(delivery.ID, storage.DeliveryDelivered, responseStatus, nil, nil)
+	}
+
+	lastError := err.Error()
+	attempts := delivery.Attempts + 1
+	if attempts >= d.config.MaxAttempts {
+		return d.store.RecordWebhookAttempt(delivery.ID, storage.DeliveryFailed, responseStatus, &lastError, nil)
+	}
+
+	next := d.now().Add(outbox.Backoff(attempts, d.config.MinBackoff, d.config.MaxBackoff))
+	return d.store.RecordWebhookAttempt(delivery.ID, storage.DeliveryPending, responseStatus, &lastError, &next)
+}
+
+// send POSTs the signed event. The response status is nil if no response was received.
+func (d *Deliverer) send(ctx context.Context, delivery storage.WebhookDelivery) (*int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Event))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Event))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(EventHeader, strconv.FormatInt(delivery.EventID, 10))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &res.StatusCode, fmt.Errorf("receiver responded with status %d", res.StatusCode)
+	}
+	return &res.StatusCode, nil
+}
@@ -0,0 +1,152 @@
+//go:build unit
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type attempt struct {
+	status         storage.WebhookDeliveryStatus
+	responseStatus *int
+	lastError      *string
+	nextAttemptAt  *time.Time
+}
+
+// fakeStore serves fixed deliveries and records the attempts. Other methods are not used by the Deliverer.
+type fakeStore struct {
+	storage.WebhookStore
+	deliveries []storage.WebhookDelivery
+	attempts   map[int64]attempt
+	enqueued   []storage.OutboxEvent
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(limit int, _ time.Duration) ([]storage.WebhookDelivery, error) {
+	if len(f.deliveries) < limit {
+		limit = len(f.deliveries)
+	}
+	claimed := f.deliveries[:limit]
+	f.deliveries = f.deliveries[limit:]
+	return claimed, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(id int64, status storage.WebhookDeliveryStatus, responseStatus *int, lastError *string, nextAttemptAt *time.Time) error {
+	f.attempts[id] = attempt{status, responseStatus, lastError, nextAttemptAt}
+	return nil
+}
+
+func (f *fakeStore) EnqueueWebhookDeliveries(event storage.OutboxEvent) error {
+	f.enqueued = append(f.enqueued, event)
+	return nil
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func TestDeliverer_ProcessBatch(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := json.RawMessage(`{"id":7,"action":"add","swiftCode":"TESTPLPWXXX"}`)
+
+	var requests []received
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{r.Header, body})
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	store := &fakeStore{
+		attempts: map[int64]attempt{},
+		deliveries: []storage.WebhookDelivery{
+			{ID: 1, EventID: 7, Event: event, URL: receiver.URL + "/ok", Secret: "s3cret"},
+			{ID: 2, EventID: 7, Event: event, URL: receiver.URL + "/unavailable", Secret: "s3cret", Attempts: 1},
+			{ID: 3, EventID: 7, Event: event, URL: receiver.URL + "/unavailable", Secret: "s3cret", Attempts: 2},
+		},
+	}
+
+	deliverer := NewDeliverer(store, receiver.Client(), Config{
+		BatchSize: 10, Timeout: time.Second, MaxAttempts: 3, MinBackoff: time.Second, MaxBackoff: time.Minute,
+	})
+	deliverer.now = func() time.Time { return now }
+
+	processed, err := deliverer.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, processed)
+
+	require.Len(t, requests, 3)
+	first := requests[0]
+	assert.JSONEq(t, string(event), string(first.body))
+	assert.Equal(t, "application/json", first.header.Get("Content-Type"))
+	assert.Equal(t, "1", first.header.Get(DeliveryHeader))
+	assert.Equal(t, "7", first.header.Get(EventHeader))
+	assert.Equal(t, "1772366400", first.header.Get(TimestampHeader))
+	assert.True(t, Verify("s3cret", first.header.Get(TimestampHeader), first.header.Get(SignatureHeader), first.body))
+	assert.False(t, Verify("other", first.header.Get(TimestampHeader), first.header.Get(SignatureHeader), first.body))
+
+	delivered := store.attempts[1]
+	assert.Equal(t, storage.DeliveryDelivered, delivered.status)
+	assert.Equal(t, http.StatusNoContent, *delivered.responseStatus)
+	assert.Nil(t, delivered.nextAttemptAt)
+
+	retried := store.attempts[2]
+	assert.Equal(t, storage.DeliveryPending, retried.status)
+	assert.Equal(t, http.StatusServiceUnavailable, *retried.responseStatus)
+	assert.Equal(t, "receiver responded with status 503", *retried.lastError)
+	assert.Equal(t, now.Add(2*time.Second), *retried.nextAttemptAt)
+
+	failed := store.attempts[3]
+	assert.Equal(t, storage.DeliveryFailed, failed.status)
+	assert.Nil(t, failed.nextAttemptAt)
+}
+
+func TestDeliverer_UnreachableReceiver(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	receiver.Close()
+
+	store := &fakeStore{
+		attempts:   map[int64]attempt{},
+		deliveries: []storage.WebhookDelivery{{ID: 1, Event: json.RawMessage(`{}`), URL: receiver.URL}},
+	}
+
+	_, err := NewDeliverer(store, nil, Config{BatchSize: 10, Timeout: time.Second, MaxAttempts: 3, MinBackoff: time.Second}).
+		ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, storage.DeliveryPending, store.attempts[1].status)
+	assert.Nil(t, store.attempts[1].responseStatus)
+	assert.NotNil(t, store.attempts[1].lastError)
+}
+
+func TestDispatcher(t *testing.T) {
+	store := &fakeStore{}
+	event := storage.OutboxEvent{ID: 7, SwiftCode: "TESTPLPWXXX"}
+
+	require.NoError(t, NewDispatcher(store).Publish(context.Background(), event))
+	assert.Equal(t, []storage.OutboxEvent{event}, store.enqueued)
+}
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1772366400, 0)
+	signature := Sign("s3cret", timestamp, []byte(`{"id":7}`))
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("s3cret", "1772366400", signature, []byte(`{"id":7}`)))
+	assert.False(t, Verify("s3cret", "1772366401", signature, []byte(`{"id":7}`)))
+	assert.False(t, Verify("s3cret", "1772366400", signature, []byte(`{"id":8}`)))
+	assert.False(t, Verify("s3cret", "yesterday", signature, []byte(`{"id":7}`)))
+}
@@ -0,0 +1,37 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+)
+
+// Sign returns the value of the signature header for a delivery sent at timestamp. The HMAC-SHA256 of
+// "<unix timestamp>.<body>" keyed with the subscription secret is sent as "sha256=<hex>", so that
+// receivers can check both the origin and the age of a delivery.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body sent at the given Unix timestamp.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	expected := Sign(secret, time.Unix(seconds, 0), body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
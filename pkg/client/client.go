@@ -0,0 +1,257 @@
+// Package client is the Go client of the BIC Data Service REST API.
+//
+//	c, err := client.New("http://localhost:8080")
+//	...
+//	bank, err := c.GetSwiftCode(ctx, "BREXPLPWXXX")
+//	if errors.Is(err, client.ErrSwiftCodeNotFound) {
+//		...
+//	}
+//
+// Requests are retried with exponential backoff and jitter when the service is unavailable,
+// see RetryPolicy.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures retries of requests which failed because of a network error or because the
+// service was unavailable (429, 502, 503 and 504). Waits grow exponentially from MinBackoff up to
+// MaxBackoff, and each of them is a random duration below that bound, so that many clients do not
+// retry in lockstep. A Retry-After header sent by the service takes precedence.
+//
+// POST requests are retried only if they carry an Idempotency-Key, which the client adds to
+// AddSwiftCode.
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 4, MinBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+}
+
+// backoff returns the wait before the given retry, counted from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	bound := p.MaxBackoff
+	if retry-1 < 32 {
+		if exp := p.MinBackoff << (retry - 1); exp > 0 && exp < bound {
+			bound = exp
+		}
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(mathrand.Int64N(int64(bound) + 1))
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retry      RetryPolicy
+	header     http.Header
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests; http.DefaultClient is used otherwise.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy. MaxAttempts of 1 disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithHeader adds a header to every request, e.g. for authentication by a proxy in front of the service.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// New creates a client of the service at baseURL, e.g. http://localhost:8080. It fails if baseURL is
+// not an absolute URL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL %q: %w", baseURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy(),
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request describes a call of the API. path is relative to /v1 and may contain a query; url replaces
+// it with an absolute URL announced by the service, such as the next page of a list.
+type request struct {
+	method string
+	path   string
+	url    string
+	header http.Header
+	body   any
+}
+
+// do sends the request, retrying it according to the retry policy, and decodes a successful JSON
+// response into out, if it is not nil. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, req request, out any) (*http.Response, error) {
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotModified {
+		return res, newError(res)
+	}
+
+	if out != nil && res.StatusCode != http.StatusNotModified {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return res, fmt.Errorf("client: decoding response of %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return res, nil
+}
+
+// send performs the request with retries. The caller must close the body of the response.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("client: encoding request: %w", err)
+		}
+	}
+
+	retryable := req.method != http.MethodPost || req.header.Get("Idempotency-Key") != ""
+	for attempt := 1; ; attempt++ {
+		httpReq, err := c.newRequest(ctx, req, body)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.httpClient.Do(httpReq)
+		if !retryable || attempt >= c.retry.MaxAttempts || !shouldRetry(ctx, res, err) {
+			return res, err
+		}
+
+		wait := c.retry.backoff(attempt)
+		if res != nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, req request, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	target := req.url
+	if target == "" {
+		target = c.baseURL.String() + "/v1" + req.path
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+
+	for key, values := range c.header {
+		httpReq.Header[key] = values
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	return httpReq, nil
+}
+
+func shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		// the caller gave up, which is not a failure of the service
+		return ctx.Err() == nil
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// newIdempotencyKey returns a random key, so that retries of a POST are not applied twice.
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("client: generating idempotency key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// messageResponse is the body of the service's messages and errors.
+type messageResponse struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
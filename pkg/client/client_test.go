@@ -0,0 +1,626 @@
+//go:build unit
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/app"
+	"github.com/pkacprzak5/bic-data-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryStorage is a storage.Storage keeping the records in memory, with the same versioning rules
+// as storage.RelationalDB.
+type memoryStorage struct {
+	mu    sync.Mutex
+	banks map[string]storage.Bank
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{banks: make(map[string]storage.Bank)}
+}
+
+func (m *memoryStorage) GetSwiftCodeDetails(swiftCode string) (*storage.Bank, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bank, ok := m.banks[swiftCode]
+	if !ok {
+		return nil, storage.ErrSwiftCodeNotFound
+	}
+	if *bank.IsHeadquarter {
+		bank.Branches = []storage.BankBranch{}
+		for _, code := range m.sortedCodes() {
+			if code != swiftCode && strings.HasPrefix(code, swiftCode[:8]) {
+				bank.Branches = append(bank.Branches, branchOf(m.banks[code]))
+			}
+		}
+	}
+	return &bank, nil
+}
+
+func (m *memoryStorage) GetSwiftCodesForCountry(iso2Code string) (*storage.CountryBanks, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	countryBanks := &storage.CountryBanks{CountryISO2: iso2Code}
+	for _, code := range m.sortedCodes() {
+		if bank := m.banks[code]; *bank.CountryISO2 == iso2Code {
+			countryBanks.CountryName = *bank.CountryName
+			countryBanks.SwiftCodes = append(countryBanks.SwiftCodes, branchOf(bank))
+		}
+	}
+	if len(countryBanks.SwiftCodes) == 0 {
+		return nil, storage.ErrISO2CodeNotFound
+	}
+	return countryBanks, nil
+}
+
+func (m *memoryStorage) GetSwiftCodeDetailsAsOf(swiftCode string, _ time.Time) (*storage.Bank, error) {
+	return m.GetSwiftCodeDetails(swiftCode)
+}
+
+func (m *memoryStorage) GetSwiftCodesForCountryAsOf(iso2Code string, _ time.Time) (*storage.CountryBanks, error) {
+	return m.GetSwiftCodesForCountry(iso2Code)
+}
+
+func (m *memoryStorage) AddSwiftCodeEntry(b storage.Bank) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.banks[*b.SwiftCode]; ok {
+		return storage.ErrSwiftCodeExists
+	}
+	b.Version = 1
+	m.banks[*b.SwiftCode] = b
+	return nil
+}
+
+func (m *memoryStorage) UpdateSwiftCodeEntry(b storage.Bank, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.banks[*b.SwiftCode]
+	if !ok {
+		return storage.ErrSwiftCodeNotFound
+	}
+	if current.Version != version {
+		return storage.ErrVersionMismatch
+	}
+	b.Version = version + 1
+	m.banks[*b.SwiftCode] = b
+	return nil
+}
+
+func (m *memoryStorage) DeleteSwiftCodeEntry(swiftCode string, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.banks[swiftCode]
+	if !ok {
+		return storage.ErrSwiftCodeNotFound
+	}
+	if current.Version != version {
+		return storage.ErrVersionMismatch
+	}
+	delete(m.banks, swiftCode)
+	return nil
+}
+
+// sortedCodes must be called with m.mu held.
+func (m *memoryStorage) sortedCodes() []string {
+	codes := make([]string, 0, len(m.banks))
+	for code := range m.banks {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func branchOf(b storage.Bank) storage.BankBranch {
+	return storage.BankBranch{
+		Address:       *b.Address,
+		BankName:      *b.BankName,
+		CountryISO2:   *b.CountryISO2,
+		IsHeadquarter: *b.IsHeadquarter,
+		SwiftCode:     *b.SwiftCode,
+		Version:       b.Version,
+	}
+}
+
+func newBank(swiftCode string) Bank {
+	isHeadquarter := strings.HasSuffix(swiftCode, "XXX")
+	countryName := map[string]string{"PL": "POLAND", "DE": "GERMANY"}[swiftCode[4:6]]
+	return Bank{
+		Address:       strPtr("UL. SENATORSKA 18"),
+		BankName:      strPtr("BRE BANK SA"),
+		CountryISO2:   strPtr(swiftCode[4:6]),
+		CountryName:   &countryName,
+		IsHeadquarter: &isHeadquarter,
+		SwiftCode:     &swiftCode,
+	}
+}
+
+// storageBank converts a bank of the client to the one of the service, to seed a storage directly.
+func storageBank(b Bank) storage.Bank {
+	return storage.Bank{
+		Address:       b.Address,
+		BankName:      b.BankName,
+		CountryISO2:   b.CountryISO2,
+		CountryName:   b.CountryName,
+		IsHeadquarter: b.IsHeadquarter,
+		SwiftCode:     b.SwiftCode,
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// newTestServer serves the real REST API on top of the given storage.
+func newTestServer(t *testing.T, s storage.Storage, opts ...app.Option) *httptest.Server {
+	server := httptest.NewServer(app.NewAPIServer("", s, opts...).Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newClient creates a client of the test server at baseURL.
+func newClient(t *testing.T, baseURL string, opts ...Option) *Client {
+	c, err := New(baseURL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "localhost:8080", "http://[::1"} {
+		_, err := New(baseURL)
+		assert.Error(t, err, baseURL)
+	}
+}
+
+var fastRetries = WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+func TestClient_SwiftCodes(t *testing.T) {
+	c := newClient(t, newTestServer(t, newMemoryStorage()).URL, fastRetries)
+	ctx := context.Background()
+
+	require.NoError(t, c.AddSwiftCode(ctx, newBank("BREXPLPWXXX")))
+	require.NoError(t, c.AddSwiftCode(ctx, newBank("BREXPLPWWAL")))
+
+	bank, etag, err := c.GetSwiftCodeWithETag(ctx, "BREXPLPWXXX")
+	require.NoError(t, err)
+	assert.Equal(t, "POLAND", *bank.CountryName)
+	require.Len(t, bank.Branches, 1)
+	assert.Equal(t, "BREXPLPWWAL", bank.Branches[0].SwiftCode)
+	assert.NotEmpty(t, etag)
+
+	t.Run("update", func(t *testing.T) {
+		branch := newBank("BREXPLPWWAL")
+		branch.BankName = strPtr("MBANK SA")
+
+		newETag, err := c.UpdateSwiftCode(ctx, branch, `"1"`)
+		require.NoError(t, err)
+		assert.Equal(t, `"2"`, newETag)
+
+		_, err = c.UpdateSwiftCode(ctx, branch, `"1"`)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		_, err = c.UpdateSwiftCode(ctx, branch, "")
+		assert.ErrorIs(t, err, ErrETagRequired)
+
+		updated, err := c.GetSwiftCode(ctx, "BREXPLPWWAL")
+		require.NoError(t, err)
+		assert.Equal(t, "MBANK SA", *updated.BankName)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		err := c.AddSwiftCode(ctx, newBank("BREXPLPWXXX"))
+		assert.ErrorIs(t, err, ErrSwiftCodeExists)
+		assert.ErrorIs(t, err, ErrConflict)
+
+		invalid := newBank("BREXPLPWXXX")
+		invalid.CountryName = strPtr("GERMANY")
+		err = c.AddSwiftCode(ctx, invalid)
+		assert.ErrorIs(t, err, ErrBadRequest)
+		var apiErr *Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "countryName does not match ISO2 code", apiErr.Message)
+
+		_, err = c.GetSwiftCode(ctx, "BREXDEFFXXX")
+		assert.ErrorIs(t, err, ErrSwiftCodeNotFound)
+		assert.ErrorIs(t, err, ErrNotFound)
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "swift_code_not_found", apiErr.Code)
+
+		_, err = c.GetCountry(ctx, "DE")
+		assert.ErrorIs(t, err, ErrISO2CodeNotFound)
+	})
+
+	t.Run("country", func(t *testing.T) {
+		countryBanks, err := c.GetCountry(ctx, "PL")
+		require.NoError(t, err)
+		assert.Equal(t, "POLAND", countryBanks.CountryName)
+		assert.Len(t, countryBanks.SwiftCodes, 2)
+
+		asOf, err := c.GetCountryAsOf(ctx, "PL", time.Now())
+		require.NoError(t, err)
+		assert.Len(t, asOf.SwiftCodes, 2)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.ErrorIs(t, c.DeleteSwiftCode(ctx, "BREXPLPWWAL", ""), ErrETagRequired)
+
+		require.NoError(t, c.DeleteSwiftCode(ctx, "BREXPLPWWAL", AnyETag))
+
+		_, err := c.GetSwiftCode(ctx, "BREXPLPWWAL")
+		assert.ErrorIs(t, err, ErrSwiftCodeNotFound)
+
+		err = c.DeleteSwiftCode(ctx, "BREXPLPWWAL", AnyETag)
+		assert.ErrorIs(t, err, ErrSwiftCodeNotFound)
+	})
+}
+
+func TestClient_AddSwiftCodes(t *testing.T) {
+	c := newClient(t, newTestServer(t, newSQLiteStorage(t)).URL)
+	ctx := context.Background()
+
+	require.NoError(t, c.AddSwiftCodes(ctx, []Bank{newBank("BREXPLPWXXX"), newBank("BREXPLPWWAL")}))
+	countryBanks, err := c.GetCountry(ctx, "PL")
+	require.NoError(t, err)
+	assert.Len(t, countryBanks.SwiftCodes, 2)
+
+	err = c.AddSwiftCodes(ctx, []Bank{newBank("DEUTDEFFXXX"), newBank("BREXPLPWXXX")})
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = c.GetSwiftCode(ctx, "DEUTDEFFXXX")
+	assert.ErrorIs(t, err, ErrSwiftCodeNotFound, "no bank of a failed batch is added")
+
+	t.Run("storage without batches", func(t *testing.T) {
+		c := newClient(t, newTestServer(t, newMemoryStorage()).URL)
+		err := c.AddSwiftCodes(ctx, []Bank{newBank("BREXPLPWXXX")})
+		assert.ErrorIs(t, err, ErrNotSupported)
+	})
+}
+
+func TestClient_CountrySwiftCodes(t *testing.T) {
+	store := newMemoryStorage()
+	for _, code := range []string{"BREXPLPWXXX", "BREXPLPWWAL", "ALBPPLPWXXX", "ALBPPLPWCUS", "PKOPPLPWXXX"} {
+		require.NoError(t, store.AddSwiftCodeEntry(storageBank(newBank(code))))
+	}
+
+	var requests atomic.Int32
+	api := app.NewAPIServer("", store).Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	c := newClient(t, server.URL)
+
+	var codes []string
+	for branch, err := range c.CountrySwiftCodes(context.Background(), "PL", 2) {
+		require.NoError(t, err)
+		codes = append(codes, branch.SwiftCode)
+	}
+	assert.Equal(t, []string{"ALBPPLPWCUS", "ALBPPLPWXXX", "BREXPLPWWAL", "BREXPLPWXXX", "PKOPPLPWXXX"}, codes)
+	assert.Equal(t, int32(3), requests.Load())
+
+	t.Run("stops fetching when the loop ends", func(t *testing.T) {
+		requests.Store(0)
+		for range c.CountrySwiftCodes(context.Background(), "PL", 2) {
+			break
+		}
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("default page size", func(t *testing.T) {
+		codes = nil
+		for branch, err := range c.CountrySwiftCodes(context.Background(), "PL", 0) {
+			require.NoError(t, err)
+			codes = append(codes, branch.SwiftCode)
+		}
+		assert.Len(t, codes, 5)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var errs []error
+		for _, err := range c.CountrySwiftCodes(context.Background(), "DE", 2) {
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrISO2CodeNotFound)
+	})
+}
+
+// flakyHandler answers the first failures requests with 503 Service Unavailable.
+func flakyHandler(failures int32, next http.Handler) (http.Handler, *atomic.Int32) {
+	var attempts atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), &attempts
+}
+
+func TestClient_Retries(t *testing.T) {
+	store := newMemoryStorage()
+	require.NoError(t, store.AddSwiftCodeEntry(storageBank(newBank("BREXPLPWXXX"))))
+	api := app.NewAPIServer("", store).Handler()
+	ctx := context.Background()
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		handler, attempts := flakyHandler(2, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		bank, err := newClient(t, server.URL, fastRetries).GetSwiftCode(ctx, "BREXPLPWXXX")
+		require.NoError(t, err)
+		assert.Equal(t, "BREXPLPWXXX", *bank.SwiftCode)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		handler, attempts := flakyHandler(10, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		_, err := newClient(t, server.URL, fastRetries).GetSwiftCode(ctx, "BREXPLPWXXX")
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("retries POST with an idempotency key", func(t *testing.T) {
+		handler, attempts := flakyHandler(1, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		require.NoError(t, newClient(t, server.URL, fastRetries).AddSwiftCode(ctx, newBank("BREXPLPWWAL")))
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("does not retry POST without an idempotency key", func(t *testing.T) {
+		handler, attempts := flakyHandler(1, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		_, err := newClient(t, server.URL, fastRetries).ReplayWebhookDelivery(ctx, 1, 2)
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		handler, attempts := flakyHandler(0, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		_, err := newClient(t, server.URL, fastRetries).GetSwiftCode(ctx, "BREXDEFFXXX")
+		assert.ErrorIs(t, err, ErrSwiftCodeNotFound)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		handler, _ := flakyHandler(10, api)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		slow := WithRetryPolicy(RetryPolicy{MaxAttempts: 10, MinBackoff: time.Second, MaxBackoff: time.Second})
+
+		start := time.Now()
+		_, err := newClient(t, server.URL, slow).GetSwiftCode(ctx, "BREXPLPWXXX")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for retry, bound := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 8: time.Second, 100: time.Second} {
+		for range 50 {
+			wait := policy.backoff(retry)
+			assert.GreaterOrEqual(t, wait, time.Duration(0))
+			assert.LessOrEqual(t, wait, bound, "retry %d", retry)
+		}
+	}
+}
+
+func TestClient_Changes(t *testing.T) {
+	broker := app.NewEventBroker(10)
+	for _, change := range []storage.ChangeNotification{
+		{EventID: 1, Action: storage.ChangeAdd, SwiftCode: "BREXPLPWXXX", CountryISO2: "PL"},
+		{EventID: 2, Action: storage.ChangeAdd, SwiftCode: "BREXDEFFXXX", CountryISO2: "DE"},
+		{EventID: 3, Action: storage.ChangeRemove, SwiftCode: "BREXPLPWWAL", CountryISO2: "PL"},
+	} {
+		broker.HandleChange(change)
+	}
+	c := newClient(t, newTestServer(t, newMemoryStorage(), app.WithEvents(broker)).URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []Event
+	for event, err := range c.Changes(ctx, "PL", "1") {
+		require.NoError(t, err)
+		events = append(events, event)
+		break
+	}
+	require.Len(t, events, 1)
+	assert.Equal(t, "3", events[0].ID)
+	assert.Equal(t, EventChange, events[0].Type)
+	assert.Equal(t, "BREXPLPWWAL", events[0].Change.SwiftCode)
+	assert.Equal(t, ChangeRemove, events[0].Change.Action)
+
+	t.Run("reset", func(t *testing.T) {
+		for event, err := range c.Changes(ctx, "", "99") {
+			require.NoError(t, err)
+			assert.Equal(t, EventReset, event.Type)
+			assert.Nil(t, event.Change)
+			break
+		}
+	})
+
+	t.Run("invalid country", func(t *testing.T) {
+		for _, err := range c.Changes(ctx, "pl", "") {
+			assert.ErrorIs(t, err, ErrBadRequest)
+		}
+	})
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("POST /v1/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		var subscription WebhookSubscription
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&subscription))
+		subscription.ID = 7
+		subscription.Secret = "generated"
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(subscription)
+	})
+	router.HandleFunc("GET /v1/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"webhooks": []WebhookSubscription{{ID: 7, URL: "https://example.com"}}})
+	})
+	router.HandleFunc("GET /v1/webhooks/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7", r.PathValue("id"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		json.NewEncoder(w).Encode(map[string]any{"deliveries": []WebhookDelivery{{ID: 3, SubscriptionID: 7}}})
+	})
+	router.HandleFunc("GET /v1/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(storage.Response{Message: "Webhook not found", Code: "webhook_not_found"})
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := newClient(t, server.URL)
+	ctx := context.Background()
+
+	created, err := c.CreateWebhook(ctx, WebhookSubscription{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.ID)
+	assert.Equal(t, "generated", created.Secret)
+
+	subscriptions, err := c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "https://example.com", subscriptions[0].URL)
+
+	deliveries, err := c.ListWebhookDeliveries(ctx, 7, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int64(3), deliveries[0].ID)
+
+	_, err = c.GetWebhook(ctx, 8)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.False(t, errors.Is(err, ErrSwiftCodeNotFound))
+}
+
+// newSQLiteStorage returns a storage in a temporary SQLite database, for the endpoints which the
+// memory storage does not implement.
+func newSQLiteStorage(t *testing.T) storage.Storage {
+	s, err := storage.NewSQLiteStorage("sqlite://" + filepath.Join(t.TempDir(), "bic.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Db.Close() })
+	db, err := s.Init()
+	require.NoError(t, err)
+	return storage.NewSQLiteDB(db)
+}
+
+func TestClient_Stats(t *testing.T) {
+	c := newClient(t, newTestServer(t, newSQLiteStorage(t)).URL)
+	ctx := context.Background()
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.SwiftCodes)
+	assert.Empty(t, stats.Countries)
+	assert.Nil(t, stats.LastModified)
+
+	for _, swiftCode := range []string{"BREXPLPWXXX", "BREXPLPWWAL", "BREXPLPWKRK", "DEUTDEFFXXX"} {
+		require.NoError(t, c.AddSwiftCode(ctx, newBank(swiftCode)))
+	}
+
+	stats, err = c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.SwiftCodes)
+	assert.Equal(t, 2, stats.Headquarters)
+	assert.Equal(t, 2, stats.Branches)
+	assert.Equal(t, 2, stats.Institutions)
+	assert.NotNil(t, stats.LastModified)
+	assert.Equal(t, []CountryStats{
+		{CountryISO2: "DE", CountryName: "GERMANY", SwiftCodes: 1, Headquarters: 1, Branches: 0, Institutions: 1},
+		{CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: 3, Headquarters: 1, Branches: 2, Institutions: 1},
+	}, stats.Countries)
+	assert.Equal(t, []BranchNetwork{
+		{Institution: "BREXPLPW", BankName: "BRE BANK SA", CountryISO2: "PL", Branches: 2},
+	}, stats.LargestBranchNetworks)
+}
+
+func TestClient_Countries(t *testing.T) {
+	c := newClient(t, newTestServer(t, newSQLiteStorage(t)).URL)
+	ctx := context.Background()
+
+	for _, swiftCode := range []string{"BREXPLPWXXX", "BREXPLPWWAL", "DEUTDEFFXXX"} {
+		require.NoError(t, c.AddSwiftCode(ctx, newBank(swiftCode)))
+	}
+
+	countries, err := c.ListCountries(ctx)
+	require.NoError(t, err)
+	require.Len(t, countries, 2)
+	assert.Equal(t, "DE", countries[0].CountryISO2)
+	assert.Equal(t, CountryStats{CountryISO2: "PL", CountryName: "POLAND", SwiftCodes: 2, Headquarters: 1, Branches: 1, Institutions: 1}, countries[1])
+
+	country, err := c.GetCountryInfo(ctx, "PL")
+	require.NoError(t, err)
+	assert.Equal(t, "POL", country.CountryISO3)
+	assert.Equal(t, "616", country.NumericCode)
+	assert.Equal(t, "Poland", country.Name)
+	assert.Equal(t, 2, country.SwiftCodes)
+
+	_, err = c.GetCountryInfo(ctx, "XX")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestClient_Institutions(t *testing.T) {
+	c := newClient(t, newTestServer(t, newSQLiteStorage(t)).URL)
+	ctx := context.Background()
+
+	for _, swiftCode := range []string{"BREXPLPWXXX", "BREXPLPWWAL", "ALBPPLPWKRK", "DEUTDEFFXXX"} {
+		require.NoError(t, c.AddSwiftCode(ctx, newBank(swiftCode)))
+	}
+
+	institution, err := c.GetInstitution(ctx, "ALBPPLPW")
+	require.NoError(t, err)
+	assert.Nil(t, institution.Headquarter)
+	require.Len(t, institution.Branches, 1)
+	assert.Equal(t, "ALBPPLPWKRK", institution.Branches[0].SwiftCode)
+
+	_, err = c.GetInstitution(ctx, "BREXDEFF")
+	assert.ErrorIs(t, err, ErrInstitutionNotFound)
+
+	institutions, err := c.ListInstitutions(ctx, "PL")
+	require.NoError(t, err)
+	assert.Equal(t, []InstitutionSummary{
+		{BIC8: "ALBPPLPW", BankName: "BRE BANK SA", CountryISO2: "PL", HasHeadquarter: false, Branches: 1},
+		{BIC8: "BREXPLPW", BankName: "BRE BANK SA", CountryISO2: "PL", HasHeadquarter: true, Branches: 1},
+	}, institutions)
+
+	institutions, err = c.ListInstitutions(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, institutions, 3)
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors reported by the service. They can be matched with errors.Is against any error returned by
+// the client, e.g. errors.Is(err, client.ErrSwiftCodeNotFound).
+var (
+	ErrSwiftCodeNotFound       = errors.New("Given Swift Code not found")
+	ErrISO2CodeNotFound        = errors.New("Country with given ISO2 Code does not have any swift codes")
+	ErrInstitutionNotFound     = errors.New("Institution with given BIC8 not found")
+	ErrSwiftCodeExists         = errors.New("Given Swift Code already exists in database")
+	ErrVersionMismatch         = errors.New("Record has been modified by someone else")
+	ErrWebhookNotFound         = errors.New("Webhook with given id not found")
+	ErrWebhookDeliveryNotFound = errors.New("Webhook delivery with given id not found")
+)
+
+// Errors matching a class of responses, for the cases not covered by a more specific error above.
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrNotSupported         = errors.New("not supported by the service")
+	ErrUnavailable          = errors.New("service unavailable")
+)
+
+// serviceErrors are recognised by the code of the error response.
+var serviceErrors = map[string]error{
+	"swift_code_not_found":       ErrSwiftCodeNotFound,
+	"iso2_code_not_found":        ErrISO2CodeNotFound,
+	"institution_not_found":      ErrInstitutionNotFound,
+	"swift_code_exists":          ErrSwiftCodeExists,
+	"version_mismatch":           ErrVersionMismatch,
+	"webhook_not_found":          ErrWebhookNotFound,
+	"webhook_delivery_not_found": ErrWebhookDeliveryNotFound,
+}
+
+// Error is an error response of the service. Code identifies the error, it is empty for errors
+// which have no specific code.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Unwrap returns the errors the response stands for: the specific error of the service, if it is
+// known, and the class of the status code.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if err, ok := serviceErrors[e.Code]; ok {
+		errs = append(errs, err)
+	}
+
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		errs = append(errs, ErrBadRequest)
+	case http.StatusNotFound:
+		errs = append(errs, ErrNotFound)
+	case http.StatusConflict:
+		errs = append(errs, ErrConflict)
+	case http.StatusPreconditionFailed:
+		errs = append(errs, ErrVersionMismatch)
+	case http.StatusPreconditionRequired:
+		errs = append(errs, ErrPreconditionRequired)
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		errs = append(errs, ErrNotSupported)
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		errs = append(errs, ErrUnavailable)
+	}
+	return errs
+}
+
+func newError(res *http.Response) *Error {
+	var response messageResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil || response.Message == "" {
+		response.Message = http.StatusText(res.StatusCode)
+	}
+	return &Error{StatusCode: res.StatusCode, Code: response.Code, Message: response.Message}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Event is a message of the change stream. Reset events carry no Change; they tell the client that it
+// may have missed changes and should reload the data it depends on.
+type Event struct {
+	ID     string
+	Type   string
+	Change *ChangeNotification
+}
+
+const (
+	EventChange = "change"
+	EventReset  = "reset"
+)
+
+// Changes streams the changes of the dataset, optionally of one country, until ctx is cancelled or the
+// service closes the stream. With lastEventID the stream resumes after that event. The HTTP client
+// must not have a timeout shorter than the expected duration of the stream.
+func (c *Client) Changes(ctx context.Context, iso2Code, lastEventID string) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		path := "/swift-codes/events"
+		if iso2Code != "" {
+			path += "?" + url.Values{"country": {iso2Code}}.Encode()
+		}
+		header := http.Header{"Accept": {"text/event-stream"}}
+		if lastEventID != "" {
+			header.Set("Last-Event-ID", lastEventID)
+		}
+
+		res, err := c.send(ctx, request{method: http.MethodGet, path: path, header: header})
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			yield(Event{}, newError(res))
+			return
+		}
+
+		var event Event
+		var data strings.Builder
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Type = value
+			case "data":
+				data.WriteString(value)
+			case "":
+				// a blank line ends an event, a line starting with a colon is a comment
+				if scanner.Text() != "" || event.Type == "" {
+					continue
+				}
+				if event.Type == EventChange {
+					event.Change = &ChangeNotification{}
+					if err := json.Unmarshal([]byte(data.String()), event.Change); err != nil {
+						yield(Event{}, fmt.Errorf("client: decoding change event %s: %w", event.ID, err))
+						return
+					}
+				}
+				if !yield(event, nil) {
+					return
+				}
+				event = Event{}
+				data.Reset()
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(Event{}, fmt.Errorf("client: reading change stream: %w", err))
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/pkacprzak5/bic-data-service/internal/ptr"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// GetSwiftCode returns a bank; a headquarter includes its branches.
+func (c *Client) GetSwiftCode(ctx context.Context, swiftCode string) (*Bank, error) {
+	bank, _, err := c.GetSwiftCodeWithETag(ctx, swiftCode)
+	return bank, err
+}
+
+// GetSwiftCodeWithETag also returns the ETag of the bank, which UpdateSwiftCode and DeleteSwiftCode
+// use to make sure that the record has not been modified in the meantime.
+func (c *Client) GetSwiftCodeWithETag(ctx context.Context, swiftCode string) (*Bank, string, error) {
+	var bank Bank
+	res, err := c.do(ctx, request{method: http.MethodGet, path: "/swift-codes/" + url.PathEscape(swiftCode)}, &bank)
+	if err != nil {
+		return nil, "", err
+	}
+	return &bank, res.Header.Get("ETag"), nil
+}
+
+// GetSwiftCodeAsOf returns the bank as it was at the given instant.
+func (c *Client) GetSwiftCodeAsOf(ctx context.Context, swiftCode string, asOf time.Time) (*Bank, error) {
+	var bank Bank
+	_, err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/swift-codes/" + url.PathEscape(swiftCode) + asOfQuery(asOf),
+	}, &bank)
+	if err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// GetCountry returns all SWIFT codes of a country in a single response. CountrySwiftCodes pages
+// through them instead.
+func (c *Client) GetCountry(ctx context.Context, iso2Code string) (*CountryBanks, error) {
+	var countryBanks CountryBanks
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/swift-codes/country/" + url.PathEscape(iso2Code)}, &countryBanks)
+	if err != nil {
+		return nil, err
+	}
+	return &countryBanks, nil
+}
+
+// GetCountryAsOf returns the SWIFT codes of a country as they were at the given instant.
+func (c *Client) GetCountryAsOf(ctx context.Context, iso2Code string, asOf time.Time) (*CountryBanks, error) {
+	var countryBanks CountryBanks
+	_, err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/swift-codes/country/" + url.PathEscape(iso2Code) + asOfQuery(asOf),
+	}, &countryBanks)
+	if err != nil {
+		return nil, err
+	}
+	return &countryBanks, nil
+}
+
+// CountrySwiftCodes iterates over the SWIFT codes of a country ordered by SWIFT code, fetching pages
+// of pageSize codes as they are needed (the service's default if pageSize is 0). Iteration stops
+// after the first error.
+//
+//	for branch, err := range c.CountrySwiftCodes(ctx, "PL", 500) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) CountrySwiftCodes(ctx context.Context, iso2Code string, pageSize int) iter.Seq2[BankBranch, error] {
+	return func(yield func(BankBranch, error) bool) {
+		// an empty after asks for the first page of the service's default size
+		query := url.Values{"after": {""}}
+		if pageSize > 0 {
+			query = url.Values{"limit": {strconv.Itoa(pageSize)}}
+		}
+		req := request{method: http.MethodGet, path: "/swift-codes/country/" + url.PathEscape(iso2Code) + "?" + query.Encode()}
+
+		for {
+			var page CountryBanks
+			res, err := c.do(ctx, req, &page)
+			if err != nil {
+				yield(BankBranch{}, err)
+				return
+			}
+
+			for _, branch := range page.SwiftCodes {
+				if !yield(branch, nil) {
+					return
+				}
+			}
+
+			next, ok := nextPage(res)
+			if !ok {
+				return
+			}
+			req = request{method: http.MethodGet, url: next}
+		}
+	}
+}
+
+// AddSwiftCode adds a bank. The request carries a random Idempotency-Key, so that it is safely retried.
+func (c *Client) AddSwiftCode(ctx context.Context, bank Bank) error {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/swift-codes",
+		header: http.Header{"Idempotency-Key": {key}},
+		body:   bank,
+	}, nil)
+	return err
+}
+
+// MaxBatchSize is the largest number of banks AddSwiftCodes accepts.
+const MaxBatchSize = 1000
+
+// AddSwiftCodes adds up to MaxBatchSize banks in a single transaction: if any of them is invalid or
+// already exists, none is added. Like AddSwiftCode, the request carries a random Idempotency-Key.
+// A service whose storage cannot add batches answers with ErrNotSupported.
+func (c *Client) AddSwiftCodes(ctx context.Context, banks []Bank) error {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/swift-codes/batch",
+		header: http.Header{"Idempotency-Key": {key}},
+		body:   banks,
+	}, nil)
+	return err
+}
+
+// AnyETag can be given to UpdateSwiftCode and DeleteSwiftCode to write the record whatever its
+// version, instead of the ETag it was read with.
+const AnyETag = "*"
+
+// ErrETagRequired is returned by UpdateSwiftCode and DeleteSwiftCode when no ETag is given.
+var ErrETagRequired = errors.New("client: etag is required, use AnyETag to write whatever the version")
+
+// UpdateSwiftCode replaces the details of the bank identified by bank.SwiftCode, if it still has the
+// given ETag. The new ETag is returned.
+func (c *Client) UpdateSwiftCode(ctx context.Context, bank Bank, etag string) (string, error) {
+	if etag == "" {
+		return "", ErrETagRequired
+	}
+
+	res, err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/swift-codes/" + url.PathEscape(ptr.Value(bank.SwiftCode)),
+		header: http.Header{"If-Match": {etag}},
+		body:   bank,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return res.Header.Get("ETag"), nil
+}
+
+// DeleteSwiftCode removes the bank, if it still has the given ETag.
+func (c *Client) DeleteSwiftCode(ctx context.Context, swiftCode, etag string) error {
+	if etag == "" {
+		return ErrETagRequired
+	}
+
+	_, err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/swift-codes/" + url.PathEscape(swiftCode),
+		header: http.Header{"If-Match": {etag}},
+	}, nil)
+	return err
+}
+
+// CacheStats returns the statistics of the service's lookup cache. It fails with ErrNotFound if the
+// cache is disabled.
+func (c *Client) CacheStats(ctx context.Context) (*CacheStats, error) {
+	var stats CacheStats
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/cache/stats"}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Stats returns the number of SWIFT codes, headquarters, branches and institutions, in total and per country.
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/stats"}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListCountries returns the countries which have SWIFT codes, ordered by ISO2 code.
+func (c *Client) ListCountries(ctx context.Context) ([]CountryStats, error) {
+	var list CountryList
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/countries"}, &list); err != nil {
+		return nil, err
+	}
+	return list.Countries, nil
+}
+
+// GetCountryInfo returns the ISO 3166-1 codes and canonical name of a country, with the counts of its
+// SWIFT codes. It fails with ErrBadRequest if the code is not a known country.
+func (c *Client) GetCountryInfo(ctx context.Context, iso2Code string) (*Country, error) {
+	var country Country
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/countries/" + url.PathEscape(iso2Code)}, &country); err != nil {
+		return nil, err
+	}
+	return &country, nil
+}
+
+// GetInstitution returns the headquarter and the branches of the institution with the given BIC8, the
+// first eight characters of its SWIFT codes.
+func (c *Client) GetInstitution(ctx context.Context, bic8 string) (*Institution, error) {
+	var institution Institution
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/institutions/" + url.PathEscape(bic8)}, &institution); err != nil {
+		return nil, err
+	}
+	return &institution, nil
+}
+
+// ListInstitutions returns the institutions of a country ordered by BIC8, or of all countries if
+// iso2Code is empty.
+func (c *Client) ListInstitutions(ctx context.Context, iso2Code string) ([]InstitutionSummary, error) {
+	path := "/institutions"
+	if iso2Code != "" {
+		path += "?country=" + url.QueryEscape(iso2Code)
+	}
+	var list InstitutionList
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &list); err != nil {
+		return nil, err
+	}
+	return list.Institutions, nil
+}
+
+// OpenAPI returns the OpenAPI document describing the API.
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var document json.RawMessage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/openapi.json"}, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?next"?`)
+
+// nextPage returns the absolute URL of the next page announced in the Link header of res.
+func nextPage(res *http.Response) (string, bool) {
+	for _, link := range res.Header.Values("Link") {
+		match := nextLinkPattern.FindStringSubmatch(link)
+		if match == nil {
+			continue
+		}
+		next, err := url.Parse(match[1])
+		if err != nil {
+			return "", false
+		}
+		return res.Request.URL.ResolveReference(next).String(), true
+	}
+	return "", false
+}
+
+func asOfQuery(asOf time.Time) string {
+	return "?" + url.Values{"asOf": {asOf.UTC().Format(time.RFC3339)}}.Encode()
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Bank is a SWIFT code with the details of its bank. Fields are pointers, so that a request can tell
+// a missing field from an empty one; a headquarter includes its branches.
+type Bank struct {
+	Address       *string      `json:"address"`
+	BankName      *string      `json:"bankName"`
+	CountryISO2   *string      `json:"countryISO2"`
+	CountryName   *string      `json:"countryName"`
+	IsHeadquarter *bool        `json:"isHeadquarter"`
+	SwiftCode     *string      `json:"swiftCode"`
+	Branches      []BankBranch `json:"branches"`
+	// ValidFrom and ValidTo are set in the answers to point-in-time queries.
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+	ValidTo   *time.Time `json:"validTo,omitempty"`
+}
+
+type BankBranch struct {
+	Address       string `json:"address"`
+	BankName      string `json:"bankName"`
+	CountryISO2   string `json:"countryISO2"`
+	IsHeadquarter bool   `json:"isHeadquarter"`
+	SwiftCode     string `json:"swiftCode"`
+}
+
+type CountryBanks struct {
+	CountryISO2 string       `json:"countryISO2"`
+	CountryName string       `json:"countryName"`
+	SwiftCodes  []BankBranch `json:"swiftCode"`
+}
+
+// CacheStats describes the effectiveness of the service's lookup cache since it was started.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+type Stats struct {
+	SwiftCodes   int `json:"swiftCodes"`
+	Headquarters int `json:"headquarters"`
+	Branches     int `json:"branches"`
+	Institutions int `json:"institutions"`
+	// LastModified is when a record was last added, modified or removed, nil if there never was one.
+	LastModified          *time.Time      `json:"lastModified"`
+	Countries             []CountryStats  `json:"countries"`
+	LargestBranchNetworks []BranchNetwork `json:"largestBranchNetworks"`
+}
+
+type CountryStats struct {
+	CountryISO2  string `json:"countryISO2"`
+	CountryName  string `json:"countryName"`
+	SwiftCodes   int    `json:"swiftCodes"`
+	Headquarters int    `json:"headquarters"`
+	Branches     int    `json:"branches"`
+	Institutions int    `json:"institutions"`
+}
+
+// BranchNetwork is an institution with the number of its branches.
+type BranchNetwork struct {
+	Institution string `json:"institution"`
+	BankName    string `json:"bankName"`
+	CountryISO2 string `json:"countryISO2"`
+	Branches    int    `json:"branches"`
+}
+
+type CountryList struct {
+	Countries []CountryStats `json:"countries"`
+}
+
+// Country describes a country by its ISO 3166-1 codes, with the counts of its stored records. Name is
+// the ISO 3166 name of the country, CountryName its canonical name, which its records are stored with.
+type Country struct {
+	CountryISO2  string `json:"countryISO2"`
+	CountryISO3  string `json:"countryISO3"`
+	NumericCode  string `json:"numericCode"`
+	Name         string `json:"name"`
+	CountryName  string `json:"countryName"`
+	SwiftCodes   int    `json:"swiftCodes"`
+	Headquarters int    `json:"headquarters"`
+	Branches     int    `json:"branches"`
+	Institutions int    `json:"institutions"`
+}
+
+// Institution is a headquarter and the branches sharing its BIC8. Headquarter is nil if only
+// branches are stored.
+type Institution struct {
+	BIC8        string       `json:"bic8"`
+	BankName    string       `json:"bankName"`
+	CountryISO2 string       `json:"countryISO2"`
+	CountryName string       `json:"countryName"`
+	Headquarter *BankBranch  `json:"headquarter"`
+	Branches    []BankBranch `json:"branches"`
+}
+
+type InstitutionSummary struct {
+	BIC8           string `json:"bic8"`
+	BankName       string `json:"bankName"`
+	CountryISO2    string `json:"countryISO2"`
+	HasHeadquarter bool   `json:"hasHeadquarter"`
+	Branches       int    `json:"branches"`
+}
+
+type InstitutionList struct {
+	Institutions []InstitutionSummary `json:"institutions"`
+}
+
+// WebhookSubscription receives the changes of the SWIFT codes matching its optional filters.
+type WebhookSubscription struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	CountryISO2 *string   `json:"countryISO2,omitempty"`
+	BICPrefix   *string   `json:"bicPrefix,omitempty"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an attempt to deliver one event to one subscription. ReplayOf is set for
+// deliveries created by replaying an earlier one.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscriptionId"`
+	EventID        int64                 `json:"eventId"`
+	Event          json.RawMessage       `json:"event"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus,omitempty"`
+	LastError      *string               `json:"lastError,omitempty"`
+	ReplayOf       *int64                `json:"replayOf,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+}
+
+type ChangeAction string
+
+const (
+	ChangeAdd    ChangeAction = "add"
+	ChangeModify ChangeAction = "modify"
+	ChangeRemove ChangeAction = "remove"
+)
+
+// ChangeNotification announces a change of a SWIFT code.
+type ChangeNotification struct {
+	EventID     int64        `json:"eventId,omitempty"`
+	Action      ChangeAction `json:"action,omitempty"`
+	SwiftCode   string       `json:"swiftCode"`
+	CountryISO2 string       `json:"countryISO2,omitempty"`
+	OccurredAt  time.Time    `json:"occurredAt"`
+}
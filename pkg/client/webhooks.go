@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateWebhook creates a subscription. Its secret is returned only here, it is generated by the
+// service if subscription.Secret is empty.
+func (c *Client) CreateWebhook(ctx context.Context, subscription WebhookSubscription) (*WebhookSubscription, error) {
+	var created WebhookSubscription
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/webhooks", body: subscription}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	var list struct {
+		Webhooks []WebhookSubscription `json:"webhooks"`
+	}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks"}, &list); err != nil {
+		return nil, err
+	}
+	return list.Webhooks, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id int64) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: webhookPath(id)}, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// UpdateWebhook replaces the subscription identified by subscription.ID. An empty Secret keeps the
+// current one.
+func (c *Client) UpdateWebhook(ctx context.Context, subscription WebhookSubscription) (*WebhookSubscription, error) {
+	var updated WebhookSubscription
+	_, err := c.do(ctx, request{method: http.MethodPut, path: webhookPath(subscription.ID), body: subscription}, &updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: webhookPath(id)}, nil)
+	return err
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a subscription, newest first. A limit of
+// 0 uses the service's default.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id int64, limit int) ([]WebhookDelivery, error) {
+	path := webhookPath(id) + "/deliveries"
+	if limit > 0 {
+		path += "?" + url.Values{"limit": {strconv.Itoa(limit)}}.Encode()
+	}
+
+	var list struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+	}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &list); err != nil {
+		return nil, err
+	}
+	return list.Deliveries, nil
+}
+
+func (c *Client) GetWebhookDelivery(ctx context.Context, id, deliveryID int64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	_, err := c.do(ctx, request{method: http.MethodGet, path: deliveryPath(id, deliveryID)}, &delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ReplayWebhookDelivery delivers the event of a delivery again and returns the new delivery.
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, id, deliveryID int64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	_, err := c.do(ctx, request{method: http.MethodPost, path: deliveryPath(id, deliveryID) + "/replay"}, &delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func webhookPath(id int64) string {
+	return "/webhooks/" + strconv.FormatInt(id, 10)
+}
+
+func deliveryPath(id, deliveryID int64) string {
+	return webhookPath(id) + "/deliveries/" + strconv.FormatInt(deliveryID, 10)
+}
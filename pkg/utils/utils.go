@@ -1,12 +1,180 @@
 package utils
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+	ContentTypeCSV  = "text/csv"
+)
+
+// supportedContentTypes is ordered by preference, used when the client accepts several types equally.
+var supportedContentTypes = []string{ContentTypeJSON, ContentTypeXML, ContentTypeCSV}
+
+// CSVMarshaler is implemented by response types that can be rendered as CSV.
+// The first record is expected to be the header row.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+type messageResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Message string   `json:"message" xml:"message"`
+}
+
 func WriteJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
+
+func WriteXML(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", ContentTypeXML)
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
+
+func WriteCSV(w http.ResponseWriter, status int, v CSVMarshaler) {
+	records, err := v.MarshalCSV()
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError, messageResponse{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeCSV+"; charset=utf-8")
+	w.WriteHeader(status)
+	csv.NewWriter(w).WriteAll(records)
+}
+
+// WriteResponse renders v in the representation requested by the Accept header of r.
+// JSON is used when the header is missing. If none of the acceptable types is supported
+// (or v cannot be rendered as CSV), 406 Not Acceptable is returned instead.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+
+	contentType, ok := NegotiateContentType(r.Header.Get("Accept"))
+	if !ok {
+		WriteNotAcceptable(w)
+		return
+	}
+
+	switch contentType {
+	case ContentTypeXML:
+		WriteXML(w, status, v)
+	case ContentTypeCSV:
+		m, ok := v.(CSVMarshaler)
+		if !ok {
+			WriteNotAcceptable(w)
+			return
+		}
+		WriteCSV(w, status, m)
+	default:
+		WriteJSON(w, status, v)
+	}
+}
+
+func WriteNotAcceptable(w http.ResponseWriter) {
+	WriteJSON(w, http.StatusNotAcceptable, messageResponse{
+		Message: "Supported media types are: " + strings.Join(supportedContentTypes, ", "),
+	})
+}
+
+// RequireAcceptable rejects requests whose Accept header cannot be satisfied before
+// they reach the handler, so that no side effects happen for a response nobody can read.
+func RequireAcceptable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := NegotiateContentType(r.Header.Get("Accept")); !ok {
+			w.Header().Add("Vary", "Accept")
+			WriteNotAcceptable(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// NegotiateContentType picks the best supported content type for the given Accept header value.
+func NegotiateContentType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeJSON, true
+	}
+
+	ranges := parseAccept(accept)
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, mr := range ranges {
+		if mr.quality <= 0 {
+			break
+		}
+		for _, supported := range supportedContentTypes {
+			if mediaTypeMatches(mr.mediaType, supported) && !isExcluded(ranges, supported) {
+				return supported, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+func mediaTypeMatches(pattern, contentType string) bool {
+	if pattern == "*/*" || pattern == contentType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*"))
+	}
+	// text/xml is treated as a synonym of application/xml
+	return pattern == "text/xml" && contentType == ContentTypeXML
+}
+
+// isExcluded reports whether the client explicitly refused the content type with q=0.
+func isExcluded(ranges []mediaRange, contentType string) bool {
+	for _, mr := range ranges {
+		if mr.quality <= 0 && mr.mediaType == contentType {
+			return true
+		}
+	}
+	return false
+}
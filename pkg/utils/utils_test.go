@@ -77,3 +77,196 @@ func TestWriteJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := []struct {
+		name         string
+		accept       string
+		expectedType string
+		expectedOk   bool
+	}{
+		{name: "missing header defaults to JSON", accept: "", expectedType: ContentTypeJSON, expectedOk: true},
+		{name: "wildcard", accept: "*/*", expectedType: ContentTypeJSON, expectedOk: true},
+		{name: "exact XML", accept: "application/xml", expectedType: ContentTypeXML, expectedOk: true},
+		{name: "text/xml synonym", accept: "text/xml", expectedType: ContentTypeXML, expectedOk: true},
+		{name: "exact CSV", accept: "text/csv", expectedType: ContentTypeCSV, expectedOk: true},
+		{name: "text wildcard", accept: "text/*", expectedType: ContentTypeCSV, expectedOk: true},
+		{name: "quality ordering", accept: "application/json;q=0.5, text/csv;q=0.9", expectedType: ContentTypeCSV, expectedOk: true},
+		{name: "browser style header", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", expectedType: ContentTypeXML, expectedOk: true},
+		{name: "explicit exclusion", accept: "*/*, application/json;q=0", expectedType: ContentTypeXML, expectedOk: true},
+		{name: "unsupported type", accept: "application/pdf", expectedOk: false},
+		{name: "everything refused", accept: "text/csv;q=0", expectedOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, ok := NegotiateContentType(tt.accept)
+			if ok != tt.expectedOk {
+				t.Fatalf("expected ok %v, got %v", tt.expectedOk, ok)
+			}
+			if contentType != tt.expectedType {
+				t.Errorf("expected content type %q, got %q", tt.expectedType, contentType)
+			}
+		})
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	boolPtr := func(b bool) *bool { return &b }
+
+	bank := storage.Bank{
+		Address:       strPtr("123 Test Street"),
+		BankName:      strPtr("Test Bank"),
+		CountryISO2:   strPtr("PL"),
+		CountryName:   strPtr("POLAND"),
+		IsHeadquarter: boolPtr(true),
+		SwiftCode:     strPtr("TESTPL33XXX"),
+		Branches: []storage.BankBranch{
+			{Address: "Branch Street", BankName: "Test Bank", CountryISO2: "PL", SwiftCode: "TESTPL33ABC"},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		accept              string
+		status              int
+		data                any
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "JSON by default",
+			data:                storage.Response{Message: "Success"},
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: ContentTypeJSON,
+			expectedBody:        `{"message":"Success"}`,
+		},
+		{
+			name:                "XML bank",
+			accept:              "application/xml",
+			data:                bank,
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: ContentTypeXML,
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<bank><address>123 Test Street</address><bankName>Test Bank</bankName><countryISO2>PL</countryISO2>` +
+				`<countryName>POLAND</countryName><isHeadquarter>true</isHeadquarter><swiftCode>TESTPL33XXX</swiftCode>` +
+				`<branches><branch><address>Branch Street</address><bankName>Test Bank</bankName><countryISO2>PL</countryISO2>` +
+				`<isHeadquarter>false</isHeadquarter><swiftCode>TESTPL33ABC</swiftCode></branch></branches></bank>`,
+		},
+		{
+			name:                "CSV bank with branches",
+			accept:              "text/csv",
+			data:                bank,
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: ContentTypeCSV + "; charset=utf-8",
+			expectedBody: "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n" +
+				"TESTPL33XXX,Test Bank,123 Test Street,PL,POLAND,true\n" +
+				"TESTPL33ABC,Test Bank,Branch Street,PL,POLAND,false",
+		},
+		{
+			name:   "CSV country banks",
+			accept: "text/csv",
+			data: storage.CountryBanks{
+				CountryISO2: "PL",
+				CountryName: "POLAND",
+				SwiftCodes: []storage.BankBranch{
+					{Address: "Main, 1", BankName: "Test Bank", CountryISO2: "PL", IsHeadquarter: true, SwiftCode: "TESTPL33XXX"},
+				},
+			},
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: ContentTypeCSV + "; charset=utf-8",
+			expectedBody: "swiftCode,bankName,address,countryISO2,countryName,isHeadquarter\n" +
+				`TESTPL33XXX,Test Bank,"Main, 1",PL,POLAND,true`,
+		},
+		{
+			name:                "CSV error message",
+			accept:              "text/csv",
+			data:                storage.Response{Message: "Given Swift Code not found"},
+			status:              http.StatusNotFound,
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: ContentTypeCSV + "; charset=utf-8",
+			expectedBody:        "message\nGiven Swift Code not found",
+		},
+		{
+			name:                "CSV requested for type without CSV representation",
+			accept:              "text/csv",
+			data:                map[string]string{"message": "Success"},
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: ContentTypeJSON,
+			expectedBody:        `{"message":"Supported media types are: application/json, application/xml, text/csv"}`,
+		},
+		{
+			name:                "unsupported media type",
+			accept:              "application/pdf",
+			data:                bank,
+			status:              http.StatusOK,
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: ContentTypeJSON,
+			expectedBody:        `{"message":"Supported media types are: application/json, application/xml, text/csv"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+
+			WriteResponse(recorder, req, tt.status, tt.data)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, recorder.Code)
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("expected Content-Type %s, got %s", tt.expectedContentType, contentType)
+			}
+
+			if vary := recorder.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("expected Vary Accept, got %s", vary)
+			}
+
+			body := strings.TrimSpace(recorder.Body.String())
+			if body != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestRequireAcceptable(t *testing.T) {
+	called := false
+	handler := RequireAcceptable(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/swift-codes", nil)
+	req.Header.Set("Accept", "image/png")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNotAcceptable {
+		t.Errorf("expected status %d, got %d", http.StatusNotAcceptable, recorder.Code)
+	}
+	if called {
+		t.Error("expected handler not to be called for unacceptable request")
+	}
+
+	req.Header.Set("Accept", "text/csv")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if !called || recorder.Code != http.StatusOK {
+		t.Errorf("expected handler to be called, got status %d", recorder.Code)
+	}
+}